@@ -0,0 +1,178 @@
+-- name: GetTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: CreateTodo :exec
+INSERT INTO todos (id, title, is_completed, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- The version check makes the update fail, rather than overwrite, when the
+-- todo changed since it was read
+-- name: UpdateTodo :execresult
+UPDATE todos
+SET title = $2, is_completed = $3, due_at = $4, priority = $5, list_id = $6, parent_id = $7, position = $8,
+    recurrence_rule = $9, recurrence_tz = $10, version = version + 1
+WHERE id = $1 AND version = $11 AND deleted_at IS NULL;
+
+-- Reordering is not an edit, so it leaves out the columns the updated_at
+-- trigger watches
+-- name: UpdateTodoPosition :exec
+UPDATE todos
+SET position = $2, version = version + 1
+WHERE id = $1;
+
+-- name: DeleteTodo :execresult
+DELETE FROM todos
+WHERE id = $1;
+
+-- name: TrashTodos :exec
+UPDATE todos
+SET deleted_at = @deleted_at, trash_id = @trash_id, version = version + 1
+WHERE id = ANY(@ids::uuid[]) AND deleted_at IS NULL;
+
+-- name: RestoreTodos :exec
+UPDATE todos
+SET deleted_at = NULL, trash_id = '', version = version + 1
+WHERE id = ANY(@ids::uuid[]);
+
+-- name: GetTrashedTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: ListTrash :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id;
+
+-- name: PurgeTrash :execresult
+DELETE FROM todos
+WHERE deleted_at IS NOT NULL;
+
+-- name: PurgeTrashBefore :execresult
+DELETE FROM todos
+WHERE deleted_at < $1;
+
+-- ILIKE ignores case as MySQL's collation does; backslash is LIKE's default escape
+-- name: GetTodoByTitle :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE title ILIKE $1 AND deleted_at IS NULL
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountSubtasks :many
+SELECT parent_id::uuid AS parent_id,
+    COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE is_completed) AS completed
+FROM todos
+WHERE parent_id = ANY(@parent_ids::uuid[]) AND deleted_at IS NULL
+GROUP BY parent_id;
+
+-- name: ListTags :many
+SELECT id, name, created_at
+FROM tags
+ORDER BY lower(name), id;
+
+-- name: GetTag :one
+SELECT id, name, created_at
+FROM tags
+WHERE id = $1;
+
+-- name: CreateTag :exec
+INSERT INTO tags (id, name)
+VALUES ($1, $2);
+
+-- name: RenameTag :execresult
+UPDATE tags
+SET name = $2
+WHERE id = $1;
+
+-- name: DeleteTag :execresult
+DELETE FROM tags
+WHERE id = $1;
+
+-- name: AttachTag :execresult
+INSERT INTO todo_tags (todo_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DetachTag :execresult
+DELETE FROM todo_tags
+WHERE todo_id = $1 AND tag_id = $2;
+
+-- Tags are part of a todo, so changing them moves it to a new version
+-- name: BumpTodoVersion :exec
+UPDATE todos
+SET version = version + 1
+WHERE id = $1;
+
+-- name: ListTagsForTodos :many
+SELECT todo_tags.todo_id, tags.id, tags.name, tags.created_at
+FROM todo_tags
+JOIN tags ON tags.id = todo_tags.tag_id
+WHERE todo_tags.todo_id = ANY(@todo_ids::uuid[])
+ORDER BY lower(tags.name), tags.id;
+
+-- name: ListLists :many
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    COUNT(todos.id) FILTER (WHERE todos.is_completed) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+GROUP BY lists.id
+ORDER BY lists.created_at, lists.id;
+
+-- name: GetList :one
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    COUNT(todos.id) FILTER (WHERE todos.is_completed) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+WHERE lists.id = $1
+GROUP BY lists.id;
+
+-- name: CreateList :exec
+INSERT INTO lists (id, name)
+VALUES ($1, $2);
+
+-- name: RenameList :execresult
+UPDATE lists
+SET name = $2
+WHERE id = $1;
+
+-- A list is deleted only while it holds no todos, trashed ones included, so
+-- that deleting a list never bypasses the trash
+-- name: DeleteList :execresult
+DELETE FROM lists
+WHERE lists.id = $1
+  AND NOT EXISTS (SELECT 1 FROM todos WHERE todos.list_id = $1);
+
+-- name: ClaimIdempotencyKey :exec
+INSERT INTO idempotency_keys (idempotency_key, request_hash, claim_token, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetIdempotencyKey :one
+SELECT idempotency_key, request_hash, status_code, response_header, response_body, expires_at, created_at, claim_token
+FROM idempotency_keys
+WHERE idempotency_key = $1;
+
+-- Only the claim that is still in flight under the same token is completed
+-- name: CompleteIdempotencyKey :execresult
+UPDATE idempotency_keys
+SET status_code = $1, response_header = $2, response_body = $3, expires_at = $4
+WHERE idempotency_key = $5 AND claim_token = $6 AND status_code IS NULL;
+
+-- A claim that lapsed and was taken over has another token and is kept
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = $1 AND claim_token = $2 AND status_code IS NULL;
+
+-- name: DeleteExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = $1 AND expires_at <= $2;
+
+-- name: PurgeIdempotencyKeys :execresult
+DELETE FROM idempotency_keys
+WHERE expires_at <= $1;
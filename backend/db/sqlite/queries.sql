@@ -0,0 +1,180 @@
+-- name: GetTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: CreateTodo :exec
+INSERT INTO todos (id, title, is_completed, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- The version check makes the update fail, rather than overwrite, when the
+-- todo changed since it was read
+-- name: UpdateTodo :execresult
+UPDATE todos
+SET title = ?, is_completed = ?, due_at = ?, priority = ?, list_id = ?, parent_id = ?, position = ?,
+    recurrence_rule = ?, recurrence_tz = ?, version = version + 1
+WHERE id = ? AND version = ? AND deleted_at IS NULL;
+
+-- Reordering is not an edit, so it leaves out the columns the updated_at
+-- trigger watches
+-- name: UpdateTodoPosition :exec
+UPDATE todos
+SET position = ?, version = version + 1
+WHERE id = ?;
+
+-- name: DeleteTodo :execresult
+DELETE FROM todos
+WHERE id = ?;
+
+-- name: TrashTodos :exec
+UPDATE todos
+SET deleted_at = ?, trash_id = ?, version = version + 1
+WHERE id IN (sqlc.slice('ids')) AND deleted_at IS NULL;
+
+-- name: RestoreTodos :exec
+UPDATE todos
+SET deleted_at = NULL, trash_id = '', version = version + 1
+WHERE id IN (sqlc.slice('ids'));
+
+-- name: GetTrashedTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: ListTrash :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id;
+
+-- name: PurgeTrash :execresult
+DELETE FROM todos
+WHERE deleted_at IS NOT NULL;
+
+-- name: PurgeTrashBefore :execresult
+DELETE FROM todos
+WHERE deleted_at < ?;
+
+-- SQLite's LIKE has no escape character unless one is named
+-- name: GetTodoByTitle :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE title LIKE ? ESCAPE '\' AND deleted_at IS NULL
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountSubtasks :many
+SELECT parent_id,
+    COUNT(*) AS total,
+    CAST(COALESCE(SUM(is_completed), 0) AS INTEGER) AS completed
+FROM todos
+WHERE parent_id IN (sqlc.slice('parent_ids')) AND deleted_at IS NULL
+GROUP BY parent_id;
+
+-- name: ListExists :one
+SELECT EXISTS (SELECT 1 FROM lists WHERE id = ?);
+
+-- name: ListTags :many
+SELECT id, name, created_at
+FROM tags
+ORDER BY name, id;
+
+-- name: GetTag :one
+SELECT id, name, created_at
+FROM tags
+WHERE id = ?;
+
+-- name: CreateTag :exec
+INSERT INTO tags (id, name)
+VALUES (?, ?);
+
+-- name: RenameTag :execresult
+UPDATE tags
+SET name = ?
+WHERE id = ?;
+
+-- name: DeleteTag :execresult
+DELETE FROM tags
+WHERE id = ?;
+
+-- name: AttachTag :execresult
+INSERT OR IGNORE INTO todo_tags (todo_id, tag_id)
+VALUES (?, ?);
+
+-- name: DetachTag :execresult
+DELETE FROM todo_tags
+WHERE todo_id = ? AND tag_id = ?;
+
+-- Tags are part of a todo, so changing them moves it to a new version
+-- name: BumpTodoVersion :exec
+UPDATE todos
+SET version = version + 1
+WHERE id = ?;
+
+-- name: ListTagsForTodos :many
+SELECT todo_tags.todo_id, tags.id, tags.name, tags.created_at
+FROM todo_tags
+JOIN tags ON tags.id = todo_tags.tag_id
+WHERE todo_tags.todo_id IN (sqlc.slice('todo_ids'))
+ORDER BY tags.name, tags.id;
+
+-- name: ListLists :many
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    CAST(COALESCE(SUM(todos.is_completed), 0) AS INTEGER) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+GROUP BY lists.id
+ORDER BY lists.created_at, lists.id;
+
+-- name: GetList :one
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    CAST(COALESCE(SUM(todos.is_completed), 0) AS INTEGER) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+WHERE lists.id = ?
+GROUP BY lists.id;
+
+-- name: CreateList :exec
+INSERT INTO lists (id, name)
+VALUES (?, ?);
+
+-- name: RenameList :execresult
+UPDATE lists
+SET name = ?
+WHERE id = ?;
+
+-- A list is deleted only while it holds no todos, trashed ones included, so
+-- that deleting a list never bypasses the trash
+-- name: DeleteList :execresult
+DELETE FROM lists
+WHERE lists.id = ?1
+  AND NOT EXISTS (SELECT 1 FROM todos WHERE todos.list_id = ?1);
+
+-- name: ClaimIdempotencyKey :exec
+INSERT INTO idempotency_keys (idempotency_key, request_hash, claim_token, expires_at)
+VALUES (?, ?, ?, ?);
+
+-- name: GetIdempotencyKey :one
+SELECT idempotency_key, request_hash, status_code, response_header, response_body, expires_at, created_at, claim_token
+FROM idempotency_keys
+WHERE idempotency_key = ?;
+
+-- Only the claim that is still in flight under the same token is completed
+-- name: CompleteIdempotencyKey :execresult
+UPDATE idempotency_keys
+SET status_code = ?, response_header = ?, response_body = ?, expires_at = ?
+WHERE idempotency_key = ? AND claim_token = ? AND status_code IS NULL;
+
+-- A claim that lapsed and was taken over has another token and is kept
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = ? AND claim_token = ? AND status_code IS NULL;
+
+-- name: DeleteExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = ? AND expires_at <= ?;
+
+-- name: PurgeIdempotencyKeys :execresult
+DELETE FROM idempotency_keys
+WHERE expires_at <= ?;
@@ -0,0 +1,40 @@
+package domain
+
+import "fmt"
+
+var (
+	// ErrInvalidToken is returned when a call carries no API token or one
+	// that is not configured
+	ErrInvalidToken = newError(ErrUnauthenticated, "a valid API token is required")
+	// ErrReadOnlyToken is returned when a call that writes carries a token
+	// that only grants read access
+	ErrReadOnlyToken = newError(ErrForbidden, "the API token only grants read access")
+)
+
+// Access is what a call does with the data, and what an API token allows
+type Access string
+
+const (
+	AccessRead  Access = "read"
+	AccessWrite Access = "write"
+)
+
+// IsValid reports whether a is a known access
+func (a Access) IsValid() bool {
+	return a == AccessRead || a == AccessWrite
+}
+
+// Allows reports whether a token granting a may make a call needing need.
+// Write access includes read access.
+func (a Access) Allows(need Access) bool {
+	return a == AccessWrite || a == need
+}
+
+// ParseAccess converts an access name to an Access
+func ParseAccess(s string) (Access, error) {
+	a := Access(s)
+	if !a.IsValid() {
+		return "", fmt.Errorf("invalid access %q: must be read or write", s)
+	}
+	return a, nil
+}
@@ -0,0 +1,63 @@
+package domain
+
+// MaxBatchSize is the largest number of operations a batch may hold, which
+// bounds how long a batch keeps its transaction open
+const MaxBatchSize = 100
+
+// ErrBatchAborted is the result of the operations of an atomic batch that
+// were rolled back, or never run, because another operation failed
+var ErrBatchAborted = newError(ErrConflict, "the operation was rolled back because another operation of the batch failed")
+
+// BatchAction is what a batch operation does to a todo
+type BatchAction string
+
+const (
+	BatchCreate BatchAction = "create"
+	BatchUpdate BatchAction = "update"
+	BatchDelete BatchAction = "delete"
+)
+
+// IsValid reports whether a is a known action
+func (a BatchAction) IsValid() bool {
+	switch a {
+	case BatchCreate, BatchUpdate, BatchDelete:
+		return true
+	}
+	return false
+}
+
+// BatchMode decides what a failing operation does to the rest of its batch
+type BatchMode string
+
+const (
+	// BatchAtomic applies every operation or none of them
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort undoes only the failing operations and keeps the others
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// IsValid reports whether m is a known mode
+func (m BatchMode) IsValid() bool {
+	switch m {
+	case BatchAtomic, BatchBestEffort:
+		return true
+	}
+	return false
+}
+
+// BatchOperation is one create, update or delete of a batch. Create holds
+// the new todo of a create; ID names the todo of an update or delete, and
+// Patch the changes of an update.
+type BatchOperation struct {
+	Action BatchAction
+	ID     string
+	Create CreateTodoParams
+	Patch  TodoPatch
+}
+
+// BatchResult is the outcome of one batch operation: the created or updated
+// todo, nothing for a delete, or the error the operation failed with
+type BatchResult struct {
+	Todo *Todo
+	Err  error
+}
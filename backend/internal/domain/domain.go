@@ -1,24 +1,351 @@
 package domain
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
-// Todo represents a todo item entity
+var (
+	// ErrTodoNotFound is returned when a todo does not exist, or is in the trash
+	// for operations on live todos
+	ErrTodoNotFound = newError(ErrNotFound, "todo not found")
+	// ErrInvalidListID is returned when a todo's list_id refers to a list that does not exist
+	ErrInvalidListID error = NewValidationError("list_id", "list not found")
+	// ErrSubtaskList is returned when a subtask is put in a list other than its parent's
+	ErrSubtaskList error = NewValidationError("list_id", "a subtask must be in its parent's list")
+	// ErrParentNotFound is returned when a todo's parent_id refers to a todo that does not exist
+	ErrParentNotFound error = NewValidationError("parent_id", "parent todo not found")
+	// ErrParentCycle is returned when a todo would become its own ancestor
+	ErrParentCycle = newError(ErrConflict, "a todo cannot be a subtask of itself or of its own subtasks")
+	// ErrMoveTargetNotFound is returned when a todo is moved next to a todo that does not exist
+	ErrMoveTargetNotFound = newError(ErrValidation, "move target not found")
+	// ErrInvalidMoveTarget is returned when a todo is moved next to itself or to a todo in another list
+	ErrInvalidMoveTarget = newError(ErrValidation, "a todo can only be moved next to another todo in the same list")
+	// ErrVersionMismatch is returned when a todo changed after the version an update was based on
+	ErrVersionMismatch = newError(ErrConflict, "the todo was modified by another request")
+)
+
+// Todo represents a todo item entity.
+// ParentID is the todo this one is a subtask of, nil for top-level todos;
+// Subtasks rolls up the completion of its direct subtasks. Position orders
+// the todo manually within its list (see PositionBetween). A todo with a
+// Recurrence is one occurrence of a repeating task. DeletedAt and TrashID
+// are set while the todo is in the trash; TrashID identifies the operation
+// that trashed it and is shared by the todos trashed along with it.
+//
+// Version goes up with every write to the todo, including its position,
+// tags and trash state. The Subtasks rollup and the names of the Tags come
+// from other todos and tags and do not change it, so an entity tag of the
+// todo has to cover them as well as the version.
 type Todo struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	IsCompleted bool      `json:"is_completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	IsCompleted bool            `json:"is_completed"`
+	DueAt       *time.Time      `json:"due_at"`
+	Priority    Priority        `json:"priority"`
+	Tags        []Tag           `json:"tags"`
+	ListID      string          `json:"list_id"`
+	ParentID    *string         `json:"parent_id"`
+	Subtasks    SubtaskProgress `json:"subtasks"`
+	Position    string          `json:"position"`
+	Recurrence  *Recurrence     `json:"recurrence"`
+	DeletedAt   *time.Time      `json:"deleted_at,omitempty"`
+	TrashID     string          `json:"-"`
+	Version     int             `json:"version"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// SubtaskProgress reports how many of a todo's direct subtasks are done
+type SubtaskProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// TodoTree is a todo together with its subtasks, recursively
+type TodoTree struct {
+	Todo
+	Children []*TodoTree `json:"children"`
+}
+
+// HasTag reports whether the todo carries a tag with the given name (case-insensitive)
+func (t Todo) HasTag(name string) bool {
+	for _, tag := range t.Tags {
+		if strings.EqualFold(tag.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Priority is the importance of a todo. Higher values are more important.
+type Priority int8
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+// Priorities lists every priority from least to most important
+var Priorities = []Priority{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+var priorityNames = map[Priority]string{
+	PriorityNone:   "none",
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+// IsValid reports whether p is a known priority
+func (p Priority) IsValid() bool {
+	_, ok := priorityNames[p]
+	return ok
+}
+
+// String returns the API name of the priority
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int8(p))
+}
+
+// ParsePriority converts an API name to a Priority
+func ParsePriority(s string) (Priority, error) {
+	for p, name := range priorityNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q: must be one of none, low, medium, high, urgent", s)
+}
+
+// MarshalText encodes the priority by name, so JSON carries "high" rather than 3
+func (p Priority) MarshalText() ([]byte, error) {
+	if !p.IsValid() {
+		return nil, fmt.Errorf("invalid priority %d", int8(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a priority name
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// CreateTodoParams holds the fields used to create a todo
+type CreateTodoParams struct {
+	Title    string
+	DueAt    *time.Time
+	Priority Priority
+	// ListID is the list to create the todo in; empty means DefaultListID,
+	// or the parent's list for a subtask, which cannot be in any other
+	ListID string
+	// ParentID makes the new todo a subtask
+	ParentID *string
+	// Position is set by the usecase to place the todo at the end of its list
+	Position   string
+	Recurrence *Recurrence
+}
+
+// UpdateTodoParams holds the full set of mutable fields written by an update.
+// Version is the version the update is based on; the write fails with
+// ErrVersionMismatch if the todo has moved past it.
+type UpdateTodoParams struct {
+	ID          string
+	Title       string
+	IsCompleted bool
+	DueAt       *time.Time
+	Priority    Priority
+	ListID      string
+	ParentID    *string
+	Position    string
+	Recurrence  *Recurrence
+	Version     int
+}
+
+// TodoMove places a todo directly before or after another todo of the same
+// list. Exactly one of Before and After is set.
+type TodoMove struct {
+	Before string
+	After  string
+}
+
+// Optional is a patch field that distinguishes an absent value from an
+// explicitly provided one. Null is true when the field was given as JSON null.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// Some returns an Optional holding v
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// UnmarshalJSON marks the field as set; it is only called when the key is present
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	o.Null = bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+	return json.Unmarshal(data, &o.Value)
+}
+
+// TodoPatch describes a partial update of a todo following JSON Merge Patch
+// (RFC 7396) semantics: absent fields are left unchanged and null clears a
+// nullable field.
+type TodoPatch struct {
+	Title       Optional[string]
+	IsCompleted Optional[bool]
+	DueAt       Optional[*time.Time]
+	Priority    Optional[Priority]
+	ListID      Optional[string]
+	// ParentID moves the todo under another todo; null makes it top-level
+	ParentID Optional[*string]
+	// Recurrence sets the repeat rule; null stops the todo repeating
+	Recurrence Optional[*Recurrence]
+	// IfVersion, when non-zero, is the version the todo must still be at for
+	// the patch to apply (an If-Match precondition). It is not a change.
+	IfVersion int
+}
+
+// IsEmpty reports whether the patch changes nothing
+func (p TodoPatch) IsEmpty() bool {
+	return !p.Title.Set && !p.IsCompleted.Set && !p.DueAt.Set && !p.Priority.Set && !p.ListID.Set && !p.ParentID.Set &&
+		!p.Recurrence.Set
+}
+
+// Apply merges the patch onto todo and returns the resulting update parameters
+func (p TodoPatch) Apply(todo Todo) UpdateTodoParams {
+	params := UpdateTodoParams{
+		ID:          todo.ID,
+		Title:       todo.Title,
+		IsCompleted: todo.IsCompleted,
+		DueAt:       todo.DueAt,
+		Priority:    todo.Priority,
+		ListID:      todo.ListID,
+		ParentID:    todo.ParentID,
+		Position:    todo.Position,
+		Recurrence:  todo.Recurrence,
+		Version:     todo.Version,
+	}
+	if p.Title.Set {
+		params.Title = p.Title.Value
+	}
+	if p.IsCompleted.Set {
+		params.IsCompleted = p.IsCompleted.Value
+	}
+	if p.DueAt.Set {
+		params.DueAt = p.DueAt.Value
+	}
+	if p.Priority.Set {
+		params.Priority = p.Priority.Value
+	}
+	if p.ListID.Set {
+		params.ListID = p.ListID.Value
+	}
+	if p.ParentID.Set {
+		params.ParentID = p.ParentID.Value
+	}
+	if p.Recurrence.Set {
+		params.Recurrence = p.Recurrence.Value
+	}
+	return params
+}
+
+// SearchMode selects how a title search query is matched
+type SearchMode string
+
+const (
+	// SearchModeSubstring matches titles containing the query anywhere
+	SearchModeSubstring SearchMode = "substring"
+	// SearchModePrefix matches titles starting with the query
+	SearchModePrefix SearchMode = "prefix"
+	// SearchModeAllWords matches titles containing every whitespace-separated word, in any order
+	SearchModeAllWords SearchMode = "all_words"
+)
+
+// IsValid reports whether m is a known search mode
+func (m SearchMode) IsValid() bool {
+	switch m {
+	case SearchModeSubstring, SearchModePrefix, SearchModeAllWords:
+		return true
+	}
+	return false
+}
+
+// TodoSearch is a case-insensitive title search. Query is matched literally;
+// LIKE wildcards in it carry no special meaning.
+type TodoSearch struct {
+	Query string
+	Mode  SearchMode
+}
+
+// Terms returns the strings every matching title must contain
+func (s TodoSearch) Terms() []string {
+	if s.Mode == SearchModeAllWords {
+		return strings.Fields(s.Query)
+	}
+	return []string{s.Query}
 }
 
-// TodoRepository defines the interface for todo data access
+// TodoRepository defines the interface for todo data access.
+// Todos are returned with their Tags and Subtasks progress filled in.
+// Operations on a single todo return ErrTodoNotFound if it does not exist.
 type TodoRepository interface {
-	List(ctx context.Context) ([]Todo, error)
+	// List returns at most query.Page.Limit todos matching query.Filter that
+	// follow query.Page.Cursor, ordered by query.Sort. A zero limit returns all of them.
+	// Like every read except ListTrash, it skips trashed todos.
+	List(ctx context.Context, query TodoQuery) ([]Todo, error)
 	GetByID(ctx context.Context, id string) (*Todo, error)
-	Create(ctx context.Context, title string) (*Todo, error)
-	Update(ctx context.Context, id string, title string, isCompleted bool) (*Todo, error)
+	// Create and Update return ErrInvalidListID if params.ListID does not exist.
+	// A subtask is always in its parent's list; the usecase keeps it there,
+	// moving subtasks along with their parent.
+	// Update also returns ErrVersionMismatch unless the todo is still at params.Version.
+	Create(ctx context.Context, params CreateTodoParams) (*Todo, error)
+	Update(ctx context.Context, params UpdateTodoParams) (*Todo, error)
+	// Reposition changes only the position of a todo, leaving UpdatedAt as it is
+	Reposition(ctx context.Context, id string, position string) error
+	// Delete permanently deletes a single todo. Subtasks are handled by the usecase.
 	Delete(ctx context.Context, id string) error
+	// Trash moves todos to the trash as one operation, stamping DeletedAt
+	// with at and TrashID with trashID. Todos already in the trash keep
+	// their original stamps.
+	Trash(ctx context.Context, ids []string, trashID string, at time.Time) error
+	// Restore takes todos out of the trash
+	Restore(ctx context.Context, ids []string) error
+	// GetTrashed returns a trashed todo, or ErrTodoNotFound if it is not in the trash
+	GetTrashed(ctx context.Context, id string) (*Todo, error)
+	// TrashedSubtree returns a trashed todo followed by its trashed
+	// descendants, parents before their children. It returns nothing if the
+	// todo is not in the trash.
+	TrashedSubtree(ctx context.Context, id string) ([]Todo, error)
+	// ListTrash returns the trashed todos, most recently trashed first
+	ListTrash(ctx context.Context) ([]Todo, error)
+	// PurgeTrash permanently deletes the todos trashed before cutoff, or the
+	// whole trash if cutoff is zero, and returns how many were deleted
+	PurgeTrash(ctx context.Context, cutoff time.Time) (int, error)
+	// Subtree returns the todo followed by all its descendants, parents
+	// before their children. It returns nothing if the todo does not exist.
+	Subtree(ctx context.Context, id string) ([]Todo, error)
+	Search(ctx context.Context, search TodoSearch) ([]Todo, error)
+	CountByPriority(ctx context.Context, filter TodoFilter) (map[Priority]int, error)
+	// AttachTag labels a todo; attaching a tag twice is a no-op.
+	// It returns ErrTagNotFound if the tag does not exist.
+	AttachTag(ctx context.Context, todoID string, tagID string) error
+	// DetachTag removes a label; detaching a tag that is not attached is a no-op
+	DetachTag(ctx context.Context, todoID string, tagID string) error
 }
@@ -0,0 +1,315 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor_RoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	want := NewCursor(Todo{ID: "abc", CreatedAt: created}, DefaultSort, true)
+
+	got, err := DecodeCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.CreatedAt == nil || !got.CreatedAt.Equal(created) || got.ID != want.ID ||
+		got.Backward != want.Backward || got.Sort != want.Sort {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if _, err := DecodeCursor("not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestTodoSort_Compare_DueAtNullsLast(t *testing.T) {
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	dated := Todo{ID: "a", DueAt: &early}
+	later := Todo{ID: "b", DueAt: &late}
+	undated := Todo{ID: "c"}
+
+	for _, dir := range []SortDirection{SortAsc, SortDesc} {
+		sort := TodoSort{Field: SortByDueAt, Direction: dir}
+		if sort.Compare(dated, undated) >= 0 || sort.Compare(later, undated) >= 0 {
+			t.Errorf("%s: expected dated todos before undated ones", dir)
+		}
+	}
+
+	asc := TodoSort{Field: SortByDueAt, Direction: SortAsc}
+	if asc.Compare(dated, later) >= 0 {
+		t.Error("asc: expected earlier due date first")
+	}
+	desc := TodoSort{Field: SortByDueAt, Direction: SortDesc}
+	if desc.Compare(dated, later) <= 0 {
+		t.Error("desc: expected later due date first")
+	}
+}
+
+func TestTodoSort_Compare_PriorityTies(t *testing.T) {
+	due := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := Todo{ID: "a", Priority: PriorityHigh, DueAt: &due}
+	b := Todo{ID: "b", Priority: PriorityHigh, DueAt: &due}
+	undated := Todo{ID: "0", Priority: PriorityHigh}
+
+	for _, dir := range []SortDirection{SortAsc, SortDesc} {
+		sort := TodoSort{Field: SortByPriority, Direction: dir}
+		if sort.Compare(a, undated) >= 0 {
+			t.Errorf("%s: expected the dated todo first within a priority", dir)
+		}
+	}
+	// The id breaks remaining ties in the direction of the sort, as in SQL
+	if (TodoSort{Field: SortByPriority, Direction: SortAsc}).Compare(a, b) >= 0 {
+		t.Error("asc: expected ids ascending")
+	}
+	if (TodoSort{Field: SortByPriority, Direction: SortDesc}).Compare(a, b) <= 0 {
+		t.Error("desc: expected ids descending")
+	}
+}
+
+func TestTodoFilter_Matches(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	lastWeek := now.AddDate(0, 0, -7)
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		filter TodoFilter
+		todo   Todo
+		want   bool
+	}{
+		{
+			name:   "active excludes completed",
+			filter: TodoFilter{Status: StatusActive},
+			todo:   Todo{IsCompleted: true},
+			want:   false,
+		},
+		{
+			name:   "completed since last week",
+			filter: TodoFilter{Status: StatusCompleted, UpdatedAfter: &lastWeek},
+			todo:   Todo{IsCompleted: true, UpdatedAt: now},
+			want:   true,
+		},
+		{
+			name:   "updated before the range",
+			filter: TodoFilter{UpdatedAfter: &lastWeek},
+			todo:   Todo{UpdatedAt: lastWeek.Add(-time.Second)},
+			want:   false,
+		},
+		{
+			name:   "created before bound is exclusive",
+			filter: TodoFilter{CreatedBefore: &now},
+			todo:   Todo{CreatedAt: now},
+			want:   false,
+		},
+		{
+			name:   "due filter excludes undated",
+			filter: TodoFilter{DueBefore: &now},
+			todo:   Todo{},
+			want:   false,
+		},
+		{
+			name:   "overdue",
+			filter: TodoFilter{Overdue: true},
+			todo:   Todo{DueAt: &past},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.todo, now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPriority_JSON(t *testing.T) {
+	data, err := json.Marshal(Todo{Priority: PriorityHigh})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded struct {
+		Priority string `json:"priority"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Priority != "high" {
+		t.Errorf("expected priority=high, got %q", decoded.Priority)
+	}
+
+	var p Priority
+	if err := json.Unmarshal([]byte(`"urgent"`), &p); err != nil || p != PriorityUrgent {
+		t.Errorf("expected urgent, got %v (err %v)", p, err)
+	}
+	if err := json.Unmarshal([]byte(`"critical"`), &p); err == nil {
+		t.Error("expected error for unknown priority")
+	}
+}
+
+func TestPositionBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		wantErr       bool
+	}{
+		{name: "empty list", before: "", after: ""},
+		{name: "at the start", before: "", after: "1"},
+		{name: "at the end", before: "zz", after: ""},
+		{name: "adjacent digits", before: "a", after: "b"},
+		{name: "shared prefix", before: "a1", after: "a2"},
+		{name: "before is a prefix of after", before: "a", after: "a01"},
+		{name: "equal positions", before: "a", after: "a", wantErr: true},
+		{name: "reversed", before: "b", after: "a", wantErr: true},
+		{name: "trailing zero", before: "a0", after: "", wantErr: true},
+		{name: "invalid digit", before: "a-", after: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PositionBetween(tt.before, tt.after)
+			if tt.wantErr {
+				if !errors.Is(err, ErrPositionOrder) {
+					t.Errorf("expected ErrPositionOrder, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !isPosition(got) || got == "" || got <= tt.before || (tt.after != "" && got >= tt.after) {
+				t.Errorf("PositionBetween(%q, %q) = %q, not strictly between", tt.before, tt.after, got)
+			}
+		})
+	}
+}
+
+func TestPositionBetween_RepeatedInserts(t *testing.T) {
+	// Keep inserting right after the same todo, the worst case for growth
+	before, after := "V", "W"
+	for i := 0; i < 100; i++ {
+		p, err := PositionBetween(before, after)
+		if err != nil {
+			t.Fatalf("insert %d: unexpected error: %v", i, err)
+		}
+		if p <= before || p >= after {
+			t.Fatalf("insert %d: %q not between %q and %q", i, p, before, after)
+		}
+		after = p
+	}
+
+	// Appending grows one digit every 61 inserts
+	last := ""
+	for i := 0; i < 61*4; i++ {
+		p, err := PositionBetween(last, "")
+		if err != nil || p <= last {
+			t.Fatalf("append %d: got %q after %q (err %v)", i, p, last, err)
+		}
+		last = p
+	}
+	if len(last) > 5 {
+		t.Errorf("expected appended positions to stay short, got %q", last)
+	}
+}
+
+func TestEvenPositions(t *testing.T) {
+	for _, n := range []int{0, 1, 61, 62, 5000} {
+		positions := EvenPositions(n)
+		if len(positions) != n {
+			t.Fatalf("n=%d: expected %d positions, got %d", n, n, len(positions))
+		}
+		for i, p := range positions {
+			if !isPosition(p) || p == "" {
+				t.Fatalf("n=%d: invalid position %q", n, p)
+			}
+			if i > 0 && p <= positions[i-1] {
+				t.Fatalf("n=%d: %q does not follow %q", n, p, positions[i-1])
+			}
+		}
+		if n > 0 {
+			// There must be room before the first and after the last
+			if _, err := PositionBetween("", positions[0]); err != nil {
+				t.Errorf("n=%d: no room before first: %v", n, err)
+			}
+		}
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule     string
+		timeZone string
+		want     string // canonical form, empty when parsing must fail
+	}{
+		{rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "RRULE:freq=weekly;interval=2;byday=mo,fr", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{rule: "rrule:FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "FREQ=MONTHLY;COUNT=5", want: "FREQ=MONTHLY;COUNT=5"},
+		{rule: "FREQ=DAILY;UNTIL=20240131", timeZone: "Asia/Tokyo", want: "FREQ=DAILY;UNTIL=20240131T145959Z"},
+		{rule: "FREQ=DAILY;UNTIL=20240131T120000Z", want: "FREQ=DAILY;UNTIL=20240131T120000Z"},
+		{rule: "FREQ=YEARLY"},
+		{rule: "INTERVAL=2"},
+		{rule: "FREQ=DAILY;INTERVAL=0"},
+		{rule: "FREQ=DAILY;BYDAY=MO"},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO"},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20240131"},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY"},
+		{rule: "FREQ=DAILY;BYHOUR=9"},
+		{rule: "FREQ=DAILY", timeZone: "Mars/Olympus_Mons"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.timeZone, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule, tt.timeZone)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("expected error, got %s", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRecurrence_JSON(t *testing.T) {
+	var r Recurrence
+	if err := json.Unmarshal([]byte(`{"rule":"FREQ=WEEKLY;BYDAY=SA","time_zone":"Europe/Berlin"}`), &r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"rule":"FREQ=WEEKLY;BYDAY=SA","time_zone":"Europe/Berlin"}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"rule":"FREQ=HOURLY"}`), &r); err == nil {
+		t.Error("expected error for unsupported rule")
+	}
+}
+
+func TestRecurrence_NextStopsAtMaxDueAt(t *testing.T) {
+	r, err := ParseRecurrence("FREQ=DAILY", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := time.Date(9999, 12, 31, 9, 0, 0, 0, time.UTC)
+	if next, _, ok := r.Next(last.AddDate(0, 0, -1)); !ok || !next.Equal(last) {
+		t.Errorf("expected %v, got %v, %v", last, next, ok)
+	}
+	if next, _, ok := r.Next(last); ok {
+		t.Errorf("expected the series to end after %v, got %v", last, next)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error kinds. Every error the domain defines belongs to one of them, so
+// callers can match a specific error with errors.Is or handle a whole kind at
+// once, as the HTTP layer does when choosing a status code.
+var (
+	// ErrNotFound is the kind of errors for a resource that does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is the kind of errors for a request that clashes with the
+	// current state, such as a duplicate name
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is the kind of errors for input that is invalid in itself
+	ErrValidation = errors.New("validation failed")
+	// ErrUnauthenticated is the kind of errors for a caller whose credentials
+	// are missing or not recognized
+	ErrUnauthenticated = errors.New("authentication required")
+	// ErrForbidden is the kind of errors for a caller whose credentials do
+	// not allow what it asks for
+	ErrForbidden = errors.New("permission denied")
+)
+
+// kindError is a specific error of one of the kinds above
+type kindError struct {
+	kind    error
+	message string
+}
+
+func newError(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+func (e *kindError) Error() string { return e.message }
+
+func (e *kindError) Unwrap() error { return e.kind }
+
+// FieldError describes what is wrong with one input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists invalid input fields. It is of kind ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError returns a validation error for a single field
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records a problem with a field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e, or nil if no field has been added. It lets validators
+// collect every problem before returning.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrValidation }
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{ErrTodoNotFound, ErrNotFound},
+		{ErrListNotFound, ErrNotFound},
+		{ErrTagNotFound, ErrNotFound},
+		{ErrTagExists, ErrConflict},
+		{ErrDefaultList, ErrConflict},
+		{ErrParentCycle, ErrConflict},
+		{ErrInvalidListID, ErrValidation},
+		{ErrParentNotFound, ErrValidation},
+		{ErrRecurrenceWithoutDueAt, ErrValidation},
+		{ErrInvalidCursor, ErrValidation},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.kind) {
+			t.Errorf("expected %q to be of kind %q", tt.err, tt.kind)
+		}
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var invalid ValidationError
+	if invalid.Err() != nil {
+		t.Fatal("expected no error without fields")
+	}
+
+	invalid.Add("title", "title cannot be empty")
+	invalid.Add("priority", "priority cannot be null")
+	err := invalid.Err()
+
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if got, want := err.Error(), "title cannot be empty; priority cannot be null"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	var target *ValidationError
+	if !errors.As(err, &target) || len(target.Fields) != 2 || target.Fields[1].Field != "priority" {
+		t.Errorf("expected both fields, got %+v", target)
+	}
+}
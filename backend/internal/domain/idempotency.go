@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when an idempotency key comes back
+	// with a request other than the one it was first used for
+	ErrIdempotencyKeyReused = newError(ErrValidation, "the idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInFlight is returned when a request repeats an
+	// idempotency key whose first request has not finished yet
+	ErrIdempotencyKeyInFlight = newError(ErrConflict, "a request with the same idempotency key is still being processed")
+)
+
+// IdempotencyRecord ties an idempotency key to the request it was first used
+// for and, once that request has finished, to its response. The record stops
+// holding the key at ExpiresAt.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	// Token identifies this claim of the key. Completing or releasing the
+	// claim takes its token, so a request whose claim lapsed and was taken
+	// over by another cannot touch its successor's.
+	Token string
+	// Response is nil while the first request is still being processed
+	Response  *StoredResponse
+	ExpiresAt time.Time
+}
+
+// StoredResponse is a response kept to be replayed to retries of a request
+type StoredResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+// IdempotencyRepository defines the interface for idempotency key storage
+type IdempotencyRepository interface {
+	// Claim stores record as in flight and reports true, unless another
+	// record still holds the key, which it returns instead. Records that
+	// expired by now no longer hold their key.
+	Claim(ctx context.Context, record IdempotencyRecord, now time.Time) (*IdempotencyRecord, bool, error)
+	// Complete stores the response to the in-flight request that claimed key
+	// with token and keeps it until expiresAt. It fails if that claim is no
+	// longer in flight.
+	Complete(ctx context.Context, key string, token string, response StoredResponse, expiresAt time.Time) error
+	// Release drops the in-flight claim of key made with token, so the
+	// request can be tried again. Any other claim of the key is kept.
+	Release(ctx context.Context, key string, token string) error
+	// PurgeExpired deletes the records that expired by now and returns how many were deleted
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
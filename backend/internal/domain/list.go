@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultListID is the ID of the list todos land in when none is given.
+// The list is created by the migration that introduced lists and cannot be deleted.
+const DefaultListID = "00000000-0000-0000-0000-000000000000"
+
+var (
+	// ErrListNotFound is returned when a list does not exist
+	ErrListNotFound = newError(ErrNotFound, "list not found")
+	// ErrDefaultList is returned when trying to delete the default list
+	ErrDefaultList = newError(ErrConflict, "the default list cannot be deleted")
+	// ErrListNotEmpty is returned when trying to delete a list that still
+	// holds todos, including todos in the trash
+	ErrListNotEmpty = newError(ErrConflict, "the list still has todos, counting those in the trash")
+)
+
+// List is a named collection of todos, such as a project
+type List struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	IsDefault      bool      `json:"is_default"`
+	TodoCount      int       `json:"todo_count"`
+	CompletedCount int       `json:"completed_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ListRepository defines the interface for list data access.
+// Lists are returned with their todo counts filled in. Operations on a
+// single list return ErrListNotFound if it does not exist.
+type ListRepository interface {
+	List(ctx context.Context) ([]List, error)
+	GetByID(ctx context.Context, id string) (*List, error)
+	Create(ctx context.Context, name string) (*List, error)
+	Rename(ctx context.Context, id string, name string) (*List, error)
+	// Delete deletes a list that holds no todos, live or trashed, and
+	// returns ErrListNotEmpty otherwise
+	Delete(ctx context.Context, id string) error
+}
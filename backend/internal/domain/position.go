@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// positionDigits are the base-62 digits of a position, in byte order so that
+// positions compare correctly as plain strings (and under a binary collation)
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// MaxPositionLength is the longest position PositionBetween may return
+// before the list should be rebalanced. It is well below the column limit so
+// a rebalance is always possible.
+const MaxPositionLength = 32
+
+// ErrPositionOrder is returned when no position fits between two positions
+var ErrPositionOrder = errors.New("positions are not in ascending order")
+
+// PositionBetween returns a position that sorts strictly between before and
+// after, where an empty string means the start or end of the list. Moving a
+// todo therefore only ever rewrites its own position.
+//
+// Positions are base-62 fractions (the digits after the point) without
+// trailing zeros, so there is always room between two distinct positions.
+// Repeated inserts at the same spot grow the result by one digit every few
+// inserts (every 61 when appending); callers rebalance once it exceeds
+// MaxPositionLength.
+func PositionBetween(before, after string) (string, error) {
+	if !isPosition(before) || !isPosition(after) || (after != "" && before >= after) {
+		return "", ErrPositionOrder
+	}
+	if after == "" && before != "" {
+		return increment(before), nil
+	}
+	return midpoint(before, after), nil
+}
+
+// increment returns the shortest position after p, bumping its first digit
+// that is not already the largest. Appending is the common case, and this
+// keeps positions short far longer than halving the remaining range would.
+func increment(p string) string {
+	for i := 0; ; i++ {
+		if d := digitAt(p, i); d < len(positionDigits)-1 {
+			return p[:min(i, len(p))] + string(positionDigits[d+1])
+		}
+	}
+}
+
+// midpoint implements PositionBetween for valid, ordered positions
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the common prefix, padding a with zeros as needed
+		n := 0
+		for n < len(b) && digitAt(a, n) == strings.IndexByte(positionDigits, b[n]) {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	digitA := digitAt(a, 0)
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = strings.IndexByte(positionDigits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB+1)/2])
+	}
+	// The first digits are adjacent: b's first digit alone fits if b goes on
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + midpoint(rest, "")
+}
+
+// digitAt returns the value of the i-th digit of p, treating missing digits as zero
+func digitAt(p string, i int) int {
+	if i >= len(p) {
+		return 0
+	}
+	return strings.IndexByte(positionDigits, p[i])
+}
+
+// isPosition reports whether p is empty or a valid position
+func isPosition(p string) bool {
+	if p == "" {
+		return true
+	}
+	for i := 0; i < len(p); i++ {
+		if strings.IndexByte(positionDigits, p[i]) < 0 {
+			return false
+		}
+	}
+	return p[len(p)-1] != positionDigits[0]
+}
+
+// EvenPositions returns n ascending positions spread evenly over the whole
+// range, all of the same short length. They are used to rebalance a list
+// whose positions have grown long.
+func EvenPositions(n int) []string {
+	base := len(positionDigits)
+	width, capacity := 1, base
+	for capacity <= n {
+		width++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+
+	positions := make([]string, n)
+	digits := make([]byte, width)
+	for i := range positions {
+		v := (i + 1) * step
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = positionDigits[v%base]
+			v /= base
+		}
+		positions[i] = strings.TrimRight(string(digits), positionDigits[:1])
+	}
+	return positions
+}
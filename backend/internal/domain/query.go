@@ -0,0 +1,345 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TodoQuery is a typed list request: which todos, in what order, which page
+type TodoQuery struct {
+	Filter TodoFilter
+	Sort   TodoSort
+	Page   PageRequest
+}
+
+// TodoStatus filters todos by completion
+type TodoStatus string
+
+const (
+	StatusAll       TodoStatus = "all"
+	StatusActive    TodoStatus = "active"
+	StatusCompleted TodoStatus = "completed"
+)
+
+// IsValid reports whether s is a known status
+func (s TodoStatus) IsValid() bool {
+	switch s {
+	case StatusAll, StatusActive, StatusCompleted:
+		return true
+	}
+	return false
+}
+
+// TodoFilter narrows the todos returned by List.
+// Zero values mean "no restriction"; "After" bounds are inclusive and
+// "Before" bounds exclusive.
+type TodoFilter struct {
+	// ListID keeps the todos of a single list
+	ListID        string
+	Status        TodoStatus
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	DueBefore     *time.Time
+	DueAfter      *time.Time
+	// Overdue restricts the result to incomplete todos whose due date has passed
+	Overdue bool
+	// Tags keeps todos labelled with these tag names, combined by TagMatch
+	Tags     []string
+	TagMatch TagMatch
+}
+
+// Matches reports whether todo passes the filter at the given time
+func (f TodoFilter) Matches(todo Todo, now time.Time) bool {
+	if f.ListID != "" && todo.ListID != f.ListID {
+		return false
+	}
+	switch f.Status {
+	case StatusActive:
+		if todo.IsCompleted {
+			return false
+		}
+	case StatusCompleted:
+		if !todo.IsCompleted {
+			return false
+		}
+	}
+	if !inRange(&todo.CreatedAt, f.CreatedAfter, f.CreatedBefore) ||
+		!inRange(&todo.UpdatedAt, f.UpdatedAfter, f.UpdatedBefore) {
+		return false
+	}
+	if (f.DueAfter != nil || f.DueBefore != nil) && !inRange(todo.DueAt, f.DueAfter, f.DueBefore) {
+		return false
+	}
+	if f.Overdue && (todo.IsCompleted || todo.DueAt == nil || !todo.DueAt.Before(now)) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		matched := 0
+		for _, name := range f.Tags {
+			if todo.HasTag(name) {
+				matched++
+			}
+		}
+		if matched == 0 || (f.TagMatch == TagMatchAll && matched < len(f.Tags)) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRange reports whether t lies in [after, before); a nil t never matches a bound
+func inRange(t, after, before *time.Time) bool {
+	if after != nil && (t == nil || t.Before(*after)) {
+		return false
+	}
+	if before != nil && (t == nil || !t.Before(*before)) {
+		return false
+	}
+	return true
+}
+
+// SortField is a whitelisted field todos can be ordered by
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+	SortByDueAt     SortField = "due_at"
+	SortByTitle     SortField = "title"
+	// SortByPriority orders by priority, then by due date (soonest first,
+	// undated last) within the same priority
+	SortByPriority SortField = "priority"
+	// SortByPosition is the manual order users arrange by moving todos
+	SortByPosition SortField = "position"
+)
+
+// IsValid reports whether f is a sortable field
+func (f SortField) IsValid() bool {
+	switch f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByDueAt, SortByTitle, SortByPriority, SortByPosition:
+		return true
+	}
+	return false
+}
+
+// DefaultDirection is the natural direction for the field: newest first for
+// timestamps, soonest first for due dates, alphabetical for titles, most
+// important first for priorities and top to bottom for positions
+func (f SortField) DefaultDirection() SortDirection {
+	switch f {
+	case SortByDueAt, SortByTitle, SortByPosition:
+		return SortAsc
+	}
+	return SortDesc
+}
+
+// SortDirection is ascending or descending
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// IsValid reports whether d is a known direction
+func (d SortDirection) IsValid() bool {
+	return d == SortAsc || d == SortDesc
+}
+
+// TodoSort orders a list by one field with the id as tie-breaker (in the
+// same direction). Todos without a due date always sort after those with one.
+// Titles compare case-insensitively.
+type TodoSort struct {
+	Field     SortField
+	Direction SortDirection
+}
+
+// DefaultSort is the list order used when none is requested
+var DefaultSort = TodoSort{Field: SortByCreatedAt, Direction: SortDesc}
+
+// IsValid reports whether both the field and direction are whitelisted
+func (s TodoSort) IsValid() bool {
+	return s.Field.IsValid() && s.Direction.IsValid()
+}
+
+// Compare returns -1 if a sorts before b, 1 if after and 0 if they are the same todo
+func (s TodoSort) Compare(a, b Todo) int {
+	if s.Field == SortByPriority && a.Priority != b.Priority {
+		c := 1
+		if a.Priority < b.Priority {
+			c = -1
+		}
+		if s.Direction == SortDesc {
+			c = -c
+		}
+		return c
+	}
+
+	c := 0
+	switch s.Field {
+	case SortByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case SortByUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortByPriority:
+		// Equal priorities: soonest due date first regardless of direction
+		if c := compareDueAt(a, b); c != 0 {
+			return c
+		}
+	case SortByDueAt:
+		if (a.DueAt == nil) != (b.DueAt == nil) {
+			// Undated todos come last in either direction
+			return compareDueAt(a, b)
+		}
+		c = compareDueAt(a, b)
+	case SortByTitle:
+		c = strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	case SortByPosition:
+		c = strings.Compare(a.Position, b.Position)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if s.Direction == SortDesc {
+		c = -c
+	}
+	return c
+}
+
+// compareDueAt orders todos by due date, undated ones last
+func compareDueAt(a, b Todo) int {
+	switch {
+	case a.DueAt == nil && b.DueAt == nil:
+		return 0
+	case a.DueAt == nil:
+		return 1
+	case b.DueAt == nil:
+		return -1
+	}
+	return a.DueAt.Compare(*b.DueAt)
+}
+
+// Page size bounds for List
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = newError(ErrValidation, "invalid cursor")
+
+// Cursor identifies the boundary todo of a page within a given sort order.
+// It carries the boundary's sort key so the next page can be found without
+// re-reading it. Backward cursors page towards the start of the list.
+type Cursor struct {
+	Sort      TodoSort   `json:"s"`
+	ID        string     `json:"i"`
+	CreatedAt *time.Time `json:"c,omitempty"`
+	UpdatedAt *time.Time `json:"u,omitempty"`
+	DueAt     *time.Time `json:"d,omitempty"`
+	Title     *string    `json:"t,omitempty"`
+	Priority  *Priority  `json:"p,omitempty"`
+	Position  *string    `json:"o,omitempty"`
+	Backward  bool       `json:"b,omitempty"`
+}
+
+// NewCursor returns a cursor positioned at todo in the given sort order
+func NewCursor(todo Todo, sort TodoSort, backward bool) *Cursor {
+	c := &Cursor{Sort: sort, ID: todo.ID, Backward: backward}
+	switch sort.Field {
+	case SortByCreatedAt:
+		c.CreatedAt = &todo.CreatedAt
+	case SortByUpdatedAt:
+		c.UpdatedAt = &todo.UpdatedAt
+	case SortByDueAt:
+		c.DueAt = todo.DueAt
+	case SortByTitle:
+		c.Title = &todo.Title
+	case SortByPriority:
+		c.Priority = &todo.Priority
+		c.DueAt = todo.DueAt
+	case SortByPosition:
+		c.Position = &todo.Position
+	}
+	return c
+}
+
+// Todo returns a todo carrying the cursor's sort key, for use with TodoSort.Compare
+func (c Cursor) Todo() Todo {
+	t := Todo{ID: c.ID, DueAt: c.DueAt}
+	if c.CreatedAt != nil {
+		t.CreatedAt = *c.CreatedAt
+	}
+	if c.UpdatedAt != nil {
+		t.UpdatedAt = *c.UpdatedAt
+	}
+	if c.Title != nil {
+		t.Title = *c.Title
+	}
+	if c.Priority != nil {
+		t.Priority = *c.Priority
+	}
+	if c.Position != nil {
+		t.Position = *c.Position
+	}
+	return t
+}
+
+// Encode returns the opaque string form of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || !c.Sort.IsValid() {
+		return c, ErrInvalidCursor
+	}
+	// The key for the cursor's sort field must be present (a due date may be null)
+	switch c.Sort.Field {
+	case SortByCreatedAt:
+		if c.CreatedAt == nil {
+			return c, ErrInvalidCursor
+		}
+	case SortByUpdatedAt:
+		if c.UpdatedAt == nil {
+			return c, ErrInvalidCursor
+		}
+	case SortByTitle:
+		if c.Title == nil {
+			return c, ErrInvalidCursor
+		}
+	case SortByPriority:
+		if c.Priority == nil || !c.Priority.IsValid() {
+			return c, ErrInvalidCursor
+		}
+	case SortByPosition:
+		if c.Position == nil {
+			return c, ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
+
+// PageRequest selects a page of the list. A nil Cursor starts at the beginning.
+type PageRequest struct {
+	Cursor *Cursor
+	Limit  int
+}
+
+// TodoPage is one page of todos with cursors to its neighbours, if any
+type TodoPage struct {
+	Todos      []Todo
+	NextCursor *Cursor
+	PrevCursor *Cursor
+}
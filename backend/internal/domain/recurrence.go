@@ -0,0 +1,284 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRecurrenceWithoutDueAt is returned when a recurring todo has no due date
+// to count occurrences from
+var ErrRecurrenceWithoutDueAt error = NewValidationError("due_at", "a recurring todo needs a due date")
+
+// Frequency is the base period of a recurrence rule
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// maxMonthlySteps bounds the search for a month containing the due date's
+// day; even the 29th of February with an odd interval recurs well within it
+const maxMonthlySteps = 1000
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence is a subset of an RFC 5545 RRULE: FREQ (DAILY, WEEKLY or
+// MONTHLY), INTERVAL, BYDAY (weekly rules only, without ordinals), and UNTIL
+// or COUNT. Occurrences keep the wall-clock time of the due date in TimeZone,
+// so a 09:00 chore stays at 09:00 across daylight saving changes.
+//
+// Count is the number of occurrences left including the current one; zero
+// means unlimited. Each spawned occurrence carries the rule with Count
+// decremented, so no history has to be kept.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Until    *time.Time
+	Count    int
+	TimeZone string
+}
+
+// ParseRecurrence parses an RRULE value (with or without the "RRULE:"
+// prefix) whose occurrences are computed in the named IANA time zone; an
+// empty zone means UTC. A date-only UNTIL includes that whole day.
+func ParseRecurrence(rule string, timeZone string) (*Recurrence, error) {
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", timeZone)
+	}
+
+	// The property name is case-insensitive, like the names of the rule's parts
+	const prefix = "RRULE:"
+	rule = strings.TrimSpace(rule)
+	if len(rule) >= len(prefix) && strings.EqualFold(rule[:len(prefix)], prefix) {
+		rule = rule[len(prefix):]
+	}
+
+	r := &Recurrence{Interval: 1, TimeZone: timeZone}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate recurrence rule part %s", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if r.Freq != FrequencyDaily && r.Freq != FrequencyWeekly && r.Freq != FrequencyMonthly {
+				return nil, fmt.Errorf("unsupported FREQ %q: must be DAILY, WEEKLY or MONTHLY", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+		case "BYDAY":
+			for _, code := range strings.Split(strings.ToUpper(value), ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY day %q", code)
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %s", name)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("recurrence rule needs FREQ")
+	}
+	if len(r.ByDay) > 0 && r.Freq != FrequencyWeekly {
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if r.Until != nil && r.Count > 0 {
+		return nil, errors.New("UNTIL and COUNT cannot both be set")
+	}
+	return r, nil
+}
+
+// parseUntil accepts the UTC date-time and date forms of UNTIL
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		// The last instant of that day
+		return d.AddDate(0, 0, 1).Add(-time.Nanosecond).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q: must be YYYYMMDD or YYYYMMDDTHHMMSSZ", value)
+}
+
+// String returns the rule in canonical RRULE form, without the time zone
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// recurrenceJSON is the API form of a Recurrence
+type recurrenceJSON struct {
+	Rule     string `json:"rule"`
+	TimeZone string `json:"time_zone"`
+}
+
+// MarshalJSON encodes the recurrence as its rule and time zone
+func (r Recurrence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(recurrenceJSON{Rule: r.String(), TimeZone: r.TimeZone})
+}
+
+// UnmarshalJSON decodes and validates a rule and time zone
+func (r *Recurrence) UnmarshalJSON(data []byte) error {
+	var raw recurrenceJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := ParseRecurrence(raw.Rule, raw.TimeZone)
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// Next returns the occurrence after the one due at due, and the rule the
+// next occurrence carries. It reports false when the rule is exhausted or
+// the next occurrence would fall after MaxDueAt.
+func (r Recurrence) Next(due time.Time) (time.Time, *Recurrence, bool) {
+	if r.Count == 1 {
+		return time.Time{}, nil, false
+	}
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	next, ok := r.nextLocal(due.In(loc))
+	if !ok || (r.Until != nil && next.After(*r.Until)) || next.After(MaxDueAt) {
+		return time.Time{}, nil, false
+	}
+
+	rest := r
+	if rest.Count > 0 {
+		rest.Count--
+	}
+	return next.UTC(), &rest, true
+}
+
+// nextLocal steps from a local occurrence to the next one, keeping the wall
+// clock time
+func (r Recurrence) nextLocal(t time.Time) (time.Time, bool) {
+	y, m, d := t.Date()
+	at := func(year int, month time.Month, day int) time.Time {
+		return wallClock(year, month, day, t)
+	}
+
+	switch r.Freq {
+	case FrequencyDaily:
+		return at(y, m, d+r.Interval), true
+
+	case FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			return at(y, m, d+7*r.Interval), true
+		}
+		// Weeks start on Monday (the RFC 5545 default WKST)
+		offset := mondayOffset(t.Weekday())
+		first, later := 7, 7
+		for _, day := range r.ByDay {
+			o := mondayOffset(day)
+			first = min(first, o)
+			if o > offset {
+				later = min(later, o)
+			}
+		}
+		if later < 7 {
+			return at(y, m, d+later-offset), true
+		}
+		return at(y, m, d-offset+7*r.Interval+first), true
+
+	case FrequencyMonthly:
+		// Months without the day are skipped, as RFC 5545 requires, so the
+		// 31st recurs only in 31-day months rather than drifting to the 28th
+		for step := 1; step <= maxMonthlySteps; step++ {
+			month := time.Date(y, m+time.Month(step*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+			if daysIn(month.Year(), month.Month()) >= d {
+				return at(month.Year(), month.Month(), d), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// wallClock returns the given date at the time of day of t, in t's location.
+// A time that falls in a DST gap is read with the offset in effect before
+// the gap, as RFC 5545 specifies, so 02:30 on a spring-forward night
+// becomes 03:30; time.Date leaves the result unspecified.
+func wallClock(year int, month time.Month, day int, t time.Time) time.Time {
+	local := time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if local.Hour() == t.Hour() && local.Minute() == t.Minute() && local.Second() == t.Second() {
+		return local
+	}
+	naive := time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	_, offset := naive.Add(-24 * time.Hour).In(t.Location()).Zone()
+	return naive.Add(-time.Duration(offset) * time.Second).In(t.Location())
+}
+
+// mondayOffset returns how many days day falls after Monday
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// ErrTagNotFound is returned when an operation refers to a tag that does not exist
+	ErrTagNotFound = newError(ErrNotFound, "tag not found")
+	// ErrTagExists is returned when a tag name is already taken
+	ErrTagExists = newError(ErrConflict, "tag already exists")
+)
+
+// Tag is a label that can be attached to any number of todos
+type Tag struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagMatch selects how a tag filter combines several tags
+type TagMatch string
+
+const (
+	// TagMatchAny keeps todos carrying at least one of the tags
+	TagMatchAny TagMatch = "any"
+	// TagMatchAll keeps todos carrying every one of the tags
+	TagMatchAll TagMatch = "all"
+)
+
+// IsValid reports whether m is a known match mode
+func (m TagMatch) IsValid() bool {
+	return m == TagMatchAny || m == TagMatchAll
+}
+
+// TagRepository defines the interface for tag data access.
+// Tag names are unique, compared case-insensitively. Operations on a single
+// tag return ErrTagNotFound if it does not exist.
+type TagRepository interface {
+	List(ctx context.Context) ([]Tag, error)
+	GetByID(ctx context.Context, id string) (*Tag, error)
+	Create(ctx context.Context, name string) (*Tag, error)
+	Rename(ctx context.Context, id string, name string) (*Tag, error)
+	Delete(ctx context.Context, id string) error
+}
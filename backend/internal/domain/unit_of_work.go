@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+// Repositories are the repositories of one unit of work. UnitOfWork runs
+// nested units of work within it, each under a savepoint of the enclosing
+// transaction: a failing nested unit undoes only its own writes.
+type Repositories struct {
+	Todos      TodoRepository
+	Tags       TagRepository
+	Lists      ListRepository
+	UnitOfWork UnitOfWork
+}
+
+// UnitOfWork runs several repository operations atomically
+type UnitOfWork interface {
+	// Do runs fn with repositories whose operations all belong to one
+	// transaction, committed when fn returns nil and rolled back when fn
+	// returns an error or panics. A transaction aborted by a transient
+	// conflict such as a deadlock is retried from the start, so fn may run
+	// more than once and must not carry state over from an earlier attempt.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextLimit bounds a single-line text field. Length counts user-perceived
+// characters (extended grapheme clusters), so "👍🏽" or "e" with a combining
+// accent is one character. It is also the size of the VARCHAR column, which
+// MySQL counts in code points, so text made of long clusters can be within
+// Length characters and still not fit.
+type TextLimit struct {
+	Length int
+}
+
+var (
+	// TitleLimit bounds todo titles (todos.title is VARCHAR(255))
+	TitleLimit = TextLimit{Length: 255}
+	// ListNameLimit bounds list names (lists.name is VARCHAR(255))
+	ListNameLimit = TextLimit{Length: 255}
+	// TagNameLimit bounds tag names (tags.name is VARCHAR(64))
+	TagNameLimit = TextLimit{Length: 64}
+)
+
+var (
+	// MinDueAt and MaxDueAt bound due dates to what every backend can store
+	// (todos.due_at is a MySQL DATETIME, which spans years 1000 to 9999)
+	MinDueAt = time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC)
+	MaxDueAt = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+)
+
+// NormalizeText trims surrounding white space and converts s to Unicode
+// normalization form C, so text that looks the same is stored, compared and
+// counted the same way whichever way the client composed it
+func NormalizeText(s string) string {
+	return norm.NFC.String(strings.TrimSpace(s))
+}
+
+// TextLength returns the number of user-perceived characters in s
+func TextLength(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// normalizeRequired normalizes a required text field and records every way
+// it breaks limit in invalid
+func normalizeRequired(invalid *ValidationError, field string, s string, limit TextLimit) string {
+	if !utf8.ValidString(s) {
+		invalid.Add(field, field+" must be valid UTF-8")
+		return s
+	}
+	s = NormalizeText(s)
+	switch {
+	case s == "":
+		invalid.Add(field, field+" is required")
+	case strings.IndexFunc(s, unicode.IsControl) >= 0:
+		invalid.Add(field, field+" cannot contain control characters")
+	case TextLength(s) > limit.Length:
+		invalid.Add(field, fmt.Sprintf("%s must be at most %d characters", field, limit.Length))
+	case utf8.RuneCountInString(s) > limit.Length:
+		invalid.Add(field, fmt.Sprintf("%s is too long: it may hold at most %d code points", field, limit.Length))
+	}
+	return s
+}
+
+// checkDueAt records in invalid a due date that cannot be stored
+func checkDueAt(invalid *ValidationError, dueAt *time.Time) {
+	if dueAt != nil && (dueAt.Before(MinDueAt) || dueAt.After(MaxDueAt)) {
+		invalid.Add("due_at", fmt.Sprintf("due_at must be between %s and %s",
+			MinDueAt.Format(time.RFC3339), MaxDueAt.Format(time.RFC3339)))
+	}
+}
+
+// NormalizeName normalizes and validates the name of a list or tag
+func NormalizeName(name string, limit TextLimit) (string, error) {
+	var invalid ValidationError
+	name = normalizeRequired(&invalid, "name", name, limit)
+	return name, invalid.Err()
+}
+
+// Normalize normalizes the text fields of p in place and validates them and
+// the due date, returning a *ValidationError listing every invalid field
+func (p *CreateTodoParams) Normalize() error {
+	var invalid ValidationError
+	p.Title = normalizeRequired(&invalid, "title", p.Title, TitleLimit)
+	checkDueAt(&invalid, p.DueAt)
+	return invalid.Err()
+}
+
+// Normalize normalizes the text fields present in the patch in place and
+// validates them and any new due date, returning a *ValidationError listing every invalid field
+func (p *TodoPatch) Normalize() error {
+	var invalid ValidationError
+	if p.Title.Set {
+		if p.Title.Null {
+			invalid.Add("title", "title cannot be null")
+		} else {
+			p.Title.Value = normalizeRequired(&invalid, "title", p.Title.Value, TitleLimit)
+		}
+	}
+	if p.DueAt.Set {
+		checkDueAt(&invalid, p.DueAt.Value)
+	}
+	return invalid.Err()
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"日本語", 3},
+		{"e\u0301", 1},
+		{"👍🏽", 1},
+		{"👨‍👩‍👧", 1},
+		{"🇯🇵🇫🇷", 2},
+	}
+	for _, tt := range tests {
+		if got := TextLength(tt.text); got != tt.want {
+			t.Errorf("TextLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	limit := TextLimit{Length: 4}
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "trims white space", input: "  work\n", want: "work"},
+		{name: "composes to NFC", input: "Cafe\u0301", want: "Caf\u00e9"},
+		{name: "counts clusters, not code points", input: "👍🏽👍🏽", want: "👍🏽👍🏽"},
+		{name: "rejects blank", input: " \t ", wantErr: "name is required"},
+		{name: "rejects control characters", input: "a\x00b", wantErr: "name cannot contain control characters"},
+		{name: "rejects invalid UTF-8", input: "a\xffb", wantErr: "name must be valid UTF-8"},
+		{name: "rejects too many characters", input: "abcde", wantErr: "name must be at most 4 characters"},
+		{
+			name:    "rejects text too long for the column",
+			input:   "👨‍👩‍👧👨‍👩‍👧",
+			wantErr: "name is too long: it may hold at most 4 code points",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeName(tt.input, limit)
+			if tt.wantErr != "" {
+				var invalid *ValidationError
+				if !errors.As(err, &invalid) || err.Error() != tt.wantErr {
+					t.Errorf("expected validation error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("expected %q, got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestCreateTodoParams_Normalize(t *testing.T) {
+	params := CreateTodoParams{Title: " Buy milk "}
+	if err := params.Normalize(); err != nil || params.Title != "Buy milk" {
+		t.Errorf("expected trimmed title, got %q, %v", params.Title, err)
+	}
+
+	params = CreateTodoParams{Title: strings.Repeat("あ", TitleLimit.Length+1)}
+	if err := params.Normalize(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error for a long title, got %v", err)
+	}
+
+	for _, dueAt := range []time.Time{MinDueAt, MaxDueAt} {
+		params = CreateTodoParams{Title: "Buy milk", DueAt: &dueAt}
+		if err := params.Normalize(); err != nil {
+			t.Errorf("expected due date %v to be valid, got %v", dueAt, err)
+		}
+	}
+	for _, dueAt := range []time.Time{MinDueAt.Add(-time.Second), MaxDueAt.Add(time.Second)} {
+		params = CreateTodoParams{Title: "Buy milk", DueAt: &dueAt}
+		if err := params.Normalize(); !errors.Is(err, ErrValidation) {
+			t.Errorf("expected a validation error for due date %v, got %v", dueAt, err)
+		}
+	}
+}
+
+func TestTodoPatch_Normalize(t *testing.T) {
+	patch := TodoPatch{Title: Some(" Renamed ")}
+	if err := patch.Normalize(); err != nil || patch.Title.Value != "Renamed" {
+		t.Errorf("expected trimmed title, got %q, %v", patch.Title.Value, err)
+	}
+
+	patch = TodoPatch{Title: Optional[string]{Set: true, Null: true}}
+	if err := patch.Normalize(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error for a null title, got %v", err)
+	}
+
+	patch = TodoPatch{IsCompleted: Some(true)}
+	if err := patch.Normalize(); err != nil {
+		t.Errorf("expected a patch without a title to be valid, got %v", err)
+	}
+
+	late := MaxDueAt.Add(time.Second)
+	patch = TodoPatch{DueAt: Some(&late)}
+	if err := patch.Normalize(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error for a due date after MaxDueAt, got %v", err)
+	}
+
+	patch = TodoPatch{DueAt: Some[*time.Time](nil)}
+	if err := patch.Normalize(); err != nil {
+		t.Errorf("expected clearing the due date to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// BatchRequest represents the request body for running several todo
+// operations at once. Mode is atomic (the default) or best_effort.
+type BatchRequest struct {
+	Mode       domain.BatchMode        `json:"mode"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest is one operation of a batch. A create carries the
+// new todo in todo; an update names the todo in id and carries a merge patch
+// in patch, plus optionally the version the todo must still be at; a delete
+// only names the todo.
+type BatchOperationRequest struct {
+	Op      domain.BatchAction `json:"op"`
+	ID      string             `json:"id"`
+	Todo    *CreateTodoRequest `json:"todo"`
+	Patch   *UpdateTodoRequest `json:"patch"`
+	Version int                `json:"version"`
+}
+
+// BatchResponse reports the outcome of every operation of a batch, in request order
+type BatchResponse struct {
+	Results   []BatchItemResponse `json:"results"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+}
+
+// BatchItemResponse is the outcome of one batch operation. Status is the
+// status the operation would have had as a request of its own; Todo is the
+// created or updated todo and Error the problem of a failed operation.
+type BatchItemResponse struct {
+	Status int          `json:"status"`
+	Todo   *domain.Todo `json:"todo,omitempty"`
+	Error  *Problem     `json:"error,omitempty"`
+}
+
+// CompleteAllResponse reports how many todos completing all of them completed
+type CompleteAllResponse struct {
+	Completed int `json:"completed"`
+}
+
+// ClearCompletedResponse reports how many todos clearing the completed ones
+// moved to the trash, subtasks included
+type ClearCompletedResponse struct {
+	Trashed int `json:"trashed"`
+}
+
+// BatchTodos handles POST /api/todos/batch. A batch with a malformed
+// operation is rejected as a whole with 400; otherwise the response is 200
+// when every operation succeeded and 207 Multi-Status when any failed.
+func (h *TodoHandler) BatchTodos(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = domain.BatchAtomic
+	}
+	ops, err := batchOperations(req.Operations)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	results, err := h.usecase.Batch(r.Context(), ops, mode)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	resp := BatchResponse{Results: make([]BatchItemResponse, len(results))}
+	for i, result := range results {
+		item := batchItem(r, ops[i].Action, result)
+		if item.Error == nil {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+		resp.Results[i] = item
+	}
+
+	status := http.StatusOK
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	respondJSON(w, status, resp)
+}
+
+// batchOperations converts the operations of a batch request, reporting
+// every malformed one with the path of the offending field
+func batchOperations(reqs []BatchOperationRequest) ([]domain.BatchOperation, error) {
+	var invalid domain.ValidationError
+	ops := make([]domain.BatchOperation, len(reqs))
+	for i, req := range reqs {
+		field := fmt.Sprintf("operations[%d]", i)
+		ops[i] = domain.BatchOperation{Action: req.Op, ID: req.ID}
+		if req.Op != domain.BatchCreate && req.Todo != nil {
+			invalid.Add(field+".todo", field+".todo is only allowed with op create")
+		}
+		if req.Op != domain.BatchUpdate && (req.Patch != nil || req.Version != 0) {
+			invalid.Add(field+".patch", field+".patch and version are only allowed with op update")
+		}
+
+		switch req.Op {
+		case domain.BatchCreate:
+			if req.ID != "" {
+				invalid.Add(field+".id", field+".id is not allowed with op create")
+			}
+			if req.Todo == nil {
+				invalid.Add(field+".todo", field+".todo is required")
+				continue
+			}
+			ops[i].Create = domain.CreateTodoParams{
+				Title:      req.Todo.Title,
+				DueAt:      req.Todo.DueAt,
+				Priority:   req.Todo.Priority,
+				ListID:     req.Todo.ListID,
+				ParentID:   req.Todo.ParentID,
+				Recurrence: req.Todo.Recurrence,
+			}
+		case domain.BatchUpdate:
+			if req.Patch == nil {
+				invalid.Add(field+".patch", field+".patch is required")
+				continue
+			}
+			if req.Version < 0 {
+				invalid.Add(field+".version", field+".version must be positive")
+			}
+			var patchErr *domain.ValidationError
+			if errors.As(validateUpdateTodoRequest(*req.Patch), &patchErr) {
+				for _, f := range patchErr.Fields {
+					invalid.Add(field+".patch."+f.Field, f.Message)
+				}
+			}
+			ops[i].Patch = domain.TodoPatch{
+				Title:       req.Patch.Title,
+				IsCompleted: req.Patch.IsCompleted,
+				DueAt:       req.Patch.DueAt,
+				Priority:    req.Patch.Priority,
+				ListID:      req.Patch.ListID,
+				ParentID:    req.Patch.ParentID,
+				Recurrence:  req.Patch.Recurrence,
+				IfVersion:   req.Version,
+			}
+		}
+	}
+	return ops, invalid.Err()
+}
+
+// batchItem describes the outcome of one batch operation. Operations undone
+// because another operation of an atomic batch failed get 424 Failed Dependency.
+func batchItem(r *http.Request, action domain.BatchAction, result domain.BatchResult) BatchItemResponse {
+	switch {
+	case errors.Is(result.Err, domain.ErrBatchAborted):
+		return BatchItemResponse{Status: http.StatusFailedDependency, Error: &Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusFailedDependency),
+			Status: http.StatusFailedDependency,
+			Detail: sentence(result.Err.Error()),
+		}}
+	case result.Err != nil:
+		problem := domainProblem(r, result.Err)
+		return BatchItemResponse{Status: problem.Status, Error: &problem}
+	case action == domain.BatchCreate:
+		return BatchItemResponse{Status: http.StatusCreated, Todo: result.Todo}
+	case action == domain.BatchDelete:
+		return BatchItemResponse{Status: http.StatusNoContent}
+	default:
+		return BatchItemResponse{Status: http.StatusOK, Todo: result.Todo}
+	}
+}
+
+// CompleteAll handles POST /api/todos/complete-all, completing every active
+// todo matching the filter query parameters of GET /api/todos
+func (h *TodoHandler) CompleteAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	n, err := h.usecase.CompleteAll(r.Context(), filter)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, CompleteAllResponse{Completed: n})
+}
+
+// ClearCompleted handles POST /api/todos/clear-completed, moving every
+// completed todo matching the filter query parameters of GET /api/todos to
+// the trash
+func (h *TodoHandler) ClearCompleted(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	n, err := h.usecase.ClearCompleted(r.Context(), filter)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ClearCompletedResponse{Trashed: n})
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// batchTodos returns a Batch that expects ops to be run in mode and answers
+// with results
+func batchTodos(mode domain.BatchMode, ops []domain.BatchOperation, results ...domain.BatchResult) func(ctx context.Context, got []domain.BatchOperation, gotMode domain.BatchMode) ([]domain.BatchResult, error) {
+	return func(ctx context.Context, got []domain.BatchOperation, gotMode domain.BatchMode) ([]domain.BatchResult, error) {
+		if gotMode != mode || !reflect.DeepEqual(got, ops) {
+			return nil, unexpected("%s batch %+v", gotMode, got)
+		}
+		return results, nil
+	}
+}
+
+// invalidBatch returns a Batch that rejects the batch as the usecase does
+// when field is invalid
+func invalidBatch(field string, message string) func(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error) {
+	return func(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error) {
+		return nil, domain.NewValidationError(field, message)
+	}
+}
+
+func TestBatchTodos(t *testing.T) {
+	eggs := fixtureTodo(domain.Todo{ID: uuid.NewString(), Title: "Buy eggs", Priority: domain.PriorityMedium, Position: "Y"})
+	oatMilk := milk
+	oatMilk.Title = "Buy oat milk"
+	oatMilk.Version++
+	completedMilk := completed(milk)
+	runRouteTests(t, "batch_todos", []routeTest{
+		{
+			name:   "succeeded",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body: `{"operations":[
+				{"op":"create","todo":{"title":"Buy eggs","priority":"medium"}},
+				{"op":"update","id":"{milk}","patch":{"is_completed":true},"version":1},
+				{"op":"delete","id":"{bread}"}
+			]}`,
+			todos: fakeTodos{batch: batchTodos(domain.BatchAtomic, []domain.BatchOperation{
+				{Action: domain.BatchCreate, Create: domain.CreateTodoParams{Title: "Buy eggs", Priority: domain.PriorityMedium}},
+				{Action: domain.BatchUpdate, ID: milk.ID, Patch: domain.TodoPatch{IsCompleted: domain.Some(true), IfVersion: 1}},
+				{Action: domain.BatchDelete, ID: bread.ID},
+			}, domain.BatchResult{Todo: &eggs}, domain.BatchResult{Todo: &completedMilk}, domain.BatchResult{})},
+		},
+		{
+			name:   "atomic_failure",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body: `{"mode":"atomic","operations":[
+				{"op":"create","todo":{"title":"Buy eggs"}},
+				{"op":"update","id":"{milk}","patch":{"title":"Buy oat milk"},"version":5},
+				{"op":"delete","id":"` + missingID + `"}
+			]}`,
+			todos: fakeTodos{batch: batchTodos(domain.BatchAtomic, []domain.BatchOperation{
+				{Action: domain.BatchCreate, Create: domain.CreateTodoParams{Title: "Buy eggs"}},
+				{Action: domain.BatchUpdate, ID: milk.ID, Patch: domain.TodoPatch{Title: domain.Some("Buy oat milk"), IfVersion: 5}},
+				{Action: domain.BatchDelete, ID: missingID},
+			}, domain.BatchResult{Err: domain.ErrBatchAborted}, domain.BatchResult{Err: domain.ErrVersionMismatch}, domain.BatchResult{Err: domain.ErrBatchAborted})},
+		},
+		{
+			name:   "best_effort",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body: `{"mode":"best_effort","operations":[
+				{"op":"create","todo":{"title":""}},
+				{"op":"update","id":"{milk}","patch":{"title":"Buy oat milk"}},
+				{"op":"delete","id":"` + missingID + `"}
+			]}`,
+			todos: fakeTodos{batch: batchTodos(domain.BatchBestEffort, []domain.BatchOperation{
+				{Action: domain.BatchCreate, Create: domain.CreateTodoParams{}},
+				{Action: domain.BatchUpdate, ID: milk.ID, Patch: domain.TodoPatch{Title: domain.Some("Buy oat milk")}},
+				{Action: domain.BatchDelete, ID: missingID},
+			}, domain.BatchResult{Err: (&domain.CreateTodoParams{}).Normalize()}, domain.BatchResult{Todo: &oatMilk}, domain.BatchResult{Err: domain.ErrTodoNotFound})},
+		},
+		{
+			name:   "malformed",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body: `{"operations":[
+				{"op":"create","id":"{milk}"},
+				{"op":"update","id":"{milk}","patch":{"priority":null},"version":-1},
+				{"op":"delete","id":"{milk}","todo":{"title":"Buy milk"}}
+			]}`,
+		},
+		{
+			name:   "invalid_mode",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body:   `{"mode":"eventually","operations":[{"op":"delete","id":"` + missingID + `"}]}`,
+			todos:  fakeTodos{batch: invalidBatch("mode", "mode must be atomic or best_effort")},
+		},
+		{
+			name:   "invalid_op",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body:   `{"operations":[{"op":"archive","id":"` + missingID + `"}]}`,
+			todos:  fakeTodos{batch: invalidBatch("operations[0].op", "operations[0].op must be create, update or delete")},
+		},
+		{
+			name:   "no_operations",
+			method: "POST",
+			path:   "/api/todos/batch",
+			body:   `{"operations":[]}`,
+			todos:  fakeTodos{batch: invalidBatch("operations", "operations must hold 1 to 100 operations")},
+		},
+		{name: "unavailable", method: "POST", path: "/api/todos/batch", body: `{"operations":[{"op":"delete","id":"` + missingID + `"}]}`, unavailable: true},
+	})
+}
+
+func TestCompleteAll(t *testing.T) {
+	before := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+	dueBefore := domain.TodoFilter{DueBefore: &before, TagMatch: domain.TagMatchAny}
+	runRouteTests(t, "complete_all", []routeTest{
+		{name: "completed", method: "POST", path: "/api/todos/complete-all", todos: fakeTodos{completeAll: filterCount(anyTags, 3)}},
+		{
+			name:   "filtered",
+			method: "POST",
+			path:   "/api/todos/complete-all?due_before=2031-01-01T00:00:00Z",
+			todos:  fakeTodos{completeAll: filterCount(dueBefore, 1)},
+		},
+		{name: "invalid_filter", method: "POST", path: "/api/todos/complete-all?tag_match=some"},
+		{name: "unavailable", method: "POST", path: "/api/todos/complete-all", unavailable: true},
+	})
+}
+
+func TestClearCompleted(t *testing.T) {
+	runRouteTests(t, "clear_completed", []routeTest{
+		{name: "cleared", method: "POST", path: "/api/todos/clear-completed", todos: fakeTodos{clearCompleted: filterCount(anyTags, 2)}},
+		{name: "nothing_completed", method: "POST", path: "/api/todos/clear-completed", todos: fakeTodos{clearCompleted: filterCount(anyTags, 0)}},
+		{name: "invalid_filter", method: "POST", path: "/api/todos/clear-completed?status=done"},
+		{name: "unavailable", method: "POST", path: "/api/todos/clear-completed", unavailable: true},
+	})
+}
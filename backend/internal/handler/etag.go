@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// todoETag returns the entity tag of a todo, a strong validator. It is the
+// todo's version, which every write to the todo changes, followed by a hash
+// of what the representation shows without the version going up: the
+// subtasks rollup and the names of the tags, which renaming a tag changes.
+func todoETag(todo *domain.Todo) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "subtasks %d/%d\n", todo.Subtasks.Completed, todo.Subtasks.Total)
+	for _, tag := range todo.Tags {
+		fmt.Fprintf(h, "tag %s %s\n", tag.ID, tag.Name)
+	}
+	return fmt.Sprintf(`"%d-%x"`, todo.Version, h.Sum(nil)[:8])
+}
+
+// respondTodo sends a single todo together with its ETag
+func respondTodo(w http.ResponseWriter, status int, todo *domain.Todo) {
+	w.Header().Set("ETag", todoETag(todo))
+	respondJSON(w, status, todo)
+}
+
+// etagMatches reports whether a list of entity tags from an If-Match or
+// If-None-Match header contains etag, "*" matching any. If-Match uses the
+// strong comparison, under which weak tags never match; If-None-Match uses
+// the weak comparison, which ignores the W/ prefix.
+func etagMatches(values []string, etag string, weak bool) bool {
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" {
+				return true
+			}
+			if strings.HasPrefix(tag, "W/") {
+				if !weak {
+					continue
+				}
+				tag = strings.TrimPrefix(tag, "W/")
+			}
+			if tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ifMatchVersion evaluates the If-Match header of a write against the todo's
+// current ETag and returns the version the write must still find, or zero
+// when there is no precondition. It writes the error response itself: 412
+// when no listed tag matches, or the usecase error when the todo cannot be read.
+func (h *TodoHandler) ifMatchVersion(w http.ResponseWriter, r *http.Request, id string) (int, bool) {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return 0, true
+	}
+	todo, err := h.usecase.Get(r.Context(), id)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return 0, false
+	}
+	if !etagMatches(values, todoETag(todo), false) {
+		respondPreconditionFailed(w, r, todo)
+		return 0, false
+	}
+	return todo.Version, true
+}
+
+// respondPreconditionFailed reports a failed If-Match, sending the current
+// ETag so the client knows which version it has to catch up with
+func respondPreconditionFailed(w http.ResponseWriter, r *http.Request, current *domain.Todo) {
+	if current != nil {
+		w.Header().Set("ETag", todoETag(current))
+	}
+	respondError(w, r, http.StatusPreconditionFailed, "The todo was modified since it was fetched; fetch it again and retry")
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/usecase"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted. Keys are
+// stored as a fixed-length hash (see scopedIdempotencyKey), so this is not a
+// limit of the idempotency_keys table.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency makes requests carrying an Idempotency-Key header safe to
+// retry: the first request runs and its response is stored, and a retry with
+// the same key and body gets the stored response instead of running again.
+// Keys belong to the caller: the same key sent with other credentials names
+// another request.
+type Idempotency struct {
+	service usecase.IdempotencyService
+}
+
+// NewIdempotency creates a new Idempotency middleware
+func NewIdempotency(service usecase.IdempotencyService) *Idempotency {
+	return &Idempotency{service: service}
+}
+
+// Middleware wraps a handler whose requests may carry an Idempotency-Key.
+// Requests without the header pass straight through. Replays carry an
+// Idempotent-Replayed header. Responses with a 5xx status are not stored, so
+// retrying after a server error runs the request again, and neither are
+// refusals of the caller's credentials, so a retry with the right ones does.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !isValidIdempotencyKey(key) {
+			respondDomainError(w, r, domain.NewValidationError("Idempotency-Key",
+				fmt.Sprintf("Idempotency-Key must be 1 to %d printable ASCII characters", maxIdempotencyKeyLength)))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must be at most %d bytes", maxBodyBytes))
+			return
+		}
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		key = scopedIdempotencyKey(r, key)
+
+		stored, token, err := i.service.Begin(r.Context(), key, requestHash(r, body))
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyReused):
+			respondError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			return
+		case err != nil:
+			respondDomainError(w, r, err)
+			return
+		case stored != nil:
+			replay(w, stored)
+			return
+		}
+
+		// The outcome is recorded even if the client has gone away, since a
+		// retry is exactly what is expected then
+		ctx := context.WithoutCancel(r.Context())
+		completed := false
+		defer func() {
+			if !completed {
+				if err := i.service.Release(ctx, key, token); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		var recorded bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&recorded)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError || status == http.StatusUnauthorized || status == http.StatusForbidden {
+			return
+		}
+		response := domain.StoredResponse{Status: status, Header: make(map[string]string), Body: recorded.Bytes()}
+		for _, name := range replayedHeaders {
+			if v := w.Header().Get(name); v != "" {
+				response.Header[name] = v
+			}
+		}
+		if err := i.service.Complete(ctx, key, token, response); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// replay sends a stored response again
+func replay(w http.ResponseWriter, stored *domain.StoredResponse) {
+	for name, value := range stored.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	if _, err := w.Write(stored.Body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// scopedIdempotencyKey returns the key a request's Idempotency-Key is stored
+// under, a hash of the key and the request's credentials. A caller cannot
+// see, claim or block the keys of a caller with other credentials, and a
+// response is only replayed to its caller.
+func scopedIdempotencyKey(r *http.Request, key string) string {
+	h := sha256.New()
+	io.WriteString(h, "Authorization: "+r.Header.Get("Authorization")+"\n")
+	io.WriteString(h, key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requestHash fingerprints what a request asks for, so a key reused for
+// another endpoint or with another body is told apart from a retry
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isValidIdempotencyKey reports whether key fits the column and consists of
+// printable ASCII, as a structured-field string must
+func isValidIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
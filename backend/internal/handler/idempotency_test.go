@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIdempotency(t *testing.T) {
+	create := routeTest{
+		method: "POST",
+		path:   "/api/todos",
+		header: map[string]string{"Idempotency-Key": "create-milk"},
+		body:   `{"title":"Buy milk"}`,
+	}
+	runRouteTests(t, "idempotency", []routeTest{
+		{name: "first", method: create.method, path: create.path, header: create.header, body: create.body, todos: fakeTodos{create: createTodo}},
+		{
+			name:   "replayed",
+			before: []routeTest{create},
+			method: create.method,
+			path:   create.path,
+			header: create.header,
+			body:   create.body,
+			// A replay creates nothing
+			todos: fakeTodos{create: createOnce(createTodo)},
+		},
+		{
+			name:   "key_reused",
+			before: []routeTest{create},
+			method: create.method,
+			path:   create.path,
+			header: create.header,
+			body:   `{"title":"Buy bread"}`,
+			todos:  fakeTodos{create: createOnce(createTodo)},
+		},
+		{
+			name:   "key_reused_elsewhere",
+			before: []routeTest{create},
+			method: "POST",
+			path:   "/api/todos/batch",
+			header: create.header,
+			body:   `{"title":"Buy milk"}`,
+			todos:  fakeTodos{create: createOnce(createTodo)},
+		},
+		{
+			name:   "key_of_other_caller",
+			before: []routeTest{{method: create.method, path: create.path, header: map[string]string{"Idempotency-Key": "create-milk", "Authorization": "Bearer someone"}, body: create.body}},
+			method: create.method,
+			path:   create.path,
+			header: map[string]string{"Idempotency-Key": "create-milk", "Authorization": "Bearer someone-else"},
+			body:   `{"title":"Buy bread"}`,
+			// Keys belong to their caller, so this one is free
+			todos: fakeTodos{create: createTodo},
+		},
+		{
+			name:   "failure_replayed",
+			before: []routeTest{{method: "POST", path: "/api/todos", header: create.header, body: `{"title":""}`}},
+			method: "POST",
+			path:   "/api/todos",
+			header: create.header,
+			body:   `{"title":""}`,
+			todos:  fakeTodos{create: createOnce(createTodo)},
+		},
+		{
+			name:   "invalid_key",
+			method: "POST",
+			path:   "/api/todos",
+			header: map[string]string{"Idempotency-Key": "key\twith tab"},
+			body:   create.body,
+		},
+		{
+			name:   "key_too_long",
+			method: "POST",
+			path:   "/api/todos",
+			header: map[string]string{"Idempotency-Key": strings.Repeat("k", maxIdempotencyKeyLength+1)},
+			body:   create.body,
+		},
+		{
+			name:   "too_large",
+			method: "POST",
+			path:   "/api/todos",
+			header: create.header,
+			body:   `{"title":"` + strings.Repeat("a", maxBodyBytes) + `"}`,
+		},
+		{
+			name:        "server_error",
+			method:      create.method,
+			path:        create.path,
+			header:      create.header,
+			body:        create.body,
+			unavailable: true,
+		},
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/usecase"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListHandler handles HTTP requests for todo lists
+type ListHandler struct {
+	usecase usecase.ListService
+}
+
+// NewListHandler creates a new ListHandler
+func NewListHandler(usecase usecase.ListService) *ListHandler {
+	return &ListHandler{usecase: usecase}
+}
+
+// ListRequest represents the request body for creating or renaming a list
+type ListRequest struct {
+	Name string `json:"name"`
+}
+
+// ListLists handles GET /api/lists
+func (h *ListHandler) ListLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.usecase.List(r.Context())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	if lists == nil {
+		lists = []domain.List{}
+	}
+
+	respondJSON(w, http.StatusOK, lists)
+}
+
+// GetList handles GET /api/lists/{id}
+func (h *ListHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.usecase.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, list)
+}
+
+// CreateList handles POST /api/lists
+func (h *ListHandler) CreateList(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeListName(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.usecase.Create(r.Context(), name)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, list)
+}
+
+// RenameList handles PATCH /api/lists/{id}
+func (h *ListHandler) RenameList(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeListName(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.usecase.Rename(r.Context(), chi.URLParam(r, "id"), name)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, list)
+}
+
+// DeleteList handles DELETE /api/lists/{id}
+func (h *ListHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeListName reads the list name from the request body, writing the error
+// response itself when the body is invalid. The name is validated by the usecase.
+func decodeListName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req ListRequest
+	if !decodeJSON(w, r, &req) {
+		return "", false
+	}
+	return req.Name, true
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// The lists: the inbox holding the groceries, and errands holding one
+// completed todo
+var (
+	inbox   = domain.List{ID: domain.DefaultListID, Name: "Inbox", IsDefault: true, TodoCount: 3, CreatedAt: fixtureTime, UpdatedAt: fixtureTime}
+	errands = domain.List{ID: fixtureID("errands"), Name: "errands", TodoCount: 1, CompletedCount: 1, CreatedAt: fixtureTime, UpdatedAt: fixtureTime}
+)
+
+// getList returns a Get that finds the given lists
+func getList(lists ...domain.List) func(ctx context.Context, id string) (*domain.List, error) {
+	return func(ctx context.Context, id string) (*domain.List, error) {
+		for _, list := range lists {
+			if list.ID == id {
+				return &list, nil
+			}
+		}
+		return nil, domain.ErrListNotFound
+	}
+}
+
+// listLists returns a List that finds lists
+func listLists(lists ...domain.List) func(ctx context.Context) ([]domain.List, error) {
+	return func(ctx context.Context) ([]domain.List, error) {
+		return lists, nil
+	}
+}
+
+// createList is a Create that validates the name as the usecase does and
+// creates an empty list of that name
+func createList(ctx context.Context, name string) (*domain.List, error) {
+	name, err := domain.NormalizeName(name, domain.ListNameLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.List{ID: uuid.NewString(), Name: name, CreatedAt: fixtureTime, UpdatedAt: fixtureTime}, nil
+}
+
+// renameList returns a Rename that validates the name as the usecase does
+// and renames the given lists
+func renameList(lists ...domain.List) func(ctx context.Context, id string, name string) (*domain.List, error) {
+	get := getList(lists...)
+	return func(ctx context.Context, id string, name string) (*domain.List, error) {
+		list, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if list.Name, err = domain.NormalizeName(name, domain.ListNameLimit); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+}
+
+// deleteList returns a Delete that fails on the default list and on lists
+// holding todos, as the usecase does, and finds the other given lists
+func deleteList(lists ...domain.List) func(ctx context.Context, id string) error {
+	get := getList(lists...)
+	return func(ctx context.Context, id string) error {
+		list, err := get(ctx, id)
+		switch {
+		case id == domain.DefaultListID:
+			return domain.ErrDefaultList
+		case err != nil:
+			return err
+		case list.TodoCount > 0:
+			return domain.ErrListNotEmpty
+		}
+		return nil
+	}
+}
+
+func TestListRoutes(t *testing.T) {
+	emptyInbox := inbox
+	emptyInbox.TodoCount = 0
+	emptyErrands := errands
+	emptyErrands.TodoCount, emptyErrands.CompletedCount = 0, 0
+	runRouteTests(t, "lists", []routeTest{
+		{name: "list_default", method: "GET", path: "/api/lists", lists: fakeLists{list: listLists(emptyInbox)}},
+		{name: "list", method: "GET", path: "/api/lists", lists: fakeLists{list: listLists(inbox, errands)}},
+		{name: "list_unavailable", method: "GET", path: "/api/lists", unavailable: true},
+		{name: "get", method: "GET", path: "/api/lists/{errands}", lists: fakeLists{get: getList(inbox, errands)}},
+		{name: "get_default", method: "GET", path: "/api/lists/" + domain.DefaultListID, lists: fakeLists{get: getList(emptyInbox)}},
+		{name: "get_not_found", method: "GET", path: "/api/lists/" + missingID, lists: fakeLists{get: getList(inbox)}},
+		{name: "create", method: "POST", path: "/api/lists", body: `{"name":"Errands"}`, lists: fakeLists{create: createList}},
+		{name: "create_blank", method: "POST", path: "/api/lists", body: `{"name":" "}`, lists: fakeLists{create: createList}},
+		{name: "create_unavailable", method: "POST", path: "/api/lists", body: `{"name":"Errands"}`, unavailable: true},
+		{name: "rename", method: "PATCH", path: "/api/lists/{errands}", body: `{"name":"Weekend"}`, lists: fakeLists{rename: renameList(inbox, errands)}},
+		{name: "rename_not_found", method: "PATCH", path: "/api/lists/" + missingID, body: `{"name":"Weekend"}`, lists: fakeLists{rename: renameList(inbox)}},
+		{name: "delete", method: "DELETE", path: "/api/lists/{errands}", lists: fakeLists{delete: deleteList(inbox, emptyErrands)}},
+		{name: "delete_not_empty", method: "DELETE", path: "/api/lists/{errands}", lists: fakeLists{delete: deleteList(inbox, errands)}},
+		{name: "delete_default", method: "DELETE", path: "/api/lists/" + domain.DefaultListID, lists: fakeLists{delete: deleteList(inbox)}},
+		{name: "delete_not_found", method: "DELETE", path: "/api/lists/" + missingID, lists: fakeLists{delete: deleteList(inbox)}},
+	})
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxBodyBytes bounds request bodies; the largest legitimate one, a todo
+// with a full-length title and a recurrence rule, is a few kilobytes
+const maxBodyBytes = 64 << 10
+
+// decodeJSON reads a request body holding exactly one JSON value into dst,
+// writing the error response itself when it cannot. Bodies over
+// maxBodyBytes get a 413; invalid UTF-8, which encoding/json would silently
+// replace, and fields dst does not have are rejected with a 400.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must be at most %d bytes", maxBodyBytes))
+		return false
+	}
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if !utf8.Valid(body) {
+		respondError(w, r, http.StatusBadRequest, "Request body must be valid UTF-8")
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		respondDecodeError(w, r, err)
+		return false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		respondError(w, r, http.StatusBadRequest, "Request body must contain a single JSON value")
+		return false
+	}
+	return true
+}
+
+// respondDecodeError reports a JSON decoding failure, as a field error when
+// it can be pinned to one field
+func respondDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		respondDomainError(w, r, domain.NewValidationError(typeErr.Field, fmt.Sprintf("%s cannot be a JSON %s", typeErr.Field, typeErr.Value)))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		respondDomainError(w, r, domain.NewValidationError(field, "unknown field "+field))
+	default:
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+	}
+}
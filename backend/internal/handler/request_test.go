@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	runRouteTests(t, "request_body", []routeTest{
+		{name: "empty", method: "POST", path: "/api/todos"},
+		{name: "malformed", method: "POST", path: "/api/todos", body: `{"title":`},
+		{name: "not_an_object", method: "POST", path: "/api/todos", body: `["Buy milk"]`},
+		{name: "wrong_type", method: "POST", path: "/api/todos", body: `{"title":42}`},
+		{name: "unknown_field", method: "POST", path: "/api/todos", body: `{"title":"Buy milk","done":true}`},
+		{name: "trailing_value", method: "POST", path: "/api/todos", body: `{"title":"Buy milk"} {"title":"Buy bread"}`},
+		{name: "invalid_utf8", method: "POST", path: "/api/todos", body: "{\"title\":\"Buy \xff milk\"}"},
+		{name: "too_large", method: "POST", path: "/api/todos", body: `{"title":"` + strings.Repeat("a", maxBodyBytes) + `"}`},
+		{name: "patch_malformed", method: "PATCH", path: "/api/todos/{milk}", body: `{"title"`},
+		{name: "tag_unknown_field", method: "POST", path: "/api/tags", body: `{"label":"dairy"}`},
+		{name: "list_trailing_value", method: "POST", path: "/api/lists", body: `{"name":"Errands"}]`},
+	})
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Problem types of the domain error kinds. They are URI references relative
+// to the API; other errors use "about:blank" and the HTTP status text.
+const (
+	problemTypeValidation      = "/problems/validation-error"
+	problemTypeNotFound        = "/problems/not-found"
+	problemTypeConflict        = "/problems/conflict"
+	problemTypeUnauthenticated = "/problems/unauthenticated"
+	problemTypeForbidden       = "/problems/forbidden"
+)
+
+// Problem is an RFC 9457 problem details object. Instance identifies the
+// request by its request ID so a report can be matched with the server log;
+// Errors lists the invalid fields of a validation problem.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
+
+// respondJSON sends a JSON response
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		// The status line is already sent, so the failure can only be logged
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// respondProblem sends a problem details response, filling in the instance
+func respondProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		problem.Instance = "urn:request:" + id
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("Failed to write problem response: %v", err)
+	}
+}
+
+// respondError sends a problem with a generic type for the status and the
+// given client-facing detail
+func respondError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	respondProblem(w, r, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+// respondDomainError sends the response for an error returned by a usecase,
+// as described by domainProblem. A 401 tells the client how to authenticate.
+func respondDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := domainProblem(r, err)
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	respondProblem(w, r, problem)
+}
+
+// domainProblem describes an error returned by a usecase, choosing the status
+// from the kind of domain error: 400 for validation errors (with their field
+// details), 401 and 403 for missing and insufficient credentials, 404 for
+// missing resources and 409 for conflicts. Any other error
+// is logged and reported as a bare 500, since its text may describe the database.
+func domainProblem(r *http.Request, err error) Problem {
+	var validation *domain.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		return Problem{
+			Type:   problemTypeValidation,
+			Title:  "Invalid request",
+			Status: http.StatusBadRequest,
+			Detail: sentence(err.Error()),
+			Errors: validation.Fields,
+		}
+	case errors.Is(err, domain.ErrValidation):
+		return Problem{
+			Type:   problemTypeValidation,
+			Title:  "Invalid request",
+			Status: http.StatusBadRequest,
+			Detail: sentence(err.Error()),
+		}
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return Problem{
+			Type:   problemTypeUnauthenticated,
+			Title:  "Authentication required",
+			Status: http.StatusUnauthorized,
+			Detail: sentence(err.Error()),
+		}
+	case errors.Is(err, domain.ErrForbidden):
+		return Problem{
+			Type:   problemTypeForbidden,
+			Title:  "Access denied",
+			Status: http.StatusForbidden,
+			Detail: sentence(err.Error()),
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		return Problem{
+			Type:   problemTypeNotFound,
+			Title:  "Resource not found",
+			Status: http.StatusNotFound,
+			Detail: sentence(err.Error()),
+		}
+	case errors.Is(err, domain.ErrConflict):
+		return Problem{
+			Type:   problemTypeConflict,
+			Title:  "Conflict with the current state",
+			Status: http.StatusConflict,
+			Detail: sentence(err.Error()),
+		}
+	default:
+		log.Printf("[%s] %s %s: %v", middleware.GetReqID(r.Context()), r.Method, r.URL.Path, err)
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+			Detail: "An unexpected error occurred",
+		}
+	}
+}
+
+// sentence capitalizes the first letter of a Go error message for display
+func sentence(message string) string {
+	if message == "" {
+		return message
+	}
+	r, size := utf8.DecodeRuneInString(message)
+	return string(unicode.ToUpper(r)) + message[size:]
+}
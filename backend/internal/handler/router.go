@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"backend/internal/usecase"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,7 +11,7 @@ import (
 )
 
 // NewRouter creates a new chi router with CORS middleware
-func NewRouter(todoHandler *TodoHandler) http.Handler {
+func NewRouter(todoHandler *TodoHandler, tagHandler *TagHandler, listHandler *ListHandler, idempotency *Idempotency) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
@@ -20,13 +22,26 @@ func NewRouter(todoHandler *TodoHandler) http.Handler {
 	// CORS configuration for localhost:3000 (Nuxt frontend)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	// The API token goes along with every usecase call, for an
+	// authorization decorator to check
+	r.Use(bearerToken)
+
+	// Unknown routes answer with problem details like every other error
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		respondError(w, r, http.StatusNotFound, "No such endpoint")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Allow", strings.Join(allowedMethods(r, req.URL.Path), ", "))
+		respondError(w, req, http.StatusMethodNotAllowed, "The endpoint does not support "+req.Method)
+	})
+
 	// Health check endpoint
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -42,11 +57,103 @@ func NewRouter(todoHandler *TodoHandler) http.Handler {
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/todos", func(r chi.Router) {
 			r.Get("/", todoHandler.ListTodos)
-			r.Post("/", todoHandler.CreateTodo)
-			r.Patch("/{id}", todoHandler.UpdateTodoCompleted)
+			r.Get("/search", todoHandler.SearchTodos)
+			r.Get("/summary/priority", todoHandler.PrioritySummary)
+			r.With(idempotency.Middleware).Post("/", todoHandler.CreateTodo)
+			r.With(idempotency.Middleware).Post("/batch", todoHandler.BatchTodos)
+			r.Post("/complete-all", todoHandler.CompleteAll)
+			r.Post("/clear-completed", todoHandler.ClearCompleted)
+			r.Get("/{id}", todoHandler.GetTodo)
+			r.Get("/{id}/subtree", todoHandler.GetTodoSubtree)
+			r.Patch("/{id}", todoHandler.UpdateTodo)
+			r.Put("/{id}/due_at", todoHandler.UpdateTodoDueAt)
+			r.Post("/{id}/move", todoHandler.MoveTodo)
 			r.Delete("/{id}", todoHandler.DeleteTodo)
+			r.Post("/{id}/restore", todoHandler.RestoreTodo)
+			r.Put("/{id}/tags/{tagID}", todoHandler.AttachTag)
+			r.Delete("/{id}/tags/{tagID}", todoHandler.DetachTag)
+		})
+
+		r.Route("/trash", func(r chi.Router) {
+			r.Get("/", todoHandler.ListTrash)
+			r.Delete("/", todoHandler.EmptyTrash)
+			r.Delete("/{id}", todoHandler.PurgeTodo)
+		})
+
+		r.Route("/tags", func(r chi.Router) {
+			r.Get("/", tagHandler.ListTags)
+			r.Post("/", tagHandler.CreateTag)
+			r.Get("/{id}", tagHandler.GetTag)
+			r.Patch("/{id}", tagHandler.RenameTag)
+			r.Delete("/{id}", tagHandler.DeleteTag)
+		})
+
+		r.Route("/lists", func(r chi.Router) {
+			r.Get("/", listHandler.ListLists)
+			r.Post("/", listHandler.CreateList)
+			r.Get("/{id}", listHandler.GetList)
+			r.Patch("/{id}", listHandler.RenameList)
+			r.Delete("/{id}", listHandler.DeleteList)
 		})
 	})
 
 	return r
 }
+
+// routeMethods are the methods allowedMethods looks for
+var routeMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// allowedMethods returns the methods router has a route for at path, for
+// the Allow header of a 405 response. chi keeps the methods it found to
+// itself once a custom handler answers 405, so the routes are matched again.
+func allowedMethods(router chi.Routes, path string) []string {
+	found := make(map[string]bool)
+	chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if routeMatches(route, path) {
+			found[method] = true
+		}
+		return nil
+	})
+	var allowed []string
+	for _, method := range routeMethods {
+		if found[method] {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// routeMatches reports whether path matches a chi route pattern, in which a
+// {param} stands for one segment and a trailing * for the rest of the path
+func routeMatches(route string, path string) bool {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range routeSegments {
+		switch {
+		case segment == "*":
+			return true
+		case i >= len(pathSegments):
+			return false
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			if pathSegments[i] == "" {
+				return false
+			}
+		case segment != pathSegments[i]:
+			return false
+		}
+	}
+	return len(routeSegments) == len(pathSegments)
+}
+
+// bearerToken passes the token of an "Authorization: Bearer" header on in
+// the request context. Checking it is up to the services, so requests
+// without one go through as they are.
+func bearerToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			r = r.WithContext(usecase.WithToken(r.Context(), strings.TrimSpace(token)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
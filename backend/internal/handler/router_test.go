@@ -0,0 +1,736 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/usecase"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// update rewrites the golden files from the responses instead of checking them:
+//
+//	go test ./internal/handler -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// routeTest is one request sent through the router, whose response is
+// compared with the golden file testdata/<group>/<name>.golden.json.
+// {name} placeholders in path, header values and body stand for the IDs of
+// the fixtures of that name.
+type routeTest struct {
+	name   string
+	method string
+	path   string
+	header map[string]string
+	body   string
+	// todos, tags and lists stand in for the services the handlers call
+	todos fakeTodos
+	tags  fakeTags
+	lists fakeLists
+	// before are requests sent first, through the same router, whose
+	// responses are not checked
+	before []routeTest
+	// unavailable makes every service call the test did not set up fail,
+	// as it would with the database down
+	unavailable bool
+	// decorators wrap the services the handlers call
+	decorators []usecase.Decorator
+}
+
+// fixtureNames maps the IDs of the fixtures to their names
+var fixtureNames = make(map[string]string)
+
+// fixtureID returns the ID of the fixture called name, which is the same on
+// every run
+func fixtureID(name string) string {
+	id := uuid.NewSHA1(uuid.NameSpaceURL, []byte("fixture:"+name)).String()
+	fixtureNames[id] = name
+	return id
+}
+
+// fixtureTime is when the fixtures were created and last updated
+var fixtureTime = time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// expand replaces the {name} placeholders in s with the IDs of the fixtures
+func expand(s string) string {
+	var pairs []string
+	for id, name := range fixtureNames {
+		pairs = append(pairs, "{"+name+"}", id)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// newRouter wires the handlers the way main does, on the given services
+func newRouter(todos usecase.TodoService, tags usecase.TagService, lists usecase.ListService, decorators ...usecase.Decorator) http.Handler {
+	return NewRouter(
+		NewTodoHandler(usecase.DecorateTodoService(todos, decorators...)),
+		NewTagHandler(usecase.DecorateTagService(tags, decorators...)),
+		NewListHandler(usecase.DecorateListService(lists, decorators...)),
+		NewIdempotency(newFakeIdempotency()),
+	)
+}
+
+// runRouteTests runs each test against its own fake services
+func runRouteTests(t *testing.T, group string, tests []routeTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fake{t: t, unavailable: tt.unavailable}
+			todos, tags, lists := tt.todos, tt.tags, tt.lists
+			todos.fake, tags.fake, lists.fake = f, f, f
+			router := newRouter(&todos, &tags, &lists, tt.decorators...)
+
+			for _, before := range tt.before {
+				serve(router, before)
+			}
+			rec := serve(router, tt)
+			checkGolden(t, filepath.Join("testdata", group, tt.name+".golden.json"), rec)
+		})
+	}
+}
+
+// serve sends the request of tt through router
+func serve(router http.Handler, tt routeTest) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(tt.method, expand(tt.path), strings.NewReader(expand(tt.body)))
+	for name, value := range tt.header {
+		req.Header.Set(name, expand(value))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// goldenHeaders are the response headers recorded in golden files
+var goldenHeaders = []string{
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Origin",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+	"Allow",
+	"Content-Type",
+	"ETag",
+	"Idempotent-Replayed",
+	"Link",
+	"Vary",
+	"WWW-Authenticate",
+}
+
+// golden is the recorded form of a response. A JSON body is kept as JSON
+// so that the file shows its shape, any other body as a string.
+type golden struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   interface{}       `json:"body"`
+}
+
+// checkGolden compares a response with the golden file at path, or rewrites
+// the file with -update
+func checkGolden(t *testing.T, path string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	got, err := goldenResponse(rec)
+	if err != nil {
+		t.Fatalf("failed to record response: %v", err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response does not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	cursorPattern = regexp.MustCompile(`cursor=[^&>]*`)
+)
+
+// timestampFields are set by the server's clock, so their values differ on
+// every run and are left out of golden files
+var timestampFields = map[string]bool{"created_at": true, "updated_at": true, "deleted_at": true}
+
+// goldenResponse renders a response as a golden file. What varies between
+// runs is replaced: the IDs of fixtures by their {name}, other generated IDs by
+// {new-N} in order of appearance, timestamps, cursors and request IDs by a
+// placeholder.
+func goldenResponse(rec *httptest.ResponseRecorder) ([]byte, error) {
+	g := golden{Status: rec.Code, Header: make(map[string]string)}
+	for _, name := range goldenHeaders {
+		if v := rec.Header().Values(name); len(v) > 0 {
+			g.Header[name] = strings.Join(v, ", ")
+		}
+	}
+	if link, ok := g.Header["Link"]; ok {
+		g.Header["Link"] = cursorPattern.ReplaceAllString(link, "cursor={cursor}")
+	}
+
+	if body := rec.Body.Bytes(); len(body) > 0 {
+		if strings.Contains(rec.Header().Get("Content-Type"), "json") {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var v interface{}
+			if err := decoder.Decode(&v); err != nil {
+				return nil, fmt.Errorf("invalid JSON body %q: %w", body, err)
+			}
+			g.Body = normalizeJSON(v)
+		} else {
+			g.Body = string(body)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(g); err != nil {
+		return nil, err
+	}
+
+	generated := make(map[string]string)
+	return uuidPattern.ReplaceAllFunc(buf.Bytes(), func(id []byte) []byte {
+		switch s := string(id); {
+		case s == domain.DefaultListID:
+			return id
+		case fixtureNames[s] != "":
+			return []byte("{" + fixtureNames[s] + "}")
+		default:
+			if _, ok := generated[s]; !ok {
+				generated[s] = fmt.Sprintf("{new-%d}", len(generated)+1)
+			}
+			return []byte(generated[s])
+		}
+	}), nil
+}
+
+// normalizeJSON replaces the timestamps and request IDs in a decoded body
+func normalizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch {
+			case timestampFields[key] && value != nil:
+				v[key] = "{time}"
+			case key == "instance":
+				v[key] = "urn:request:{request-id}"
+			default:
+				v[key] = normalizeJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeJSON(value)
+		}
+	}
+	return v
+}
+
+// errUnavailable is what every service call of an unavailable test returns
+var errUnavailable = errors.New("connection refused")
+
+// errUnexpected is what a fake returns from a call the test did not expect,
+// failing the test
+var errUnexpected = errors.New("unexpected call")
+
+// fake is what the fake services share. A call to a method whose function
+// the test did not set fails the test, unless the test is unavailable, and
+// so is a call that the function reports as unexpected.
+type fake struct {
+	t           *testing.T
+	unavailable bool
+}
+
+// unset answers a call to a method whose function the test did not set
+func (f fake) unset(method string) error {
+	if f.unavailable {
+		return errUnavailable
+	}
+	f.t.Errorf("unexpected call to %s", method)
+	return errUnexpected
+}
+
+// check fails the test if a function reported its call as unexpected
+func (f fake) check(err error) error {
+	if errors.Is(err, errUnexpected) {
+		f.t.Error(err)
+	}
+	return err
+}
+
+// unexpected reports a call whose arguments a fake's function did not expect
+func unexpected(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{errUnexpected}, args...)...)
+}
+
+// fakeTodos is a TodoService whose methods call the functions a test sets
+type fakeTodos struct {
+	fake
+	list            func(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error)
+	search          func(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error)
+	countByPriority func(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error)
+	get             func(ctx context.Context, id string) (*domain.Todo, error)
+	getSubtree      func(ctx context.Context, id string) (*domain.TodoTree, error)
+	create          func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error)
+	update          func(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error)
+	move            func(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error)
+	attachTag       func(ctx context.Context, todoID string, tagID string) (*domain.Todo, error)
+	detachTag       func(ctx context.Context, todoID string, tagID string) (*domain.Todo, error)
+	delete          func(ctx context.Context, id string) error
+	batch           func(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error)
+	completeAll     func(ctx context.Context, filter domain.TodoFilter) (int, error)
+	clearCompleted  func(ctx context.Context, filter domain.TodoFilter) (int, error)
+	listTrash       func(ctx context.Context) ([]domain.Todo, error)
+	restore         func(ctx context.Context, id string) (*domain.Todo, error)
+	purge           func(ctx context.Context, id string) error
+	emptyTrash      func(ctx context.Context) (int, error)
+}
+
+var _ usecase.TodoService = (*fakeTodos)(nil)
+
+func (f *fakeTodos) List(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+	if f.list == nil {
+		return nil, f.unset("TodoService.List")
+	}
+	page, err := f.list(ctx, query)
+	return page, f.check(err)
+}
+
+func (f *fakeTodos) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	if f.search == nil {
+		return nil, f.unset("TodoService.Search")
+	}
+	todos, err := f.search(ctx, search)
+	return todos, f.check(err)
+}
+
+func (f *fakeTodos) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	if f.countByPriority == nil {
+		return nil, f.unset("TodoService.CountByPriority")
+	}
+	counts, err := f.countByPriority(ctx, filter)
+	return counts, f.check(err)
+}
+
+func (f *fakeTodos) Get(ctx context.Context, id string) (*domain.Todo, error) {
+	if f.get == nil {
+		return nil, f.unset("TodoService.Get")
+	}
+	todo, err := f.get(ctx, id)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) GetSubtree(ctx context.Context, id string) (*domain.TodoTree, error) {
+	if f.getSubtree == nil {
+		return nil, f.unset("TodoService.GetSubtree")
+	}
+	tree, err := f.getSubtree(ctx, id)
+	return tree, f.check(err)
+}
+
+func (f *fakeTodos) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	if f.create == nil {
+		return nil, f.unset("TodoService.Create")
+	}
+	todo, err := f.create(ctx, params)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) Update(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+	if f.update == nil {
+		return nil, f.unset("TodoService.Update")
+	}
+	todo, err := f.update(ctx, id, patch)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) Move(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+	if f.move == nil {
+		return nil, f.unset("TodoService.Move")
+	}
+	todo, err := f.move(ctx, id, move)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) AttachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	if f.attachTag == nil {
+		return nil, f.unset("TodoService.AttachTag")
+	}
+	todo, err := f.attachTag(ctx, todoID, tagID)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) DetachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	if f.detachTag == nil {
+		return nil, f.unset("TodoService.DetachTag")
+	}
+	todo, err := f.detachTag(ctx, todoID, tagID)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) Delete(ctx context.Context, id string) error {
+	if f.delete == nil {
+		return f.unset("TodoService.Delete")
+	}
+	return f.check(f.delete(ctx, id))
+}
+
+func (f *fakeTodos) Batch(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error) {
+	if f.batch == nil {
+		return nil, f.unset("TodoService.Batch")
+	}
+	results, err := f.batch(ctx, ops, mode)
+	return results, f.check(err)
+}
+
+func (f *fakeTodos) CompleteAll(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	if f.completeAll == nil {
+		return 0, f.unset("TodoService.CompleteAll")
+	}
+	n, err := f.completeAll(ctx, filter)
+	return n, f.check(err)
+}
+
+func (f *fakeTodos) ClearCompleted(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	if f.clearCompleted == nil {
+		return 0, f.unset("TodoService.ClearCompleted")
+	}
+	n, err := f.clearCompleted(ctx, filter)
+	return n, f.check(err)
+}
+
+func (f *fakeTodos) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	if f.listTrash == nil {
+		return nil, f.unset("TodoService.ListTrash")
+	}
+	todos, err := f.listTrash(ctx)
+	return todos, f.check(err)
+}
+
+func (f *fakeTodos) Restore(ctx context.Context, id string) (*domain.Todo, error) {
+	if f.restore == nil {
+		return nil, f.unset("TodoService.Restore")
+	}
+	todo, err := f.restore(ctx, id)
+	return todo, f.check(err)
+}
+
+func (f *fakeTodos) Purge(ctx context.Context, id string) error {
+	if f.purge == nil {
+		return f.unset("TodoService.Purge")
+	}
+	return f.check(f.purge(ctx, id))
+}
+
+func (f *fakeTodos) EmptyTrash(ctx context.Context) (int, error) {
+	if f.emptyTrash == nil {
+		return 0, f.unset("TodoService.EmptyTrash")
+	}
+	n, err := f.emptyTrash(ctx)
+	return n, f.check(err)
+}
+
+// fakeTags is a TagService whose methods call the functions a test sets
+type fakeTags struct {
+	fake
+	list   func(ctx context.Context) ([]domain.Tag, error)
+	get    func(ctx context.Context, id string) (*domain.Tag, error)
+	create func(ctx context.Context, name string) (*domain.Tag, error)
+	rename func(ctx context.Context, id string, name string) (*domain.Tag, error)
+	delete func(ctx context.Context, id string) error
+}
+
+var _ usecase.TagService = (*fakeTags)(nil)
+
+func (f *fakeTags) List(ctx context.Context) ([]domain.Tag, error) {
+	if f.list == nil {
+		return nil, f.unset("TagService.List")
+	}
+	tags, err := f.list(ctx)
+	return tags, f.check(err)
+}
+
+func (f *fakeTags) Get(ctx context.Context, id string) (*domain.Tag, error) {
+	if f.get == nil {
+		return nil, f.unset("TagService.Get")
+	}
+	tag, err := f.get(ctx, id)
+	return tag, f.check(err)
+}
+
+func (f *fakeTags) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	if f.create == nil {
+		return nil, f.unset("TagService.Create")
+	}
+	tag, err := f.create(ctx, name)
+	return tag, f.check(err)
+}
+
+func (f *fakeTags) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	if f.rename == nil {
+		return nil, f.unset("TagService.Rename")
+	}
+	tag, err := f.rename(ctx, id, name)
+	return tag, f.check(err)
+}
+
+func (f *fakeTags) Delete(ctx context.Context, id string) error {
+	if f.delete == nil {
+		return f.unset("TagService.Delete")
+	}
+	return f.check(f.delete(ctx, id))
+}
+
+// fakeLists is a ListService whose methods call the functions a test sets
+type fakeLists struct {
+	fake
+	list   func(ctx context.Context) ([]domain.List, error)
+	get    func(ctx context.Context, id string) (*domain.List, error)
+	create func(ctx context.Context, name string) (*domain.List, error)
+	rename func(ctx context.Context, id string, name string) (*domain.List, error)
+	delete func(ctx context.Context, id string) error
+}
+
+var _ usecase.ListService = (*fakeLists)(nil)
+
+func (f *fakeLists) List(ctx context.Context) ([]domain.List, error) {
+	if f.list == nil {
+		return nil, f.unset("ListService.List")
+	}
+	lists, err := f.list(ctx)
+	return lists, f.check(err)
+}
+
+func (f *fakeLists) Get(ctx context.Context, id string) (*domain.List, error) {
+	if f.get == nil {
+		return nil, f.unset("ListService.Get")
+	}
+	list, err := f.get(ctx, id)
+	return list, f.check(err)
+}
+
+func (f *fakeLists) Create(ctx context.Context, name string) (*domain.List, error) {
+	if f.create == nil {
+		return nil, f.unset("ListService.Create")
+	}
+	list, err := f.create(ctx, name)
+	return list, f.check(err)
+}
+
+func (f *fakeLists) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	if f.rename == nil {
+		return nil, f.unset("ListService.Rename")
+	}
+	list, err := f.rename(ctx, id, name)
+	return list, f.check(err)
+}
+
+func (f *fakeLists) Delete(ctx context.Context, id string) error {
+	if f.delete == nil {
+		return f.unset("ListService.Delete")
+	}
+	return f.check(f.delete(ctx, id))
+}
+
+// fakeIdempotency keeps the claims and responses of the Idempotency
+// middleware in a map, answering Begin the way IdempotencyUsecase does
+type fakeIdempotency struct {
+	records map[string]domain.IdempotencyRecord
+	claims  int
+}
+
+func newFakeIdempotency() *fakeIdempotency {
+	return &fakeIdempotency{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (f *fakeIdempotency) Begin(ctx context.Context, key string, requestHash string) (*domain.StoredResponse, string, error) {
+	held, ok := f.records[key]
+	switch {
+	case !ok:
+		f.claims++
+		token := fmt.Sprintf("claim-%d", f.claims)
+		f.records[key] = domain.IdempotencyRecord{Key: key, RequestHash: requestHash, Token: token}
+		return nil, token, nil
+	case held.RequestHash != requestHash:
+		return nil, "", domain.ErrIdempotencyKeyReused
+	case held.Response == nil:
+		return nil, "", domain.ErrIdempotencyKeyInFlight
+	}
+	return held.Response, "", nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, key string, token string, response domain.StoredResponse) error {
+	record, ok := f.records[key]
+	if !ok || record.Token != token || record.Response != nil {
+		return fmt.Errorf("idempotency key %q is no longer claimed", key)
+	}
+	record.Response = &response
+	f.records[key] = record
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, key string, token string) error {
+	if record, ok := f.records[key]; ok && record.Token == token && record.Response == nil {
+		delete(f.records, key)
+	}
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	runRouteTests(t, "router", []routeTest{
+		{name: "health", method: "GET", path: "/health"},
+		{name: "root", method: "GET", path: "/"},
+		{name: "unknown_route", method: "GET", path: "/api/nothing"},
+		{name: "unknown_method", method: "PUT", path: "/api/todos"},
+		{name: "unknown_method_on_todo", method: "POST", path: "/api/todos/" + missingID},
+		{
+			name:   "cors_preflight",
+			method: "OPTIONS",
+			path:   "/api/todos/" + domain.DefaultListID,
+			header: map[string]string{
+				"Origin":                         "http://localhost:3000",
+				"Access-Control-Request-Method":  "PATCH",
+				"Access-Control-Request-Headers": "Content-Type, If-Match",
+			},
+		},
+		{
+			name:   "cors_preflight_other_origin",
+			method: "OPTIONS",
+			path:   "/api/todos",
+			header: map[string]string{
+				"Origin":                        "http://evil.example",
+				"Access-Control-Request-Method": "POST",
+			},
+		},
+		{
+			name:   "cors_simple_request",
+			method: "GET",
+			path:   "/api/tags",
+			header: map[string]string{"Origin": "http://localhost:3000"},
+			tags: fakeTags{list: func(ctx context.Context) ([]domain.Tag, error) {
+				return []domain.Tag{}, nil
+			}},
+		},
+	})
+}
+
+func TestAuthorization(t *testing.T) {
+	authorized := []usecase.Decorator{usecase.Authorization(usecase.Tokens{
+		"admin":  domain.AccessWrite,
+		"viewer": domain.AccessRead,
+	})}
+	runRouteTests(t, "authorization", []routeTest{
+		{name: "missing_token", method: "GET", path: "/api/todos", decorators: authorized},
+		{
+			name:       "unknown_token",
+			method:     "GET",
+			path:       "/api/todos",
+			header:     map[string]string{"Authorization": "Bearer admin2"},
+			decorators: authorized,
+		},
+		{
+			name:       "other_scheme",
+			method:     "GET",
+			path:       "/api/todos",
+			header:     map[string]string{"Authorization": "Basic YWRtaW46"},
+			decorators: authorized,
+		},
+		{
+			name:       "read",
+			method:     "GET",
+			path:       "/api/todos/{milk}",
+			header:     map[string]string{"Authorization": "Bearer viewer"},
+			todos:      fakeTodos{get: getTodo(milk)},
+			decorators: authorized,
+		},
+		{
+			name:       "write_with_read_token",
+			method:     "POST",
+			path:       "/api/todos",
+			header:     map[string]string{"Authorization": "Bearer viewer"},
+			body:       `{"title":"Buy milk"}`,
+			decorators: authorized,
+		},
+		{
+			name:       "write",
+			method:     "POST",
+			path:       "/api/todos",
+			header:     map[string]string{"Authorization": "bearer admin"},
+			body:       `{"title":"Buy milk"}`,
+			todos:      fakeTodos{create: createTodo},
+			decorators: authorized,
+		},
+		{
+			name:       "tag_write_with_read_token",
+			method:     "DELETE",
+			path:       "/api/tags/" + missingID,
+			header:     map[string]string{"Authorization": "Bearer viewer"},
+			decorators: authorized,
+		},
+		{name: "list_missing_token", method: "GET", path: "/api/lists", decorators: authorized},
+		{
+			name:       "batch_with_read_token",
+			method:     "POST",
+			path:       "/api/todos/batch",
+			header:     map[string]string{"Authorization": "Bearer viewer"},
+			body:       `{"operations":[{"op":"delete","id":"` + missingID + `"}]}`,
+			decorators: authorized,
+		},
+		{
+			name: "idempotent_retry_with_token",
+			// A refusal is not stored, so the retry with a token runs
+			before: []routeTest{{
+				method: "POST",
+				path:   "/api/todos",
+				header: map[string]string{"Idempotency-Key": "create-milk"},
+				body:   `{"title":"Buy milk"}`,
+			}},
+			method:     "POST",
+			path:       "/api/todos",
+			header:     map[string]string{"Idempotency-Key": "create-milk", "Authorization": "Bearer admin"},
+			body:       `{"title":"Buy milk"}`,
+			todos:      fakeTodos{create: createOnce(createTodo)},
+			decorators: authorized,
+		},
+		{
+			name: "idempotent_key_of_other_caller",
+			// The key is not the viewer's, so nothing is replayed to it
+			before: []routeTest{{
+				method: "POST",
+				path:   "/api/todos",
+				header: map[string]string{"Idempotency-Key": "create-milk", "Authorization": "Bearer admin"},
+				body:   `{"title":"Buy milk"}`,
+			}},
+			method:     "POST",
+			path:       "/api/todos",
+			header:     map[string]string{"Idempotency-Key": "create-milk", "Authorization": "Bearer viewer"},
+			body:       `{"title":"Buy milk"}`,
+			todos:      fakeTodos{create: createOnce(createTodo)},
+			decorators: authorized,
+		},
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/usecase"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TagHandler handles HTTP requests for tags
+type TagHandler struct {
+	usecase usecase.TagService
+}
+
+// NewTagHandler creates a new TagHandler
+func NewTagHandler(usecase usecase.TagService) *TagHandler {
+	return &TagHandler{usecase: usecase}
+}
+
+// TagRequest represents the request body for creating or renaming a tag
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+// ListTags handles GET /api/tags
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.usecase.List(r.Context())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	if tags == nil {
+		tags = []domain.Tag{}
+	}
+
+	respondJSON(w, http.StatusOK, tags)
+}
+
+// GetTag handles GET /api/tags/{id}
+func (h *TagHandler) GetTag(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.usecase.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tag)
+}
+
+// CreateTag handles POST /api/tags
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeTagName(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.usecase.Create(r.Context(), name)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, tag)
+}
+
+// RenameTag handles PATCH /api/tags/{id}
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeTagName(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.usecase.Rename(r.Context(), chi.URLParam(r, "id"), name)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tag)
+}
+
+// DeleteTag handles DELETE /api/tags/{id}
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTagName reads the tag name from the request body, writing the error
+// response itself when the body is invalid. The name is validated by the usecase.
+func decodeTagName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req TagRequest
+	if !decodeJSON(w, r, &req) {
+		return "", false
+	}
+	return req.Name, true
+}
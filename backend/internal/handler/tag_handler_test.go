@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// The pantry tags, whose names sort as bakery, dairy
+var (
+	dairy  = domain.Tag{ID: fixtureID("dairy"), Name: "dairy", CreatedAt: fixtureTime}
+	bakery = domain.Tag{ID: fixtureID("bakery"), Name: "bakery", CreatedAt: fixtureTime}
+)
+
+// getTag returns a Get that finds the given tags
+func getTag(tags ...domain.Tag) func(ctx context.Context, id string) (*domain.Tag, error) {
+	return func(ctx context.Context, id string) (*domain.Tag, error) {
+		for _, tag := range tags {
+			if tag.ID == id {
+				return &tag, nil
+			}
+		}
+		return nil, domain.ErrTagNotFound
+	}
+}
+
+// createTag is a Create that validates the name as the usecase does and
+// creates a tag of that name
+func createTag(ctx context.Context, name string) (*domain.Tag, error) {
+	name, err := domain.NormalizeName(name, domain.TagNameLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Tag{ID: uuid.NewString(), Name: name, CreatedAt: fixtureTime}, nil
+}
+
+// renameTag returns a Rename that validates the name as the usecase does and
+// renames the given tags
+func renameTag(tags ...domain.Tag) func(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	get := getTag(tags...)
+	return func(ctx context.Context, id string, name string) (*domain.Tag, error) {
+		tag, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if tag.Name, err = domain.NormalizeName(name, domain.TagNameLimit); err != nil {
+			return nil, err
+		}
+		return tag, nil
+	}
+}
+
+// deleteTag returns a Delete that finds the given tags
+func deleteTag(tags ...domain.Tag) func(ctx context.Context, id string) error {
+	get := getTag(tags...)
+	return func(ctx context.Context, id string) error {
+		_, err := get(ctx, id)
+		return err
+	}
+}
+
+// listTags returns a List that finds tags
+func listTags(tags ...domain.Tag) func(ctx context.Context) ([]domain.Tag, error) {
+	return func(ctx context.Context) ([]domain.Tag, error) {
+		return append([]domain.Tag{}, tags...), nil
+	}
+}
+
+func TestTagRoutes(t *testing.T) {
+	runRouteTests(t, "tags", []routeTest{
+		{name: "list_empty", method: "GET", path: "/api/tags", tags: fakeTags{list: listTags()}},
+		{name: "list", method: "GET", path: "/api/tags", tags: fakeTags{list: listTags(bakery, dairy)}},
+		{name: "list_unavailable", method: "GET", path: "/api/tags", unavailable: true},
+		{name: "get", method: "GET", path: "/api/tags/{dairy}", tags: fakeTags{get: getTag(dairy, bakery)}},
+		{name: "get_not_found", method: "GET", path: "/api/tags/" + missingID, tags: fakeTags{get: getTag()}},
+		{name: "create", method: "POST", path: "/api/tags", body: `{"name":" Dairy "}`, tags: fakeTags{create: createTag}},
+		{
+			name:   "create_duplicate",
+			method: "POST",
+			path:   "/api/tags",
+			body:   `{"name":"DAIRY"}`,
+			tags: fakeTags{create: func(ctx context.Context, name string) (*domain.Tag, error) {
+				return nil, domain.ErrTagExists
+			}},
+		},
+		{name: "create_blank", method: "POST", path: "/api/tags", body: `{"name":""}`, tags: fakeTags{create: createTag}},
+		{name: "create_unavailable", method: "POST", path: "/api/tags", body: `{"name":"dairy"}`, unavailable: true},
+		{name: "rename", method: "PATCH", path: "/api/tags/{dairy}", body: `{"name":"milk products"}`, tags: fakeTags{rename: renameTag(dairy, bakery)}},
+		{
+			name:   "rename_duplicate",
+			method: "PATCH",
+			path:   "/api/tags/{dairy}",
+			body:   `{"name":"Bakery"}`,
+			tags: fakeTags{rename: func(ctx context.Context, id string, name string) (*domain.Tag, error) {
+				return nil, domain.ErrTagExists
+			}},
+		},
+		{name: "rename_not_found", method: "PATCH", path: "/api/tags/" + missingID, body: `{"name":"dairy"}`, tags: fakeTags{rename: renameTag()}},
+		{name: "delete", method: "DELETE", path: "/api/tags/{dairy}", tags: fakeTags{delete: deleteTag(dairy, bakery)}},
+		{name: "delete_not_found", method: "DELETE", path: "/api/tags/" + missingID, tags: fakeTags{delete: deleteTag()}},
+	})
+}
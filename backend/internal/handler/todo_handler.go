@@ -3,53 +3,148 @@ package handler
 import (
 	"backend/internal/domain"
 	"backend/internal/usecase"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
 // TodoHandler handles HTTP requests for todos
 type TodoHandler struct {
-	usecase *usecase.TodoUsecase
+	usecase usecase.TodoService
 }
 
 // NewTodoHandler creates a new TodoHandler
-func NewTodoHandler(usecase *usecase.TodoUsecase) *TodoHandler {
+func NewTodoHandler(usecase usecase.TodoService) *TodoHandler {
 	return &TodoHandler{usecase: usecase}
 }
 
 // CreateTodoRequest represents the request body for creating a todo
 type CreateTodoRequest struct {
-	Title string `json:"title"`
+	Title      string             `json:"title"`
+	DueAt      *time.Time         `json:"due_at"`
+	Priority   domain.Priority    `json:"priority"`
+	ListID     string             `json:"list_id"`
+	ParentID   *string            `json:"parent_id"`
+	Recurrence *domain.Recurrence `json:"recurrence"`
 }
 
-// UpdateTodoRequest represents the request body for updating a todo's completion status
+// UpdateTodoRequest represents a JSON Merge Patch body for updating a todo.
+// Omitted fields are left unchanged; due_at may be null to clear it.
 type UpdateTodoRequest struct {
-	IsCompleted bool `json:"is_completed"`
+	Title       domain.Optional[string]             `json:"title"`
+	IsCompleted domain.Optional[bool]               `json:"is_completed"`
+	DueAt       domain.Optional[*time.Time]         `json:"due_at"`
+	Priority    domain.Optional[domain.Priority]    `json:"priority"`
+	ListID      domain.Optional[string]             `json:"list_id"`
+	ParentID    domain.Optional[*string]            `json:"parent_id"`
+	Recurrence  domain.Optional[*domain.Recurrence] `json:"recurrence"`
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
+// MoveTodoRequest represents the request body for moving a todo.
+// Exactly one of before and after names the todo to move next to.
+type MoveTodoRequest struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// UpdateTodoDueAtRequest represents the request body for setting a todo's due date.
+// A null due_at clears the due date.
+type UpdateTodoDueAtRequest struct {
+	DueAt *time.Time `json:"due_at"`
 }
 
 // ListTodos handles GET /api/todos
 func (h *TodoHandler) ListTodos(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	todos, err := h.usecase.List(ctx)
+	query, err := parseTodoQuery(r.URL.Query())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	result, err := h.usecase.List(ctx, query)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		respondDomainError(w, r, err)
 		return
 	}
 
+	if link := paginationLinks(r.URL, result); link != "" {
+		w.Header().Set("Link", link)
+	}
+
 	// Return empty array instead of null when no todos
+	todos := result.Todos
 	if todos == nil {
 		todos = []domain.Todo{}
 	}
 
-	h.respondJSON(w, http.StatusOK, todos)
+	respondJSON(w, http.StatusOK, todos)
+}
+
+// SearchTodos handles GET /api/todos/search
+func (h *TodoHandler) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	search := domain.TodoSearch{
+		Query: domain.NormalizeText(query.Get("q")),
+		Mode:  domain.SearchMode(query.Get("mode")),
+	}
+	if search.Mode == "" {
+		search.Mode = domain.SearchModeSubstring
+	}
+
+	if search.Query == "" {
+		respondDomainError(w, r, domain.NewValidationError("q", "query parameter q is required"))
+		return
+	}
+	if domain.TextLength(search.Query) > domain.TitleLimit.Length {
+		respondDomainError(w, r, domain.NewValidationError("q", fmt.Sprintf("query parameter q must be at most %d characters", domain.TitleLimit.Length)))
+		return
+	}
+	if !search.Mode.IsValid() {
+		respondDomainError(w, r, domain.NewValidationError("mode", "invalid mode: must be one of substring, prefix, all_words"))
+		return
+	}
+
+	todos, err := h.usecase.Search(ctx, search)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	if todos == nil {
+		todos = []domain.Todo{}
+	}
+
+	respondJSON(w, http.StatusOK, todos)
+}
+
+// PrioritySummary handles GET /api/todos/summary/priority
+func (h *TodoHandler) PrioritySummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	counts, err := h.usecase.CountByPriority(ctx, filter)
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, counts)
 }
 
 // CreateTodo handles POST /api/todos
@@ -57,73 +152,387 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req CreateTodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	todo, err := h.usecase.Create(ctx, domain.CreateTodoParams{
+		Title:      req.Title,
+		DueAt:      req.DueAt,
+		Priority:   req.Priority,
+		ListID:     req.ListID,
+		ParentID:   req.ParentID,
+		Recurrence: req.Recurrence,
+	})
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondTodo(w, http.StatusCreated, todo)
+}
+
+// GetTodo handles GET /api/todos/{id}, answering 304 Not Modified when the
+// If-None-Match header lists the todo's current ETag
+func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
+	todo, err := h.usecase.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondDomainError(w, r, err)
 		return
 	}
 
-	if req.Title == "" {
-		h.respondError(w, http.StatusBadRequest, "Title is required")
+	if values := r.Header.Values("If-None-Match"); len(values) > 0 && etagMatches(values, todoETag(todo), true) {
+		w.Header().Set("ETag", todoETag(todo))
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	todo, err := h.usecase.Create(ctx, req.Title)
+	respondTodo(w, http.StatusOK, todo)
+}
+
+// GetTodoSubtree handles GET /api/todos/{id}/subtree
+func (h *TodoHandler) GetTodoSubtree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.usecase.GetSubtree(r.Context(), chi.URLParam(r, "id"))
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		respondDomainError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusCreated, todo)
+	respondJSON(w, http.StatusOK, tree)
 }
 
-// UpdateTodoCompleted handles PATCH /api/todos/{id}
-func (h *TodoHandler) UpdateTodoCompleted(w http.ResponseWriter, r *http.Request) {
+// UpdateTodo handles PATCH /api/todos/{id}. With an If-Match header the
+// patch only applies if the todo is still at a listed ETag.
+func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := chi.URLParam(r, "id")
 
+	if ct := r.Header.Get("Content-Type"); ct != "" && !isPatchContentType(ct) {
+		respondError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be application/json or application/merge-patch+json")
+		return
+	}
+
 	var req UpdateTodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validateUpdateTodoRequest(req); err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	ifVersion, ok := h.ifMatchVersion(w, r, id)
+	if !ok {
+		return
+	}
+
+	todo, err := h.usecase.Update(ctx, id, domain.TodoPatch{
+		Title:       req.Title,
+		IsCompleted: req.IsCompleted,
+		DueAt:       req.DueAt,
+		Priority:    req.Priority,
+		ListID:      req.ListID,
+		ParentID:    req.ParentID,
+		Recurrence:  req.Recurrence,
+		IfVersion:   ifVersion,
+	})
+	if err != nil {
+		respondWriteError(w, r, err, ifVersion)
+		return
+	}
+
+	respondTodo(w, http.StatusOK, todo)
+}
+
+// UpdateTodoDueAt handles PUT /api/todos/{id}/due_at, honouring If-Match
+// like UpdateTodo
+func (h *TodoHandler) UpdateTodoDueAt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	var req UpdateTodoDueAtRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ifVersion, ok := h.ifMatchVersion(w, r, id)
+	if !ok {
 		return
 	}
 
-	todo, err := h.usecase.UpdateCompleted(ctx, id, req.IsCompleted)
+	todo, err := h.usecase.Update(ctx, id, domain.TodoPatch{DueAt: domain.Some(req.DueAt), IfVersion: ifVersion})
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		respondWriteError(w, r, err, ifVersion)
 		return
 	}
 
-	if todo == nil {
-		h.respondError(w, http.StatusNotFound, "Todo not found")
+	respondTodo(w, http.StatusOK, todo)
+}
+
+// respondWriteError sends the response for a failed conditional write: a
+// version mismatch after an If-Match check means another write won the
+// race, which is a failed precondition rather than a plain conflict
+func respondWriteError(w http.ResponseWriter, r *http.Request, err error, ifVersion int) {
+	if ifVersion != 0 && errors.Is(err, domain.ErrVersionMismatch) {
+		respondPreconditionFailed(w, r, nil)
+		return
+	}
+	respondDomainError(w, r, err)
+}
+
+// MoveTodo handles POST /api/todos/{id}/move
+func (h *TodoHandler) MoveTodo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	var req MoveTodoRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if (req.Before == "") == (req.After == "") {
+		respondError(w, r, http.StatusBadRequest, "Exactly one of before and after is required")
+		return
+	}
+
+	todo, err := h.usecase.Move(ctx, id, domain.TodoMove{Before: req.Before, After: req.After})
+	if err != nil {
+		respondDomainError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, todo)
+	respondTodo(w, http.StatusOK, todo)
 }
 
-// DeleteTodo handles DELETE /api/todos/{id}
+// AttachTag handles PUT /api/todos/{id}/tags/{tagID}
+func (h *TodoHandler) AttachTag(w http.ResponseWriter, r *http.Request) {
+	todo, err := h.usecase.AttachTag(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "tagID"))
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondTodo(w, http.StatusOK, todo)
+}
+
+// DetachTag handles DELETE /api/todos/{id}/tags/{tagID}
+func (h *TodoHandler) DetachTag(w http.ResponseWriter, r *http.Request) {
+	todo, err := h.usecase.DetachTag(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "tagID"))
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondTodo(w, http.StatusOK, todo)
+}
+
+// DeleteTodo handles DELETE /api/todos/{id}, moving the todo to the trash
 func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := chi.URLParam(r, "id")
 
 	if err := h.usecase.Delete(ctx, id); err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		respondDomainError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// respondJSON sends a JSON response
-func (h *TodoHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// validateUpdateTodoRequest checks that the fields present in a patch are
+// not null or empty where the todo requires a value, reporting every invalid
+// field at once. The title is validated by the usecase.
+func validateUpdateTodoRequest(req UpdateTodoRequest) error {
+	var invalid domain.ValidationError
+	if req.IsCompleted.Set && req.IsCompleted.Null {
+		invalid.Add("is_completed", "is_completed cannot be null")
+	}
+	if req.Priority.Set && req.Priority.Null {
+		invalid.Add("priority", "priority cannot be null")
+	}
+	if req.ListID.Set && (req.ListID.Null || req.ListID.Value == "") {
+		invalid.Add("list_id", "list_id cannot be empty")
+	}
+	if req.ParentID.Set && !req.ParentID.Null && *req.ParentID.Value == "" {
+		invalid.Add("parent_id", "parent_id cannot be empty")
+	}
+	return invalid.Err()
+}
+
+// isPatchContentType reports whether ct is a media type accepted for PATCH bodies
+func isPatchContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "application/merge-patch+json"
+}
+
+// parseTodoQuery builds a domain.TodoQuery from the list query parameters
+func parseTodoQuery(query url.Values) (domain.TodoQuery, error) {
+	var q domain.TodoQuery
+
+	filter, err := parseTodoFilter(query)
+	if err != nil {
+		return q, err
+	}
+	q.Filter = filter
+
+	sort, err := parseTodoSort(query)
+	if err != nil {
+		return q, err
+	}
+	q.Sort = sort
+
+	page, err := parsePageRequest(query)
+	if err != nil {
+		return q, err
+	}
+	if page.Cursor != nil && page.Cursor.Sort != sort {
+		return q, domain.NewValidationError("cursor", "invalid cursor: it was issued for a different sort order")
+	}
+	q.Page = page
+
+	return q, nil
+}
+
+// parseTodoFilter builds a domain.TodoFilter from the list query parameters
+func parseTodoFilter(query url.Values) (domain.TodoFilter, error) {
+	var filter domain.TodoFilter
+
+	filter.ListID = query.Get("list_id")
+
+	if v := query.Get("status"); v != "" {
+		filter.Status = domain.TodoStatus(v)
+		if !filter.Status.IsValid() {
+			return filter, domain.NewValidationError("status", "invalid status: must be one of all, active, completed")
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+		{"due_after", &filter.DueAfter},
+		{"due_before", &filter.DueBefore},
+	} {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, domain.NewValidationError(p.name, fmt.Sprintf("invalid %s: must be an RFC 3339 timestamp", p.name))
+		}
+		*p.dst = &t
+	}
+
+	if v := query.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, domain.NewValidationError("overdue", "invalid overdue: must be a boolean")
+		}
+		filter.Overdue = overdue
+	}
+
+	filter.Tags = parseTagNames(query["tag"])
+	filter.TagMatch = domain.TagMatchAny
+	if v := query.Get("tag_match"); v != "" {
+		filter.TagMatch = domain.TagMatch(v)
+		if !filter.TagMatch.IsValid() {
+			return filter, domain.NewValidationError("tag_match", "invalid tag_match: must be any or all")
+		}
 	}
+
+	return filter, nil
 }
 
-// respondError sends an error response
-func (h *TodoHandler) respondError(w http.ResponseWriter, status int, message string) {
-	h.respondJSON(w, status, ErrorResponse{Error: message})
+// parseTagNames collects tag names from repeated and comma-separated tag
+// parameters, dropping blanks and case-insensitive duplicates
+func parseTagNames(values []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			name = domain.NormalizeText(name)
+			key := strings.ToLower(name)
+			if name == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseTodoSort reads the sort and order query parameters against the whitelist
+func parseTodoSort(query url.Values) (domain.TodoSort, error) {
+	sort := domain.DefaultSort
+
+	if v := query.Get("sort"); v != "" {
+		sort.Field = domain.SortField(v)
+		if !sort.Field.IsValid() {
+			return sort, domain.NewValidationError("sort", "invalid sort: must be one of created_at, updated_at, due_at, title, priority, position")
+		}
+		sort.Direction = sort.Field.DefaultDirection()
+	}
+
+	if v := query.Get("order"); v != "" {
+		sort.Direction = domain.SortDirection(v)
+		if !sort.Direction.IsValid() {
+			return sort, domain.NewValidationError("order", "invalid order: must be asc or desc")
+		}
+	}
+
+	return sort, nil
+}
+
+// parsePageRequest reads the cursor and limit query parameters
+func parsePageRequest(query url.Values) (domain.PageRequest, error) {
+	var page domain.PageRequest
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := domain.DecodeCursor(v)
+		if err != nil {
+			return page, domain.NewValidationError("cursor", "invalid cursor")
+		}
+		page.Cursor = &cursor
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > domain.MaxPageLimit {
+			return page, domain.NewValidationError("limit", fmt.Sprintf("invalid limit: must be between 1 and %d", domain.MaxPageLimit))
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// paginationLinks builds an RFC 8288 Link header value pointing at the
+// neighbouring pages, keeping every other query parameter of the request
+func paginationLinks(u *url.URL, page *domain.TodoPage) string {
+	var links []string
+	for _, l := range []struct {
+		cursor *domain.Cursor
+		rel    string
+	}{
+		{page.NextCursor, "next"},
+		{page.PrevCursor, "prev"},
+	} {
+		if l.cursor == nil {
+			continue
+		}
+		query := u.Query()
+		query.Set("cursor", l.cursor.Encode())
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), l.rel))
+	}
+	return strings.Join(links, ", ")
 }
@@ -0,0 +1,745 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/usecase"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// missingID is a well-formed ID that names nothing
+const missingID = "11111111-1111-4111-8111-111111111111"
+
+// milkDue is when the milk fixture is due
+var milkDue = time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+
+// The groceries are three todos of the inbox whose titles sort as apples,
+// bread, milk, the reverse of their positions
+var (
+	milk   = fixtureTodo(domain.Todo{ID: fixtureID("milk"), Title: "Buy milk", Priority: domain.PriorityHigh, DueAt: &milkDue, Position: "V"})
+	bread  = fixtureTodo(domain.Todo{ID: fixtureID("bread"), Title: "Buy bread", Priority: domain.PriorityLow, Position: "W"})
+	apples = fixtureTodo(domain.Todo{ID: fixtureID("apples"), Title: "Buy apples", Position: "X"})
+)
+
+// fixtureTodo fills in what todo fixtures have in common: they are live
+// todos at their first version, in the inbox unless they say otherwise
+func fixtureTodo(todo domain.Todo) domain.Todo {
+	if todo.ListID == "" {
+		todo.ListID = domain.DefaultListID
+	}
+	if todo.Tags == nil {
+		todo.Tags = []domain.Tag{}
+	}
+	todo.Version = 1
+	todo.CreatedAt, todo.UpdatedAt = fixtureTime, fixtureTime
+	return todo
+}
+
+// completed returns todo completed, at its next version
+func completed(todo domain.Todo) domain.Todo {
+	todo.IsCompleted = true
+	todo.Version++
+	return todo
+}
+
+// tagged returns todo carrying tags, at its next version
+func tagged(todo domain.Todo, tags ...domain.Tag) domain.Todo {
+	todo.Tags = append([]domain.Tag{}, tags...)
+	todo.Version++
+	return todo
+}
+
+// etag returns the entity tag of todo
+func etag(todo domain.Todo) string {
+	return todoETag(&todo)
+}
+
+// getTodo returns a Get that finds the given todos
+func getTodo(todos ...domain.Todo) func(ctx context.Context, id string) (*domain.Todo, error) {
+	return func(ctx context.Context, id string) (*domain.Todo, error) {
+		for _, todo := range todos {
+			if todo.ID == id {
+				return &todo, nil
+			}
+		}
+		return nil, domain.ErrTodoNotFound
+	}
+}
+
+// listTodos returns a List that expects query and finds todos
+func listTodos(want domain.TodoQuery, todos ...domain.Todo) func(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+	return func(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+		if !reflect.DeepEqual(query, want) {
+			return nil, unexpected("query %+v", query)
+		}
+		return &domain.TodoPage{Todos: todos}, nil
+	}
+}
+
+// createTodo is a Create that validates params as the usecase does and
+// creates the todo they describe, as the first of its list
+func createTodo(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	if err := params.Normalize(); err != nil {
+		return nil, err
+	}
+	todo := fixtureTodo(domain.Todo{
+		ID:         uuid.NewString(),
+		Title:      params.Title,
+		DueAt:      params.DueAt,
+		Priority:   params.Priority,
+		ListID:     params.ListID,
+		ParentID:   params.ParentID,
+		Position:   "V",
+		Recurrence: params.Recurrence,
+	})
+	return &todo, nil
+}
+
+// createOnce returns a Create that calls create the first time and reports
+// any further call as unexpected
+func createOnce(create func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error)) func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	called := false
+	return func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+		if called {
+			return nil, unexpected("second Create of %q", params.Title)
+		}
+		called = true
+		return create(ctx, params)
+	}
+}
+
+// updateTodo returns an Update that validates patches as the usecase does
+// and applies them to the given todos, failing when the todo is not at the
+// version the patch expects
+func updateTodo(todos ...domain.Todo) func(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+	get := getTodo(todos...)
+	return func(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+		if err := patch.Normalize(); err != nil {
+			return nil, err
+		}
+		todo, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if patch.IfVersion != 0 && patch.IfVersion != todo.Version {
+			return nil, domain.ErrVersionMismatch
+		}
+		params := patch.Apply(*todo)
+		todo.Title = params.Title
+		todo.IsCompleted = params.IsCompleted
+		todo.DueAt = params.DueAt
+		todo.Priority = params.Priority
+		todo.ListID = params.ListID
+		todo.ParentID = params.ParentID
+		todo.Recurrence = params.Recurrence
+		todo.Version++
+		return todo, nil
+	}
+}
+
+// deleteTodo returns a Delete that finds the given todos
+func deleteTodo(todos ...domain.Todo) func(ctx context.Context, id string) error {
+	get := getTodo(todos...)
+	return func(ctx context.Context, id string) error {
+		_, err := get(ctx, id)
+		return err
+	}
+}
+
+// filterCount returns a function taking a filter that expects want and counts n
+func filterCount(want domain.TodoFilter, n int) func(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	return func(ctx context.Context, filter domain.TodoFilter) (int, error) {
+		if !reflect.DeepEqual(filter, want) {
+			return 0, unexpected("filter %+v", filter)
+		}
+		return n, nil
+	}
+}
+
+// anyTags is the filter of a request without filter query parameters
+var anyTags = domain.TodoFilter{TagMatch: domain.TagMatchAny}
+
+func TestListTodos(t *testing.T) {
+	byTitle := domain.TodoSort{Field: domain.SortByTitle, Direction: domain.SortAsc}
+	firstPage := domain.TodoQuery{Filter: anyTags, Sort: byTitle, Page: domain.PageRequest{Limit: 2}}
+	next := domain.NewCursor(bread, byTitle, false)
+	runRouteTests(t, "list_todos", []routeTest{
+		{
+			name:   "empty",
+			method: "GET",
+			path:   "/api/todos",
+			todos:  fakeTodos{list: listTodos(domain.TodoQuery{Filter: anyTags, Sort: domain.DefaultSort})},
+		},
+		{
+			name:   "sorted_by_title",
+			method: "GET",
+			path:   "/api/todos?sort=title",
+			todos:  fakeTodos{list: listTodos(domain.TodoQuery{Filter: anyTags, Sort: byTitle}, apples, bread, milk)},
+		},
+		{
+			name:   "filtered",
+			method: "GET",
+			path:   "/api/todos?status=active&sort=title&order=desc",
+			todos: fakeTodos{list: listTodos(domain.TodoQuery{
+				Filter: domain.TodoFilter{Status: domain.StatusActive, TagMatch: domain.TagMatchAny},
+				Sort:   domain.TodoSort{Field: domain.SortByTitle, Direction: domain.SortDesc},
+			}, milk, apples)},
+		},
+		{
+			name:   "filtered_by_tag",
+			method: "GET",
+			path:   "/api/todos?tag=Dairy",
+			todos: fakeTodos{list: listTodos(domain.TodoQuery{
+				Filter: domain.TodoFilter{Tags: []string{"Dairy"}, TagMatch: domain.TagMatchAny},
+				Sort:   domain.DefaultSort,
+			}, tagged(milk, dairy))},
+		},
+		{
+			name:   "no_match",
+			method: "GET",
+			path:   "/api/todos?status=completed",
+			todos: fakeTodos{list: listTodos(domain.TodoQuery{
+				Filter: domain.TodoFilter{Status: domain.StatusCompleted, TagMatch: domain.TagMatchAny},
+				Sort:   domain.DefaultSort,
+			})},
+		},
+		{
+			name:   "first_page",
+			method: "GET",
+			path:   "/api/todos?sort=title&limit=2",
+			todos: fakeTodos{list: func(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+				if !reflect.DeepEqual(query, firstPage) {
+					return nil, unexpected("query %+v", query)
+				}
+				return &domain.TodoPage{Todos: []domain.Todo{apples, bread}, NextCursor: next}, nil
+			}},
+		},
+		{
+			name:   "second_page",
+			method: "GET",
+			path:   "/api/todos?sort=title&limit=2&cursor=" + next.Encode(),
+			todos: fakeTodos{list: func(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+				if query.Page.Cursor == nil || query.Page.Cursor.Encode() != next.Encode() {
+					return nil, unexpected("query %+v", query)
+				}
+				return &domain.TodoPage{Todos: []domain.Todo{milk}, PrevCursor: domain.NewCursor(milk, byTitle, true)}, nil
+			}},
+		},
+		{name: "invalid_status", method: "GET", path: "/api/todos?status=done"},
+		{name: "invalid_sort", method: "GET", path: "/api/todos?sort=color"},
+		{name: "invalid_order", method: "GET", path: "/api/todos?order=up"},
+		{name: "invalid_limit", method: "GET", path: "/api/todos?limit=0"},
+		{name: "invalid_cursor", method: "GET", path: "/api/todos?cursor=garbage"},
+		{name: "invalid_timestamp", method: "GET", path: "/api/todos?due_before=tomorrow"},
+		{name: "unavailable", method: "GET", path: "/api/todos", unavailable: true},
+	})
+}
+
+// queryRecorder is a TodoService that records the query ListTodos passes
+// on and finds nothing. Its other methods are not expected to be called.
+type queryRecorder struct {
+	usecase.TodoService
+	query *domain.TodoQuery
+}
+
+func (s *queryRecorder) List(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+	s.query = &query
+	return &domain.TodoPage{}, nil
+}
+
+func TestListTodos_Query(t *testing.T) {
+	svc := &queryRecorder{}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/todos?list_id=work&status=active&tag=home,Work&tag=WORK&tag_match=all&sort=due_at&order=desc&limit=5", nil)
+	NewTodoHandler(svc).ListTodos(rec, req)
+
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("response = %d %q, want 200 []", rec.Code, rec.Body.String())
+	}
+	want := domain.TodoQuery{
+		Filter: domain.TodoFilter{
+			ListID:   "work",
+			Status:   domain.StatusActive,
+			Tags:     []string{"home", "Work"},
+			TagMatch: domain.TagMatchAll,
+		},
+		Sort: domain.TodoSort{Field: domain.SortByDueAt, Direction: domain.SortDesc},
+		Page: domain.PageRequest{Limit: 5},
+	}
+	if svc.query == nil || !reflect.DeepEqual(*svc.query, want) {
+		t.Errorf("List() query = %+v, want %+v", svc.query, want)
+	}
+}
+
+// searchTodos returns a Search that expects want and finds todos
+func searchTodos(want domain.TodoSearch, todos ...domain.Todo) func(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	return func(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+		if search != want {
+			return nil, unexpected("search %+v", search)
+		}
+		return todos, nil
+	}
+}
+
+func TestSearchTodos(t *testing.T) {
+	runRouteTests(t, "search_todos", []routeTest{
+		{
+			name:   "match",
+			method: "GET",
+			path:   "/api/todos/search?q=milk",
+			todos:  fakeTodos{search: searchTodos(domain.TodoSearch{Query: "milk", Mode: domain.SearchModeSubstring}, milk)},
+		},
+		{
+			name:   "no_match",
+			method: "GET",
+			path:   "/api/todos/search?q=cheese",
+			todos:  fakeTodos{search: searchTodos(domain.TodoSearch{Query: "cheese", Mode: domain.SearchModeSubstring})},
+		},
+		{
+			name:   "prefix",
+			method: "GET",
+			path:   "/api/todos/search?q=appl&mode=prefix",
+			todos:  fakeTodos{search: searchTodos(domain.TodoSearch{Query: "appl", Mode: domain.SearchModePrefix})},
+		},
+		{name: "missing_query", method: "GET", path: "/api/todos/search"},
+		{name: "query_too_long", method: "GET", path: "/api/todos/search?q=" + strings.Repeat("a", domain.TitleLimit.Length+1)},
+		{name: "invalid_mode", method: "GET", path: "/api/todos/search?q=milk&mode=fuzzy"},
+		{name: "unavailable", method: "GET", path: "/api/todos/search?q=milk", unavailable: true},
+	})
+}
+
+// countByPriority returns a CountByPriority that expects want and counts
+// todos of the priorities in counts, and none of the others
+func countByPriority(want domain.TodoFilter, counts map[domain.Priority]int) func(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	return func(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+		if !reflect.DeepEqual(filter, want) {
+			return nil, unexpected("filter %+v", filter)
+		}
+		all := make(map[domain.Priority]int, len(domain.Priorities))
+		for _, p := range domain.Priorities {
+			all[p] = counts[p]
+		}
+		return all, nil
+	}
+}
+
+func TestPrioritySummary(t *testing.T) {
+	runRouteTests(t, "priority_summary", []routeTest{
+		{
+			name:   "empty",
+			method: "GET",
+			path:   "/api/todos/summary/priority",
+			todos:  fakeTodos{countByPriority: countByPriority(anyTags, nil)},
+		},
+		{
+			name:   "counts",
+			method: "GET",
+			path:   "/api/todos/summary/priority",
+			todos: fakeTodos{countByPriority: countByPriority(anyTags, map[domain.Priority]int{
+				domain.PriorityNone: 1,
+				domain.PriorityLow:  1,
+				domain.PriorityHigh: 1,
+			})},
+		},
+		{name: "invalid_filter", method: "GET", path: "/api/todos/summary/priority?overdue=maybe"},
+		{name: "unavailable", method: "GET", path: "/api/todos/summary/priority", unavailable: true},
+	})
+}
+
+func TestCreateTodo(t *testing.T) {
+	runRouteTests(t, "create_todo", []routeTest{
+		{
+			name:   "created",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"  Buy milk  ","priority":"high","due_at":"2030-01-02T09:00:00+09:00"}`,
+			todos:  fakeTodos{create: createTodo},
+		},
+		{
+			name:   "recurring",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"Water plants","due_at":"2030-01-02T09:00:00Z","recurrence":{"rule":"FREQ=WEEKLY;BYDAY=MO,TH","time_zone":"Asia/Tokyo"}}`,
+			todos:  fakeTodos{create: createTodo},
+		},
+		{
+			name:   "under_parent",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"Draft outline","parent_id":"{milk}"}`,
+			todos:  fakeTodos{create: createTodo},
+		},
+		{
+			name:   "parent_in_other_list",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"Draft outline","list_id":"{errands}","parent_id":"{milk}"}`,
+			todos: fakeTodos{create: func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+				return nil, domain.ErrSubtaskList
+			}},
+		},
+		{
+			name:   "blank_title",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"   "}`,
+			todos:  fakeTodos{create: createTodo},
+		},
+		{name: "invalid_priority", method: "POST", path: "/api/todos", body: `{"title":"Buy milk","priority":"huge"}`},
+		{name: "invalid_recurrence", method: "POST", path: "/api/todos", body: `{"title":"Buy milk","recurrence":{"rule":"FREQ=HOURLY","time_zone":"UTC"}}`},
+		{
+			name:   "unknown_list",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"Buy milk","list_id":"` + missingID + `"}`,
+			todos: fakeTodos{create: func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+				return nil, domain.ErrInvalidListID
+			}},
+		},
+		{
+			name:   "unknown_parent",
+			method: "POST",
+			path:   "/api/todos",
+			body:   `{"title":"Buy milk","parent_id":"` + missingID + `"}`,
+			todos: fakeTodos{create: func(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+				return nil, domain.ErrParentNotFound
+			}},
+		},
+		{name: "unavailable", method: "POST", path: "/api/todos", body: `{"title":"Buy milk"}`, unavailable: true},
+	})
+}
+
+func TestGetTodo(t *testing.T) {
+	// Completing the subtask of the party, or renaming a tag, leaves the
+	// version of the todo as it was
+	partyDone := party
+	partyDone.Subtasks.Completed = 1
+	dairyRenamed := dairy
+	dairyRenamed.Name = "milk products"
+	runRouteTests(t, "get_todo", []routeTest{
+		{name: "found", method: "GET", path: "/api/todos/{milk}", todos: fakeTodos{get: getTodo(milk)}},
+		{name: "not_found", method: "GET", path: "/api/todos/" + missingID, todos: fakeTodos{get: getTodo(milk)}},
+		{name: "malformed_id", method: "GET", path: "/api/todos/not-an-id", todos: fakeTodos{get: getTodo(milk)}},
+		{
+			name:   "not_modified",
+			method: "GET",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-None-Match": "W/" + etag(milk)},
+			todos:  fakeTodos{get: getTodo(milk)},
+		},
+		{
+			name:   "modified",
+			method: "GET",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-None-Match": etag(milk)},
+			todos:  fakeTodos{get: getTodo(completed(milk))},
+		},
+		{
+			name:   "subtasks_changed",
+			method: "GET",
+			path:   "/api/todos/{party}",
+			header: map[string]string{"If-None-Match": etag(party)},
+			todos:  fakeTodos{get: getTodo(partyDone)},
+		},
+		{
+			name:   "tag_renamed",
+			method: "GET",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-None-Match": etag(tagged(milk, dairy))},
+			todos:  fakeTodos{get: getTodo(tagged(milk, dairyRenamed))},
+		},
+		{name: "unavailable", method: "GET", path: "/api/todos/{milk}", unavailable: true},
+	})
+}
+
+// The party is a todo with a subtask that has a completed subtask of its own
+var (
+	party   = fixtureTodo(domain.Todo{ID: fixtureID("party"), Title: "Plan party", Position: "V", Subtasks: domain.SubtaskProgress{Total: 1}})
+	cake    = fixtureTodo(domain.Todo{ID: fixtureID("cake"), Title: "Order cake", ParentID: &party.ID, Position: "W", Subtasks: domain.SubtaskProgress{Completed: 1, Total: 1}})
+	flavour = completed(fixtureTodo(domain.Todo{ID: fixtureID("flavour"), Title: "Pick flavour", ParentID: &cake.ID, Position: "X"}))
+)
+
+// subtree returns todo with children nested below it
+func subtree(todo domain.Todo, children ...*domain.TodoTree) *domain.TodoTree {
+	if children == nil {
+		children = []*domain.TodoTree{}
+	}
+	return &domain.TodoTree{Todo: todo, Children: children}
+}
+
+// getSubtree returns a GetSubtree that finds the given trees
+func getSubtree(trees ...*domain.TodoTree) func(ctx context.Context, id string) (*domain.TodoTree, error) {
+	return func(ctx context.Context, id string) (*domain.TodoTree, error) {
+		for _, tree := range trees {
+			if tree.ID == id {
+				return tree, nil
+			}
+		}
+		return nil, domain.ErrTodoNotFound
+	}
+}
+
+func TestGetTodoSubtree(t *testing.T) {
+	runRouteTests(t, "get_todo_subtree", []routeTest{
+		{
+			name:   "found",
+			method: "GET",
+			path:   "/api/todos/{party}/subtree",
+			todos:  fakeTodos{getSubtree: getSubtree(subtree(party, subtree(cake, subtree(flavour))))},
+		},
+		{name: "leaf", method: "GET", path: "/api/todos/{milk}/subtree", todos: fakeTodos{getSubtree: getSubtree(subtree(milk))}},
+		{name: "not_found", method: "GET", path: "/api/todos/" + missingID + "/subtree", todos: fakeTodos{getSubtree: getSubtree()}},
+	})
+}
+
+func TestUpdateTodo(t *testing.T) {
+	runRouteTests(t, "update_todo", []routeTest{
+		{
+			name:   "updated",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"Content-Type": "application/merge-patch+json"},
+			body:   `{"title":"Buy oat milk","is_completed":true,"due_at":null}`,
+			todos:  fakeTodos{update: updateTodo(milk)},
+		},
+		{
+			name:   "moved_to_list",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			body:   `{"list_id":"{errands}","priority":"urgent"}`,
+			todos:  fakeTodos{update: updateTodo(milk)},
+		},
+		{
+			name:   "unsupported_media_type",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"Content-Type": "text/plain"},
+			body:   `{"title":"Buy oat milk"}`,
+		},
+		{
+			name:   "null_fields",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			body:   `{"is_completed":null,"priority":null,"list_id":"","parent_id":""}`,
+		},
+		{
+			name:   "blank_title",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			body:   `{"title":""}`,
+			todos:  fakeTodos{update: updateTodo(milk)},
+		},
+		{
+			name:   "own_parent",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			body:   `{"parent_id":"{milk}"}`,
+			todos: fakeTodos{update: func(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+				return nil, domain.ErrParentCycle
+			}},
+		},
+		{
+			name:   "if_match",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-Match": `"0", ` + etag(milk)},
+			body:   `{"title":"Buy oat milk"}`,
+			todos:  fakeTodos{get: getTodo(milk), update: updateTodo(milk)},
+		},
+		{
+			name:   "if_match_stale",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-Match": etag(milk)},
+			body:   `{"title":"Buy oat milk"}`,
+			todos:  fakeTodos{get: getTodo(completed(milk))},
+		},
+		{
+			name:   "if_match_weak",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-Match": "W/" + etag(milk)},
+			body:   `{"title":"Buy oat milk"}`,
+			todos:  fakeTodos{get: getTodo(milk)},
+		},
+		{
+			name:   "if_match_raced",
+			method: "PATCH",
+			path:   "/api/todos/{milk}",
+			header: map[string]string{"If-Match": etag(milk)},
+			body:   `{"title":"Buy oat milk"}`,
+			// Another write lands between the check and the update
+			todos: fakeTodos{get: getTodo(milk), update: updateTodo(completed(milk))},
+		},
+		{
+			name:   "not_found",
+			method: "PATCH",
+			path:   "/api/todos/" + missingID,
+			body:   `{"title":"Buy oat milk"}`,
+			todos:  fakeTodos{update: updateTodo(milk)},
+		},
+		{
+			name:   "if_match_not_found",
+			method: "PATCH",
+			path:   "/api/todos/" + missingID,
+			header: map[string]string{"If-Match": etag(milk)},
+			body:   `{"title":"Buy oat milk"}`,
+			todos:  fakeTodos{get: getTodo(milk)},
+		},
+		{name: "unavailable", method: "PATCH", path: "/api/todos/{milk}", body: `{"title":"Buy oat milk"}`, unavailable: true},
+	})
+}
+
+func TestUpdateTodoDueAt(t *testing.T) {
+	runRouteTests(t, "update_todo_due_at", []routeTest{
+		{
+			name:   "set",
+			method: "PUT",
+			path:   "/api/todos/{bread}/due_at",
+			body:   `{"due_at":"2030-02-03T04:05:06Z"}`,
+			todos:  fakeTodos{update: updateTodo(bread)},
+		},
+		{
+			name:   "cleared",
+			method: "PUT",
+			path:   "/api/todos/{milk}/due_at",
+			body:   `{"due_at":null}`,
+			todos:  fakeTodos{update: updateTodo(milk)},
+		},
+		{name: "invalid", method: "PUT", path: "/api/todos/{milk}/due_at", body: `{"due_at":"tomorrow"}`},
+		{
+			name:   "if_match_stale",
+			method: "PUT",
+			path:   "/api/todos/{milk}/due_at",
+			header: map[string]string{"If-Match": etag(completed(milk))},
+			body:   `{"due_at":null}`,
+			todos:  fakeTodos{get: getTodo(milk)},
+		},
+		{
+			name:   "not_found",
+			method: "PUT",
+			path:   "/api/todos/" + missingID + "/due_at",
+			body:   `{"due_at":null}`,
+			todos:  fakeTodos{update: updateTodo(milk)},
+		},
+	})
+}
+
+// moveTodo returns a Move that expects todo to be moved as want and places
+// it at position
+func moveTodo(todo domain.Todo, want domain.TodoMove, position string) func(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+	return func(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+		if id != todo.ID {
+			return nil, domain.ErrTodoNotFound
+		}
+		if move != want {
+			return nil, unexpected("move %+v", move)
+		}
+		todo.Position = position
+		todo.Version++
+		return &todo, nil
+	}
+}
+
+func TestMoveTodo(t *testing.T) {
+	runRouteTests(t, "move_todo", []routeTest{
+		{
+			name:   "before",
+			method: "POST",
+			path:   "/api/todos/{apples}/move",
+			body:   `{"before":"{milk}"}`,
+			todos:  fakeTodos{move: moveTodo(apples, domain.TodoMove{Before: milk.ID}, "G")},
+		},
+		{
+			name:   "after",
+			method: "POST",
+			path:   "/api/todos/{milk}/move",
+			body:   `{"after":"{apples}"}`,
+			todos:  fakeTodos{move: moveTodo(milk, domain.TodoMove{After: apples.ID}, "Y")},
+		},
+		{name: "both_neighbours", method: "POST", path: "/api/todos/{milk}/move", body: `{"before":"{bread}","after":"{apples}"}`},
+		{name: "no_neighbour", method: "POST", path: "/api/todos/{milk}/move", body: `{}`},
+		{
+			name:   "unknown_neighbour",
+			method: "POST",
+			path:   "/api/todos/{milk}/move",
+			body:   `{"before":"` + missingID + `"}`,
+			todos: fakeTodos{move: func(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+				return nil, domain.ErrMoveTargetNotFound
+			}},
+		},
+		{
+			name:   "not_found",
+			method: "POST",
+			path:   "/api/todos/" + missingID + "/move",
+			body:   `{"before":"{milk}"}`,
+			todos:  fakeTodos{move: moveTodo(apples, domain.TodoMove{Before: milk.ID}, "G")},
+		},
+	})
+}
+
+// changeTag returns an AttachTag or DetachTag that expects todo and tag and
+// returns changed
+func changeTag(todo domain.Todo, tag domain.Tag, changed domain.Todo) func(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	return func(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+		switch {
+		case todoID != todo.ID:
+			return nil, domain.ErrTodoNotFound
+		case tagID != tag.ID:
+			return nil, domain.ErrTagNotFound
+		}
+		return &changed, nil
+	}
+}
+
+func TestTodoTags(t *testing.T) {
+	milkWithDairy := tagged(milk, dairy)
+	runRouteTests(t, "todo_tags", []routeTest{
+		{
+			name:   "attached",
+			method: "PUT",
+			path:   "/api/todos/{milk}/tags/{dairy}",
+			todos:  fakeTodos{attachTag: changeTag(milk, dairy, milkWithDairy)},
+		},
+		{
+			name:   "unknown_tag",
+			method: "PUT",
+			path:   "/api/todos/{milk}/tags/" + missingID,
+			todos:  fakeTodos{attachTag: changeTag(milk, dairy, milkWithDairy)},
+		},
+		{
+			name:   "unknown_todo",
+			method: "PUT",
+			path:   "/api/todos/" + missingID + "/tags/{dairy}",
+			todos:  fakeTodos{attachTag: changeTag(milk, dairy, milkWithDairy)},
+		},
+		{
+			name:   "detached",
+			method: "DELETE",
+			path:   "/api/todos/{milk}/tags/{dairy}",
+			todos:  fakeTodos{detachTag: changeTag(milkWithDairy, dairy, tagged(milkWithDairy))},
+		},
+		{
+			name:   "detach_unknown_todo",
+			method: "DELETE",
+			path:   "/api/todos/" + missingID + "/tags/{dairy}",
+			todos:  fakeTodos{detachTag: changeTag(milkWithDairy, dairy, tagged(milkWithDairy))},
+		},
+	})
+}
+
+func TestDeleteTodo(t *testing.T) {
+	runRouteTests(t, "delete_todo", []routeTest{
+		{name: "deleted", method: "DELETE", path: "/api/todos/{milk}", todos: fakeTodos{delete: deleteTodo(milk)}},
+		{name: "not_found", method: "DELETE", path: "/api/todos/" + missingID, todos: fakeTodos{delete: deleteTodo(milk)}},
+		{name: "unavailable", method: "DELETE", path: "/api/todos/{milk}", unavailable: true},
+	})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EmptyTrashResponse reports how many todos emptying the trash deleted
+type EmptyTrashResponse struct {
+	Purged int `json:"purged"`
+}
+
+// ListTrash handles GET /api/trash
+func (h *TodoHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	todos, err := h.usecase.ListTrash(r.Context())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	if todos == nil {
+		todos = []domain.Todo{}
+	}
+
+	respondJSON(w, http.StatusOK, todos)
+}
+
+// RestoreTodo handles POST /api/todos/{id}/restore
+func (h *TodoHandler) RestoreTodo(w http.ResponseWriter, r *http.Request) {
+	todo, err := h.usecase.Restore(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondTodo(w, http.StatusOK, todo)
+}
+
+// PurgeTodo handles DELETE /api/trash/{id}
+func (h *TodoHandler) PurgeTodo(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.Purge(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash handles DELETE /api/trash
+func (h *TodoHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	n, err := h.usecase.EmptyTrash(r.Context())
+	if err != nil {
+		respondDomainError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, EmptyTrashResponse{Purged: n})
+}
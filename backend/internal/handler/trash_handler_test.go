@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"context"
+	"testing"
+)
+
+// trashedMilk is milk moved to the trash
+var trashedMilk = func() domain.Todo {
+	todo := milk
+	todo.DeletedAt = &fixtureTime
+	todo.Version++
+	return todo
+}()
+
+// listTrash returns a ListTrash that finds todos
+func listTrash(todos ...domain.Todo) func(ctx context.Context) ([]domain.Todo, error) {
+	return func(ctx context.Context) ([]domain.Todo, error) {
+		return append([]domain.Todo{}, todos...), nil
+	}
+}
+
+// restoreTodo returns a Restore that takes the given trashed todos out of the trash
+func restoreTodo(todos ...domain.Todo) func(ctx context.Context, id string) (*domain.Todo, error) {
+	get := getTodo(todos...)
+	return func(ctx context.Context, id string) (*domain.Todo, error) {
+		todo, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		todo.DeletedAt = nil
+		todo.Version++
+		return todo, nil
+	}
+}
+
+// countTrash returns an EmptyTrash that purges n todos
+func countTrash(n int) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		return n, nil
+	}
+}
+
+func TestListTrash(t *testing.T) {
+	runRouteTests(t, "list_trash", []routeTest{
+		{name: "empty", method: "GET", path: "/api/trash", todos: fakeTodos{listTrash: listTrash()}},
+		{name: "trashed", method: "GET", path: "/api/trash", todos: fakeTodos{listTrash: listTrash(trashedMilk)}},
+		{name: "unavailable", method: "GET", path: "/api/trash", unavailable: true},
+	})
+}
+
+func TestRestoreTodo(t *testing.T) {
+	runRouteTests(t, "restore_todo", []routeTest{
+		{name: "restored", method: "POST", path: "/api/todos/{milk}/restore", todos: fakeTodos{restore: restoreTodo(trashedMilk)}},
+		{name: "not_found", method: "POST", path: "/api/todos/" + missingID + "/restore", todos: fakeTodos{restore: restoreTodo(trashedMilk)}},
+	})
+}
+
+func TestPurgeTodo(t *testing.T) {
+	runRouteTests(t, "purge_todo", []routeTest{
+		{name: "purged", method: "DELETE", path: "/api/trash/{milk}", todos: fakeTodos{purge: deleteTodo(trashedMilk)}},
+		{name: "not_found", method: "DELETE", path: "/api/trash/" + missingID, todos: fakeTodos{purge: deleteTodo(trashedMilk)}},
+	})
+}
+
+func TestEmptyTrash(t *testing.T) {
+	runRouteTests(t, "empty_trash", []routeTest{
+		{name: "emptied", method: "DELETE", path: "/api/trash", todos: fakeTodos{emptyTrash: countTrash(1)}},
+		{name: "already_empty", method: "DELETE", path: "/api/trash", todos: fakeTodos{emptyTrash: countTrash(0)}},
+		{name: "unavailable", method: "DELETE", path: "/api/trash", unavailable: true},
+	})
+}
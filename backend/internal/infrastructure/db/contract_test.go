@@ -0,0 +1,58 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"backend/internal/infrastructure/repotest"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// openTestDB returns the MySQL database at TEST_MYSQL_DSN emptied of
+// everything but the default list, and skips the test without one. The DSN
+// has to name a migrated database the tests may wipe, and set parseTime=true
+// and loc=UTC as the server does.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	database, err := ConnectDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, statement := range []string{
+		"DELETE FROM todo_tags",
+		"DELETE FROM todos",
+		"DELETE FROM tags",
+		"DELETE FROM idempotency_keys",
+		"DELETE FROM lists WHERE id <> '" + domain.DefaultListID + "'",
+	} {
+		if _, err := database.ExecContext(context.Background(), statement); err != nil {
+			t.Fatalf("failed to empty the database: %v", err)
+		}
+	}
+	return database
+}
+
+func TestTodoRepositoryAdapter_Contract(t *testing.T) {
+	repotest.TestTodoRepository(t, func(t *testing.T) domain.Repositories {
+		database := openTestDB(t)
+		return domain.Repositories{
+			Todos:      NewTodoRepositoryAdapter(NewTodoRepository(database)),
+			Tags:       NewTagRepositoryAdapter(NewTagRepository(database)),
+			Lists:      NewListRepositoryAdapter(NewListRepository(database)),
+			UnitOfWork: NewUnitOfWork(database),
+		}
+	})
+}
+
+func TestIdempotencyRepositoryAdapter_Contract(t *testing.T) {
+	repotest.TestIdempotencyRepository(t, func(t *testing.T) domain.IdempotencyRepository {
+		return NewIdempotencyRepositoryAdapter(NewIdempotencyRepository(openTestDB(t)))
+	})
+}
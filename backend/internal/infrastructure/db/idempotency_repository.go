@@ -0,0 +1,84 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	queries *Queries
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{queries: New(db)}
+}
+
+// Claim inserts an in-flight row for params.IdempotencyKey. If the key is
+// taken it returns the row holding it and false; a row that expired by now
+// is deleted and the insert tried again. The primary key makes concurrent
+// claims of one key safe: exactly one insert succeeds.
+func (r *IdempotencyRepository) Claim(ctx context.Context, params ClaimIdempotencyKeyParams, now time.Time) (*IdempotencyKey, bool, error) {
+	// A second attempt follows a deleted or vanished row; if the key is taken
+	// again by then, a concurrent request claimed it first
+	for attempt := 0; attempt < 2; attempt++ {
+		err := r.queries.ClaimIdempotencyKey(ctx, params)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !isMySQLError(err, mysqlErrDuplicateEntry) {
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		existing, err := r.queries.GetIdempotencyKey(ctx, params.IdempotencyKey)
+		if err == sql.ErrNoRows {
+			// Released between the insert and the read
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		if existing.ExpiresAt.After(now) {
+			return &existing, false, nil
+		}
+		err = r.queries.DeleteExpiredIdempotencyKey(ctx, DeleteExpiredIdempotencyKeyParams{IdempotencyKey: params.IdempotencyKey, ExpiresAt: now})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+		}
+	}
+	return nil, false, domain.ErrIdempotencyKeyInFlight
+}
+
+// Complete stores the response of an in-flight claim. It fails if the claim
+// was released or has expired and been taken over in the meantime.
+func (r *IdempotencyRepository) Complete(ctx context.Context, params CompleteIdempotencyKeyParams) error {
+	result, err := r.queries.CompleteIdempotencyKey(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return requireAffected(result, fmt.Errorf("idempotency key %q is no longer claimed", params.IdempotencyKey))
+}
+
+// Release deletes a claim whose request has not completed, unless another
+// claim has taken its place
+func (r *IdempotencyRepository) Release(ctx context.Context, params ReleaseIdempotencyKeyParams) error {
+	if err := r.queries.ReleaseIdempotencyKey(ctx, params); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes every row that expired by now and returns how many were deleted
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.queries.PurgeIdempotencyKeys(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(n), nil
+}
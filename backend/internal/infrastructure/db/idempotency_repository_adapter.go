@@ -0,0 +1,85 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IdempotencyRepositoryAdapter adapts the sqlc-based IdempotencyRepository to the domain.IdempotencyRepository interface
+type IdempotencyRepositoryAdapter struct {
+	repo *IdempotencyRepository
+}
+
+// NewIdempotencyRepositoryAdapter creates a new adapter
+func NewIdempotencyRepositoryAdapter(repo *IdempotencyRepository) *IdempotencyRepositoryAdapter {
+	return &IdempotencyRepositoryAdapter{repo: repo}
+}
+
+// Claim stores an in-flight record unless the key is still held
+func (a *IdempotencyRepositoryAdapter) Claim(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, bool, error) {
+	existing, claimed, err := a.repo.Claim(ctx, ClaimIdempotencyKeyParams{
+		IdempotencyKey: record.Key,
+		RequestHash:    record.RequestHash,
+		ClaimToken:     record.Token,
+		ExpiresAt:      record.ExpiresAt.UTC(),
+	}, now.UTC())
+	if err != nil || claimed {
+		return nil, claimed, err
+	}
+	held, err := toDomainIdempotencyRecord(existing)
+	if err != nil {
+		return nil, false, err
+	}
+	return held, false, nil
+}
+
+// Complete stores the response to a claimed key
+func (a *IdempotencyRepositoryAdapter) Complete(ctx context.Context, key string, token string, response domain.StoredResponse, expiresAt time.Time) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode response header: %w", err)
+	}
+	return a.repo.Complete(ctx, CompleteIdempotencyKeyParams{
+		StatusCode:     sql.NullInt16{Int16: int16(response.Status), Valid: true},
+		ResponseHeader: header,
+		ResponseBody:   sql.NullString{String: string(response.Body), Valid: true},
+		ExpiresAt:      expiresAt.UTC(),
+		IdempotencyKey: key,
+		ClaimToken:     token,
+	})
+}
+
+// Release drops an in-flight claim
+func (a *IdempotencyRepositoryAdapter) Release(ctx context.Context, key string, token string) error {
+	return a.repo.Release(ctx, ReleaseIdempotencyKeyParams{IdempotencyKey: key, ClaimToken: token})
+}
+
+// PurgeExpired deletes expired records
+func (a *IdempotencyRepositoryAdapter) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	return a.repo.PurgeExpired(ctx, now.UTC())
+}
+
+// toDomainIdempotencyRecord converts a db.IdempotencyKey to domain.IdempotencyRecord
+func toDomainIdempotencyRecord(k *IdempotencyKey) (*domain.IdempotencyRecord, error) {
+	record := &domain.IdempotencyRecord{
+		Key:         k.IdempotencyKey,
+		RequestHash: k.RequestHash,
+		Token:       k.ClaimToken,
+		ExpiresAt:   k.ExpiresAt,
+	}
+	if !k.StatusCode.Valid {
+		return record, nil
+	}
+	response := &domain.StoredResponse{Status: int(k.StatusCode.Int16), Body: []byte(k.ResponseBody.String)}
+	if len(k.ResponseHeader) > 0 {
+		if err := json.Unmarshal(k.ResponseHeader, &response.Header); err != nil {
+			return nil, fmt.Errorf("failed to decode response header: %w", err)
+		}
+	}
+	record.Response = response
+	return record, nil
+}
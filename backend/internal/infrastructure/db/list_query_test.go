@@ -0,0 +1,117 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewListQuery_Filters(t *testing.T) {
+	since := time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	q := NewListQuery(domain.TodoQuery{
+		Filter: domain.TodoFilter{Status: domain.StatusCompleted, UpdatedAfter: &since},
+		Sort:   domain.TodoSort{Field: domain.SortByUpdatedAt, Direction: domain.SortAsc},
+		Page:   domain.PageRequest{Limit: 10},
+	}, time.Now())
+
+	want := "SELECT " + todoColumns + `
+FROM todos
+WHERE deleted_at IS NULL
+  AND is_completed = TRUE
+  AND updated_at >= ?
+ORDER BY updated_at ASC, id ASC
+LIMIT ?`
+	if q.sql != want {
+		t.Errorf("unexpected SQL:\n%s\nwant:\n%s", q.sql, want)
+	}
+	if len(q.args) != 2 || !q.args[0].(time.Time).Equal(since) || q.args[0].(time.Time).Location() != time.UTC || q.args[1] != 10 {
+		t.Errorf("unexpected args: %v", q.args)
+	}
+}
+
+func TestNewListQuery_Cursor(t *testing.T) {
+	title := "x' OR 1=1 --"
+	cursor := domain.NewCursor(domain.Todo{ID: "id-1", Title: title}, domain.TodoSort{Field: domain.SortByTitle, Direction: domain.SortAsc}, false)
+	q := NewListQuery(domain.TodoQuery{
+		Sort: cursor.Sort,
+		Page: domain.PageRequest{Cursor: cursor, Limit: 5},
+	}, time.Now())
+
+	if strings.Contains(q.sql, title) {
+		t.Fatalf("cursor value leaked into SQL: %s", q.sql)
+	}
+	if !strings.Contains(q.sql, "AND ((LOWER(title) COLLATE utf8mb4_bin > ?) OR (LOWER(title) COLLATE utf8mb4_bin = ? AND id > ?))") {
+		t.Errorf("unexpected keyset predicate:\n%s", q.sql)
+	}
+	lowered := strings.ToLower(title)
+	if len(q.args) != 4 || q.args[0] != lowered || q.args[1] != lowered || q.args[2] != "id-1" {
+		t.Errorf("unexpected args: %v", q.args)
+	}
+}
+
+func TestNewListQuery_BackwardReversesOrder(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := domain.NewCursor(domain.Todo{ID: "id-1", CreatedAt: created}, domain.DefaultSort, true)
+	q := NewListQuery(domain.TodoQuery{
+		Sort: domain.DefaultSort,
+		Page: domain.PageRequest{Cursor: cursor, Limit: 5},
+	}, time.Now())
+
+	if !q.backward {
+		t.Error("expected a backward query")
+	}
+	if !strings.Contains(q.sql, "((created_at > ?) OR (created_at = ? AND id > ?))") {
+		t.Errorf("unexpected keyset predicate:\n%s", q.sql)
+	}
+	if !strings.Contains(q.sql, "ORDER BY created_at ASC, id ASC") {
+		t.Errorf("unexpected order:\n%s", q.sql)
+	}
+}
+
+func TestNewListQuery_PriorityOrder(t *testing.T) {
+	q := NewListQuery(domain.TodoQuery{
+		Sort: domain.TodoSort{Field: domain.SortByPriority, Direction: domain.SortDesc},
+	}, time.Now())
+
+	want := "ORDER BY priority DESC, (due_at IS NULL) ASC, COALESCE(due_at, " + nullDueAtKey + ") ASC, id DESC"
+	if !strings.Contains(q.sql, want) {
+		t.Errorf("unexpected order:\n%s\nwant:\n%s", q.sql, want)
+	}
+}
+
+func TestNewListQuery_PositionOrder(t *testing.T) {
+	order := domain.TodoSort{Field: domain.SortByPosition, Direction: domain.SortAsc}
+	cursor := domain.NewCursor(domain.Todo{ID: "id-1", Position: "V"}, order, false)
+	q := NewListQuery(domain.TodoQuery{
+		Filter: domain.TodoFilter{ListID: "list-1"},
+		Sort:   order,
+		Page:   domain.PageRequest{Cursor: cursor, Limit: 2},
+	}, time.Now())
+
+	want := "SELECT " + todoColumns + `
+FROM todos
+WHERE deleted_at IS NULL
+  AND list_id = ?
+  AND ((position > ?) OR (position = ? AND id > ?))
+ORDER BY position ASC, id ASC
+LIMIT ?`
+	if q.sql != want {
+		t.Errorf("unexpected SQL:\n%s\nwant:\n%s", q.sql, want)
+	}
+	if len(q.args) != 5 || q.args[0] != "list-1" || q.args[1] != "V" || q.args[3] != "id-1" {
+		t.Errorf("unexpected args: %v", q.args)
+	}
+}
+
+func TestNewCountByPriorityQuery(t *testing.T) {
+	query, args := NewCountByPriorityQuery(domain.TodoFilter{Status: domain.StatusActive}, time.Now())
+
+	want := "SELECT priority, COUNT(*)\nFROM todos\nWHERE deleted_at IS NULL\n  AND is_completed = FALSE\nGROUP BY priority"
+	if query != want {
+		t.Errorf("unexpected SQL:\n%s\nwant:\n%s", query, want)
+	}
+	if len(args) != 0 {
+		t.Errorf("expected no args, got %v", args)
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type ListRepository struct {
+	queries *Queries
+}
+
+func NewListRepository(db *sql.DB) *ListRepository {
+	return &ListRepository{queries: New(db)}
+}
+
+// List returns every list with its todo counts, oldest first
+func (r *ListRepository) List(ctx context.Context) ([]ListListsRow, error) {
+	lists, err := r.queries.ListLists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+	return lists, nil
+}
+
+// GetByID returns a list with its todo counts, or domain.ErrListNotFound if it does not exist
+func (r *ListRepository) GetByID(ctx context.Context, id string) (*GetListRow, error) {
+	list, err := r.queries.GetList(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrListNotFound
+		}
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+	return &list, nil
+}
+
+func (r *ListRepository) Create(ctx context.Context, name string) (*GetListRow, error) {
+	id := uuid.New().String()
+	_, err := r.queries.CreateList(ctx, CreateListParams{ID: id, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Rename changes a list's name. It returns domain.ErrListNotFound if the list does not exist.
+func (r *ListRepository) Rename(ctx context.Context, id string, name string) (*GetListRow, error) {
+	// As with tags, an unchanged name affects no rows, so check existence first
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	if _, err := r.queries.RenameList(ctx, RenameListParams{ID: id, Name: name}); err != nil {
+		return nil, fmt.Errorf("failed to rename list: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a list that holds no todos, trashed ones included. It
+// returns domain.ErrListNotFound if the list does not exist and
+// domain.ErrListNotEmpty if it still has todos.
+func (r *ListRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteList(ctx, DeleteListParams{ID: id})
+	if err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	if err := requireAffected(result, domain.ErrListNotFound); err != nil {
+		// Nothing was deleted: either the list is missing or it has todos
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return getErr
+		}
+		return domain.ErrListNotEmpty
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+)
+
+// ListRepositoryAdapter adapts the sqlc-based ListRepository to the domain.ListRepository interface
+type ListRepositoryAdapter struct {
+	repo *ListRepository
+}
+
+// NewListRepositoryAdapter creates a new adapter
+func NewListRepositoryAdapter(repo *ListRepository) *ListRepositoryAdapter {
+	return &ListRepositoryAdapter{repo: repo}
+}
+
+// List returns all lists, oldest first
+func (a *ListRepositoryAdapter) List(ctx context.Context) ([]domain.List, error) {
+	rows, err := a.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lists := make([]domain.List, len(rows))
+	for i, row := range rows {
+		lists[i] = *toDomainList(GetListRow(row))
+	}
+	return lists, nil
+}
+
+// GetByID returns a list by ID
+func (a *ListRepositoryAdapter) GetByID(ctx context.Context, id string) (*domain.List, error) {
+	row, err := a.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainList(*row), nil
+}
+
+// Create creates a new, empty list
+func (a *ListRepositoryAdapter) Create(ctx context.Context, name string) (*domain.List, error) {
+	row, err := a.repo.Create(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainList(*row), nil
+}
+
+// Rename renames a list
+func (a *ListRepositoryAdapter) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	row, err := a.repo.Rename(ctx, id, name)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainList(*row), nil
+}
+
+// Delete deletes a list that holds no todos
+func (a *ListRepositoryAdapter) Delete(ctx context.Context, id string) error {
+	return a.repo.Delete(ctx, id)
+}
+
+// toDomainList converts a list row with counts to domain.List
+func toDomainList(row GetListRow) *domain.List {
+	return &domain.List{
+		ID:             row.ID,
+		Name:           row.Name,
+		IsDefault:      row.ID == domain.DefaultListID,
+		TodoCount:      int(row.TodoCount),
+		CompletedCount: int(row.CompletedCount),
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
@@ -5,13 +5,54 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+type IdempotencyKey struct {
+	IdempotencyKey string          `json:"idempotency_key"`
+	RequestHash    string          `json:"request_hash"`
+	StatusCode     sql.NullInt16   `json:"status_code"`
+	ResponseHeader json.RawMessage `json:"response_header"`
+	ResponseBody   sql.NullString  `json:"response_body"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ClaimToken     string          `json:"claim_token"`
+}
+
+type List struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Tag struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Todo struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	IsCompleted bool      `json:"is_completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	IsCompleted    bool           `json:"is_completed"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	Priority       int8           `json:"priority"`
+	ListID         string         `json:"list_id"`
+	ParentID       sql.NullString `json:"parent_id"`
+	Position       string         `json:"position"`
+	RecurrenceRule sql.NullString `json:"recurrence_rule"`
+	RecurrenceTz   sql.NullString `json:"recurrence_tz"`
+	DeletedAt      sql.NullTime   `json:"deleted_at"`
+	Version        uint32         `json:"version"`
+	DueAt          sql.NullTime   `json:"due_at"`
+	TrashID        string         `json:"trash_id"`
+}
+
+type TodoTag struct {
+	TodoID string `json:"todo_id"`
+	TagID  string `json:"tag_id"`
 }
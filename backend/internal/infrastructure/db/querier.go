@@ -7,15 +7,52 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AttachTag(ctx context.Context, arg AttachTagParams) (sql.Result, error)
+	// Tags are part of a todo, so changing them moves it to a new version
+	BumpTodoVersion(ctx context.Context, id string) error
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) error
+	// Only the claim that is still in flight under the same token is completed
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (sql.Result, error)
+	CountSubtasks(ctx context.Context, parentIds []sql.NullString) ([]CountSubtasksRow, error)
+	CreateList(ctx context.Context, arg CreateListParams) (sql.Result, error)
+	CreateTag(ctx context.Context, arg CreateTagParams) (sql.Result, error)
 	CreateTodo(ctx context.Context, arg CreateTodoParams) (sql.Result, error)
-	DeleteTodo(ctx context.Context, id string) error
+	DeleteExpiredIdempotencyKey(ctx context.Context, arg DeleteExpiredIdempotencyKeyParams) error
+	// A list is deleted only while it holds no todos, trashed ones included, so
+	// that deleting a list never bypasses the trash
+	DeleteList(ctx context.Context, arg DeleteListParams) (sql.Result, error)
+	DeleteTag(ctx context.Context, id string) (sql.Result, error)
+	DeleteTodo(ctx context.Context, id string) (sql.Result, error)
+	DetachTag(ctx context.Context, arg DetachTagParams) (sql.Result, error)
+	GetIdempotencyKey(ctx context.Context, idempotencyKey string) (IdempotencyKey, error)
+	GetList(ctx context.Context, id string) (GetListRow, error)
+	GetTag(ctx context.Context, id string) (Tag, error)
 	GetTodo(ctx context.Context, id string) (Todo, error)
 	GetTodoByTitle(ctx context.Context, title string) ([]Todo, error)
-	ListTodos(ctx context.Context) ([]Todo, error)
+	GetTrashedTodo(ctx context.Context, id string) (Todo, error)
+	ListLists(ctx context.Context) ([]ListListsRow, error)
+	ListTags(ctx context.Context) ([]Tag, error)
+	ListTagsForTodos(ctx context.Context, todoIds []string) ([]ListTagsForTodosRow, error)
+	ListTrash(ctx context.Context) ([]Todo, error)
+	PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) (sql.Result, error)
+	PurgeTrash(ctx context.Context) (sql.Result, error)
+	PurgeTrashBefore(ctx context.Context, deletedAt sql.NullTime) (sql.Result, error)
+	// A claim that lapsed and was taken over has another token and is kept
+	ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error
+	RenameList(ctx context.Context, arg RenameListParams) (sql.Result, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) (sql.Result, error)
+	RestoreTodos(ctx context.Context, ids []string) error
+	// Trashing is not an edit either, so updated_at is kept as it is
+	TrashTodos(ctx context.Context, arg TrashTodosParams) error
+	// The version check makes the update fail, rather than overwrite, when the
+	// todo changed since it was read
 	UpdateTodo(ctx context.Context, arg UpdateTodoParams) (sql.Result, error)
+	// Reordering is not an edit, so updated_at is kept as it is
+	UpdateTodoPosition(ctx context.Context, arg UpdateTodoPositionParams) error
 }
 
 var _ Querier = (*Queries)(nil)
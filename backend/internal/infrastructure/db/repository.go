@@ -1,100 +1,198 @@
 package db
 
 import (
+	"backend/internal/domain"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 )
 
+// MySQL server error numbers mapped to domain errors
+const (
+	mysqlErrDuplicateEntry  = 1062
+	mysqlErrNoReferencedRow = 1452
+	// mysqlErrLockDeadlock aborts the transaction chosen to break a deadlock
+	mysqlErrLockDeadlock = 1213
+)
+
 type TodoRepository struct {
-	db      *sql.DB
 	queries *Queries
+	// conn runs the hand-written queries: the database, or a transaction
+	// within a unit of work
+	conn DBTX
 }
 
 func NewTodoRepository(db *sql.DB) *TodoRepository {
-	return &TodoRepository{
-		db:      db,
-		queries: New(db),
-	}
+	return newTodoRepository(db)
 }
 
-func (r *TodoRepository) GetDB() *sql.DB {
-	return r.db
+func newTodoRepository(conn DBTX) *TodoRepository {
+	return &TodoRepository{
+		queries: New(conn),
+		conn:    conn,
+	}
 }
 
 func (r *TodoRepository) GetQueries() *Queries {
 	return r.queries
 }
 
-func (r *TodoRepository) Create(ctx context.Context, title string) (*Todo, error) {
-	id := uuid.New().String()
-	params := CreateTodoParams{
-		ID:          id,
-		Title:       title,
-		IsCompleted: false,
-	}
+// Create inserts a todo under a freshly generated id; params.ID is ignored
+func (r *TodoRepository) Create(ctx context.Context, params CreateTodoParams) (*Todo, error) {
+	params.ID = uuid.New().String()
 
 	_, err := r.queries.CreateTodo(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create todo: %w", err)
 	}
 
-	return r.GetByID(ctx, id)
+	return r.GetByID(ctx, params.ID)
 }
 
+// GetByID returns a todo, or domain.ErrTodoNotFound if it does not exist or is trashed
 func (r *TodoRepository) GetByID(ctx context.Context, id string) (*Todo, error) {
 	todo, err := r.queries.GetTodo(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, domain.ErrTodoNotFound
 		}
 		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
 	return &todo, nil
 }
 
-func (r *TodoRepository) List(ctx context.Context) ([]Todo, error) {
-	todos, err := r.queries.ListTodos(ctx)
+// GetTrashed returns a trashed todo, or domain.ErrTodoNotFound if it is not in the trash
+func (r *TodoRepository) GetTrashed(ctx context.Context, id string) (*Todo, error) {
+	todo, err := r.queries.GetTrashedTodo(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list todos: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to get trashed todo: %w", err)
 	}
-	return todos, nil
+	return &todo, nil
 }
 
-func (r *TodoRepository) Update(ctx context.Context, id string, title string, isCompleted bool) (*Todo, error) {
-	params := UpdateTodoParams{
-		ID:          id,
-		Title:       title,
-		IsCompleted: isCompleted,
+// List runs a query built by NewListQuery and returns the rows in list order
+func (r *TodoRepository) List(ctx context.Context, q ListQuery) ([]Todo, error) {
+	todos, err := r.queryTodos(ctx, q.sql, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
 	}
+	if q.backward {
+		// The query walked towards the start of the list, so flip it back
+		for i, j := 0, len(todos)-1; i < j; i, j = i+1, j-1 {
+			todos[i], todos[j] = todos[j], todos[i]
+		}
+	}
+	return todos, nil
+}
 
+// Update writes every field of a todo that is still at params.Version. It
+// returns domain.ErrTodoNotFound if the todo does not exist or is trashed,
+// and domain.ErrVersionMismatch if it has moved on to another version.
+func (r *TodoRepository) Update(ctx context.Context, params UpdateTodoParams) (*Todo, error) {
 	result, err := r.queries.UpdateTodo(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update todo: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
-	if rowsAffected == 0 {
-		return nil, nil
+	// The version always changes, so a matched row is always reported as
+	// affected; no match means the todo is gone or another write got there first
+	if affected == 0 {
+		if _, err := r.GetByID(ctx, params.ID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrVersionMismatch
 	}
+	return r.GetByID(ctx, params.ID)
+}
 
-	return r.GetByID(ctx, id)
+// Reposition sets the position of a todo without touching updated_at
+func (r *TodoRepository) Reposition(ctx context.Context, id string, position string) error {
+	err := r.queries.UpdateTodoPosition(ctx, UpdateTodoPositionParams{ID: id, Position: position})
+	if err != nil {
+		return fmt.Errorf("failed to reposition todo: %w", err)
+	}
+	return nil
 }
 
+// Delete permanently deletes a todo. It returns domain.ErrTodoNotFound if
+// there is no such row, trashed or not.
 func (r *TodoRepository) Delete(ctx context.Context, id string) error {
-	err := r.queries.DeleteTodo(ctx, id)
+	result, err := r.queries.DeleteTodo(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
+	return requireAffected(result, domain.ErrTodoNotFound)
+}
+
+// Trash moves todos to the trash at the given time as the operation trashID.
+// Todos already in the trash keep their original time and operation.
+func (r *TodoRepository) Trash(ctx context.Context, ids []string, trashID string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := r.queries.TrashTodos(ctx, TrashTodosParams{DeletedAt: sql.NullTime{Time: at.UTC(), Valid: true}, TrashID: trashID, Ids: ids})
+	if err != nil {
+		return fmt.Errorf("failed to trash todos: %w", err)
+	}
+	return nil
+}
+
+// Restore takes todos out of the trash
+func (r *TodoRepository) Restore(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := r.queries.RestoreTodos(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to restore todos: %w", err)
+	}
 	return nil
 }
 
+// ListTrash returns the trashed todos, most recently trashed first
+func (r *TodoRepository) ListTrash(ctx context.Context) ([]Todo, error) {
+	todos, err := r.queries.ListTrash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	return todos, nil
+}
+
+// PurgeTrash permanently deletes the todos trashed before cutoff, or the
+// whole trash if cutoff is zero, and returns how many rows were deleted.
+// Subtasks trashed with their parent go with it through the foreign key.
+func (r *TodoRepository) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if cutoff.IsZero() {
+		result, err = r.queries.PurgeTrash(ctx)
+	} else {
+		result, err = r.queries.PurgeTrashBefore(ctx, sql.NullTime{Time: cutoff.UTC(), Valid: true})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
 func (r *TodoRepository) SearchByTitle(ctx context.Context, titlePattern string) ([]Todo, error) {
 	todos, err := r.queries.GetTodoByTitle(ctx, titlePattern)
 	if err != nil {
@@ -103,6 +201,245 @@ func (r *TodoRepository) SearchByTitle(ctx context.Context, titlePattern string)
 	return todos, nil
 }
 
+// AttachTag links a tag to a todo, ignoring links that already exist
+func (r *TodoRepository) AttachTag(ctx context.Context, todoID string, tagID string) error {
+	result, err := r.queries.AttachTag(ctx, AttachTagParams{TodoID: todoID, TagID: tagID})
+	if err != nil {
+		return fmt.Errorf("failed to attach tag: %w", err)
+	}
+	return r.bumpVersionIfAffected(ctx, todoID, result)
+}
+
+// DetachTag unlinks a tag from a todo
+func (r *TodoRepository) DetachTag(ctx context.Context, todoID string, tagID string) error {
+	result, err := r.queries.DetachTag(ctx, DetachTagParams{TodoID: todoID, TagID: tagID})
+	if err != nil {
+		return fmt.Errorf("failed to detach tag: %w", err)
+	}
+	return r.bumpVersionIfAffected(ctx, todoID, result)
+}
+
+// bumpVersionIfAffected moves a todo to a new version when a change to its
+// tags touched any rows, so attaching a tag twice stays a no-op
+func (r *TodoRepository) bumpVersionIfAffected(ctx context.Context, todoID string, result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return nil
+	}
+	if err := r.queries.BumpTodoVersion(ctx, todoID); err != nil {
+		return fmt.Errorf("failed to bump todo version: %w", err)
+	}
+	return nil
+}
+
+// TagsForTodos loads the tags of several todos in a single query, keyed by todo id
+func (r *TodoRepository) TagsForTodos(ctx context.Context, todoIDs []string) (map[string][]Tag, error) {
+	result := make(map[string][]Tag, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return result, nil
+	}
+	rows, err := r.queries.ListTagsForTodos(ctx, todoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags for todos: %w", err)
+	}
+	for _, row := range rows {
+		result[row.TodoID] = append(result[row.TodoID], Tag{ID: row.ID, Name: row.Name, CreatedAt: row.CreatedAt})
+	}
+	return result, nil
+}
+
+// SubtaskCount is the number of direct subtasks of a todo and how many of them are completed
+type SubtaskCount struct {
+	Total     int
+	Completed int
+}
+
+// SubtaskCounts counts the direct subtasks of several todos in a single query,
+// keyed by parent id. Todos without subtasks are absent from the result.
+func (r *TodoRepository) SubtaskCounts(ctx context.Context, parentIDs []string) (map[string]SubtaskCount, error) {
+	result := make(map[string]SubtaskCount, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return result, nil
+	}
+	ids := make([]sql.NullString, len(parentIDs))
+	for i, id := range parentIDs {
+		ids[i] = sql.NullString{String: id, Valid: true}
+	}
+	rows, err := r.queries.CountSubtasks(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count subtasks: %w", err)
+	}
+	for _, row := range rows {
+		result[row.ParentID.String] = SubtaskCount{Total: int(row.Total), Completed: int(row.Completed)}
+	}
+	return result, nil
+}
+
+// subtreeQuery walks todos.parent_id downwards from a root through live
+// todos, and trashedSubtreeQuery through trashed ones. sqlc's MySQL analyzer
+// cannot resolve the columns of a recursive CTE, so they are written by hand.
+var (
+	subtreeQuery        = walkSubtreeQuery("deleted_at IS NULL")
+	trashedSubtreeQuery = walkSubtreeQuery("deleted_at IS NOT NULL")
+)
+
+// walkSubtreeQuery builds a subtree walk that keeps to todos matching the
+// given condition on deleted_at
+func walkSubtreeQuery(deleted string) string {
+	return `WITH RECURSIVE subtree (id, depth) AS (
+    SELECT id, 0 FROM todos WHERE id = ? AND ` + deleted + `
+    UNION ALL
+    SELECT todos.id, subtree.depth + 1 FROM todos JOIN subtree ON todos.parent_id = subtree.id
+    WHERE todos.` + deleted + `
+)
+SELECT ` + todoColumns + `
+FROM todos
+JOIN subtree USING (id)
+ORDER BY subtree.depth, created_at, id`
+}
+
+// Subtree returns a todo followed by all its descendants, shallower levels
+// first, skipping trashed todos. It returns no rows if the todo does not exist
+// or is trashed.
+func (r *TodoRepository) Subtree(ctx context.Context, id string) ([]Todo, error) {
+	todos, err := r.queryTodos(ctx, subtreeQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtree: %w", err)
+	}
+	return todos, nil
+}
+
+// TrashedSubtree returns a trashed todo followed by all its trashed
+// descendants, shallower levels first. It returns no rows if the todo does
+// not exist or is not in the trash.
+func (r *TodoRepository) TrashedSubtree(ctx context.Context, id string) ([]Todo, error) {
+	todos, err := r.queryTodos(ctx, trashedSubtreeQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed subtree: %w", err)
+	}
+	return todos, nil
+}
+
+// CountByPriority runs a query built by NewCountByPriorityQuery.
+// Priorities without todos are absent from the result.
+func (r *TodoRepository) CountByPriority(ctx context.Context, query string, args ...interface{}) (map[int8]int, error) {
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todos by priority: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int8]int)
+	for rows.Next() {
+		var (
+			priority int8
+			count    int
+		)
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan priority count: %w", err)
+		}
+		counts[priority] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count todos by priority: %w", err)
+	}
+	return counts, nil
+}
+
+// SearchByTitleWords returns todos whose title matches every LIKE pattern.
+// sqlc cannot express a variable number of predicates, so the query is built here
+// from placeholders only.
+func (r *TodoRepository) SearchByTitleWords(ctx context.Context, titlePatterns []string) ([]Todo, error) {
+	if len(titlePatterns) == 0 {
+		return nil, nil
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]interface{}, len(titlePatterns))
+	for i, pattern := range titlePatterns {
+		conditions = append(conditions, "title LIKE ?")
+		args[i] = pattern
+	}
+
+	query := "SELECT " + todoColumns + `
+FROM todos
+WHERE ` + strings.Join(conditions, " AND ") + `
+ORDER BY created_at DESC`
+
+	todos, err := r.queryTodos(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos by title words: %w", err)
+	}
+	return todos, nil
+}
+
+// queryTodos runs a hand-built SELECT of todoColumns and scans every row
+func (r *TodoRepository) queryTodos(ctx context.Context, query string, args ...interface{}) ([]Todo, error) {
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []Todo
+	for rows.Next() {
+		var t Todo
+		if err := rows.Scan(&t.ID, &t.Title, &t.IsCompleted, &t.CreatedAt, &t.UpdatedAt, &t.DueAt, &t.Priority, &t.ListID, &t.ParentID, &t.Position, &t.RecurrenceRule, &t.RecurrenceTz, &t.DeletedAt, &t.Version, &t.TrashID); err != nil {
+			return nil, err
+		}
+		todos = append(todos, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// likeEscaper escapes the MySQL LIKE wildcards and the default escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// toNullTime converts an optional time to sql.NullTime, normalized to UTC
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// toNullString converts an optional string to sql.NullString
+func toNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// requireAffected returns notFound if a statement matched no rows
+func requireAffected(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// isMySQLError reports whether err wraps a MySQL server error with the given number
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
+
 func ConnectDB(dsn string) (*sql.DB, error) {
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
@@ -3,6 +3,9 @@ package db
 import (
 	"backend/internal/domain"
 	"context"
+	"database/sql"
+	"strings"
+	"time"
 )
 
 // TodoRepositoryAdapter adapts the sqlc-based TodoRepository to the domain.TodoRepository interface
@@ -15,13 +18,13 @@ func NewTodoRepositoryAdapter(repo *TodoRepository) *TodoRepositoryAdapter {
 	return &TodoRepositoryAdapter{repo: repo}
 }
 
-// List returns all todos
-func (a *TodoRepositoryAdapter) List(ctx context.Context) ([]domain.Todo, error) {
-	todos, err := a.repo.List(ctx)
+// List returns a page of the todos matching the query
+func (a *TodoRepositoryAdapter) List(ctx context.Context, query domain.TodoQuery) ([]domain.Todo, error) {
+	todos, err := a.repo.List(ctx, NewListQuery(query, time.Now()))
 	if err != nil {
 		return nil, err
 	}
-	return toDomainTodos(todos), nil
+	return a.withTags(ctx, todos)
 }
 
 // GetByID returns a todo by ID
@@ -30,60 +33,272 @@ func (a *TodoRepositoryAdapter) GetByID(ctx context.Context, id string) (*domain
 	if err != nil {
 		return nil, err
 	}
-	if todo == nil {
-		return nil, nil
-	}
-	return toDomainTodo(todo), nil
+	return a.withTagsOne(ctx, todo)
 }
 
 // Create creates a new todo
-func (a *TodoRepositoryAdapter) Create(ctx context.Context, title string) (*domain.Todo, error) {
-	todo, err := a.repo.Create(ctx, title)
+func (a *TodoRepositoryAdapter) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	todo, err := a.repo.Create(ctx, CreateTodoParams{
+		Title:          params.Title,
+		IsCompleted:    false,
+		DueAt:          toNullTime(params.DueAt),
+		Priority:       int8(params.Priority),
+		ListID:         params.ListID,
+		ParentID:       toNullString(params.ParentID),
+		Position:       params.Position,
+		RecurrenceRule: recurrenceRule(params.Recurrence),
+		RecurrenceTz:   recurrenceTimeZone(params.Recurrence),
+	})
 	if err != nil {
-		return nil, err
+		return nil, referenceError(err)
 	}
-	return toDomainTodo(todo), nil
+	return toDomainTodo(todo, nil, SubtaskCount{}), nil
 }
 
 // Update updates a todo
-func (a *TodoRepositoryAdapter) Update(ctx context.Context, id string, title string, isCompleted bool) (*domain.Todo, error) {
-	todo, err := a.repo.Update(ctx, id, title, isCompleted)
+func (a *TodoRepositoryAdapter) Update(ctx context.Context, params domain.UpdateTodoParams) (*domain.Todo, error) {
+	todo, err := a.repo.Update(ctx, UpdateTodoParams{
+		ID:             params.ID,
+		Title:          params.Title,
+		IsCompleted:    params.IsCompleted,
+		DueAt:          toNullTime(params.DueAt),
+		Priority:       int8(params.Priority),
+		ListID:         params.ListID,
+		ParentID:       toNullString(params.ParentID),
+		Position:       params.Position,
+		RecurrenceRule: recurrenceRule(params.Recurrence),
+		RecurrenceTz:   recurrenceTimeZone(params.Recurrence),
+		Version:        uint32(params.Version),
+	})
 	if err != nil {
-		return nil, err
+		return nil, referenceError(err)
 	}
-	if todo == nil {
-		return nil, nil
-	}
-	return toDomainTodo(todo), nil
+	return a.withTagsOne(ctx, todo)
+}
+
+// Reposition changes the manual position of a todo
+func (a *TodoRepositoryAdapter) Reposition(ctx context.Context, id string, position string) error {
+	return a.repo.Reposition(ctx, id, position)
 }
 
-// Delete deletes a todo
+// Delete permanently deletes a todo
 func (a *TodoRepositoryAdapter) Delete(ctx context.Context, id string) error {
 	return a.repo.Delete(ctx, id)
 }
 
-// toDomainTodo converts a db.Todo to domain.Todo
-func toDomainTodo(t *Todo) *domain.Todo {
+// GetTrashed returns a trashed todo by ID
+func (a *TodoRepositoryAdapter) GetTrashed(ctx context.Context, id string) (*domain.Todo, error) {
+	todo, err := a.repo.GetTrashed(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return a.withTagsOne(ctx, todo)
+}
+
+// Trash moves todos to the trash
+func (a *TodoRepositoryAdapter) Trash(ctx context.Context, ids []string, trashID string, at time.Time) error {
+	return a.repo.Trash(ctx, ids, trashID, at)
+}
+
+// Restore takes todos out of the trash
+func (a *TodoRepositoryAdapter) Restore(ctx context.Context, ids []string) error {
+	return a.repo.Restore(ctx, ids)
+}
+
+// ListTrash returns the trashed todos, most recently trashed first
+func (a *TodoRepositoryAdapter) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	todos, err := a.repo.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.withTags(ctx, todos)
+}
+
+// PurgeTrash permanently deletes trashed todos
+func (a *TodoRepositoryAdapter) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	return a.repo.PurgeTrash(ctx, cutoff)
+}
+
+// Subtree returns a todo and its descendants, parents first
+func (a *TodoRepositoryAdapter) Subtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	todos, err := a.repo.Subtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return a.withTags(ctx, todos)
+}
+
+// TrashedSubtree returns a trashed todo and its trashed descendants, parents first
+func (a *TodoRepositoryAdapter) TrashedSubtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	todos, err := a.repo.TrashedSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return a.withTags(ctx, todos)
+}
+
+// CountByPriority returns the number of todos matching the filter per priority
+func (a *TodoRepositoryAdapter) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	query, args := NewCountByPriorityQuery(filter, time.Now())
+	counts, err := a.repo.CountByPriority(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[domain.Priority]int, len(counts))
+	for p, n := range counts {
+		result[domain.Priority(p)] = n
+	}
+	return result, nil
+}
+
+// Search returns todos whose title matches the search, unranked
+func (a *TodoRepositoryAdapter) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	var (
+		todos []Todo
+		err   error
+	)
+	switch search.Mode {
+	case domain.SearchModePrefix:
+		todos, err = a.repo.SearchByTitle(ctx, escapeLike(search.Query)+"%")
+	case domain.SearchModeAllWords:
+		terms := search.Terms()
+		patterns := make([]string, len(terms))
+		for i, term := range terms {
+			patterns[i] = "%" + escapeLike(term) + "%"
+		}
+		todos, err = a.repo.SearchByTitleWords(ctx, patterns)
+	default:
+		todos, err = a.repo.SearchByTitle(ctx, "%"+escapeLike(search.Query)+"%")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.withTags(ctx, todos)
+}
+
+// AttachTag labels a todo with a tag
+func (a *TodoRepositoryAdapter) AttachTag(ctx context.Context, todoID string, tagID string) error {
+	err := a.repo.AttachTag(ctx, todoID, tagID)
+	// The todo is looked up by the caller, so a dangling reference means the tag is missing
+	if isMySQLError(err, mysqlErrNoReferencedRow) {
+		return domain.ErrTagNotFound
+	}
+	return err
+}
+
+// DetachTag removes a tag from a todo
+func (a *TodoRepositoryAdapter) DetachTag(ctx context.Context, todoID string, tagID string) error {
+	return a.repo.DetachTag(ctx, todoID, tagID)
+}
+
+// referenceError maps a dangling list_id or parent_id to the matching domain error.
+// The usecase checks the parent before writing, so a violation here is normally the list.
+func referenceError(err error) error {
+	if !isMySQLError(err, mysqlErrNoReferencedRow) {
+		return err
+	}
+	if strings.Contains(err.Error(), "fk_todos_parent") {
+		return domain.ErrParentNotFound
+	}
+	return domain.ErrInvalidListID
+}
+
+// withTags converts todos to domain.Todo, loading all their tags and subtask
+// counts with one query each
+func (a *TodoRepositoryAdapter) withTags(ctx context.Context, todos []Todo) ([]domain.Todo, error) {
+	ids := make([]string, len(todos))
+	for i, t := range todos {
+		ids[i] = t.ID
+	}
+	tags, err := a.repo.TagsForTodos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	subtasks, err := a.repo.SubtaskCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.Todo, len(todos))
+	for i, t := range todos {
+		result[i] = *toDomainTodo(&t, tags[t.ID], subtasks[t.ID])
+	}
+	return result, nil
+}
+
+// withTagsOne is withTags for a single todo
+func (a *TodoRepositoryAdapter) withTagsOne(ctx context.Context, todo *Todo) (*domain.Todo, error) {
+	todos, err := a.withTags(ctx, []Todo{*todo})
+	if err != nil {
+		return nil, err
+	}
+	return &todos[0], nil
+}
+
+// toDomainTodo converts a db.Todo, its tags and its subtask counts to domain.Todo
+func toDomainTodo(t *Todo, tags []Tag, subtasks SubtaskCount) *domain.Todo {
 	return &domain.Todo{
 		ID:          t.ID,
 		Title:       t.Title,
 		IsCompleted: t.IsCompleted,
+		DueAt:       fromNullTime(t.DueAt),
+		Priority:    domain.Priority(t.Priority),
+		Tags:        toDomainTags(tags),
+		ListID:      t.ListID,
+		ParentID:    fromNullString(t.ParentID),
+		Position:    t.Position,
+		Recurrence:  fromRecurrence(t.RecurrenceRule, t.RecurrenceTz),
+		DeletedAt:   fromNullTime(t.DeletedAt),
+		TrashID:     t.TrashID,
+		Version:     int(t.Version),
+		Subtasks:    domain.SubtaskProgress{Completed: subtasks.Completed, Total: subtasks.Total},
 		CreatedAt:   t.CreatedAt,
 		UpdatedAt:   t.UpdatedAt,
 	}
 }
 
-// toDomainTodos converts a slice of db.Todo to domain.Todo
-func toDomainTodos(todos []Todo) []domain.Todo {
-	result := make([]domain.Todo, len(todos))
-	for i, t := range todos {
-		result[i] = domain.Todo{
-			ID:          t.ID,
-			Title:       t.Title,
-			IsCompleted: t.IsCompleted,
-			CreatedAt:   t.CreatedAt,
-			UpdatedAt:   t.UpdatedAt,
-		}
+// fromNullTime converts sql.NullTime to an optional UTC time
+func fromNullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// fromRecurrence parses the stored recurrence columns. Rules are validated
+// before they are written, so one that no longer parses is treated as absent.
+func fromRecurrence(rule, timeZone sql.NullString) *domain.Recurrence {
+	if !rule.Valid {
+		return nil
+	}
+	r, err := domain.ParseRecurrence(rule.String, timeZone.String)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// recurrenceRule returns the RRULE column value for an optional recurrence
+func recurrenceRule(r *domain.Recurrence) sql.NullString {
+	if r == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: r.String(), Valid: true}
+}
+
+// recurrenceTimeZone returns the time zone column value for an optional recurrence
+func recurrenceTimeZone(r *domain.Recurrence) sql.NullString {
+	if r == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: r.TimeZone, Valid: true}
+}
+
+// fromNullString converts sql.NullString to an optional string
+func fromNullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
 	}
-	return result
+	return &s.String
 }
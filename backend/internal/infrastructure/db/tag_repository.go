@@ -0,0 +1,69 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type TagRepository struct {
+	queries *Queries
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{queries: New(db)}
+}
+
+func (r *TagRepository) List(ctx context.Context) ([]Tag, error) {
+	tags, err := r.queries.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// GetByID returns a tag, or domain.ErrTagNotFound if it does not exist
+func (r *TagRepository) GetByID(ctx context.Context, id string) (*Tag, error) {
+	tag, err := r.queries.GetTag(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return &tag, nil
+}
+
+func (r *TagRepository) Create(ctx context.Context, name string) (*Tag, error) {
+	id := uuid.New().String()
+	_, err := r.queries.CreateTag(ctx, CreateTagParams{ID: id, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Rename changes a tag's name. It returns domain.ErrTagNotFound if the tag does not exist.
+func (r *TagRepository) Rename(ctx context.Context, id string, name string) (*Tag, error) {
+	// MySQL reports zero affected rows when the name is unchanged, so existence
+	// is checked up front rather than inferred from the UPDATE result
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	if _, err := r.queries.RenameTag(ctx, RenameTagParams{ID: id, Name: name}); err != nil {
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a tag. It returns domain.ErrTagNotFound if the tag does not exist.
+func (r *TagRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteTag(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return requireAffected(result, domain.ErrTagNotFound)
+}
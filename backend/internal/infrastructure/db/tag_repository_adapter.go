@@ -0,0 +1,81 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+)
+
+// TagRepositoryAdapter adapts the sqlc-based TagRepository to the domain.TagRepository interface
+type TagRepositoryAdapter struct {
+	repo *TagRepository
+}
+
+// NewTagRepositoryAdapter creates a new adapter
+func NewTagRepositoryAdapter(repo *TagRepository) *TagRepositoryAdapter {
+	return &TagRepositoryAdapter{repo: repo}
+}
+
+// List returns all tags ordered by name
+func (a *TagRepositoryAdapter) List(ctx context.Context) ([]domain.Tag, error) {
+	tags, err := a.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainTags(tags), nil
+}
+
+// GetByID returns a tag by ID
+func (a *TagRepositoryAdapter) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tag, err := a.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainTag(tag), nil
+}
+
+// Create creates a new tag
+func (a *TagRepositoryAdapter) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	tag, err := a.repo.Create(ctx, name)
+	if err != nil {
+		if isMySQLError(err, mysqlErrDuplicateEntry) {
+			return nil, domain.ErrTagExists
+		}
+		return nil, err
+	}
+	return toDomainTag(tag), nil
+}
+
+// Rename renames a tag
+func (a *TagRepositoryAdapter) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	tag, err := a.repo.Rename(ctx, id, name)
+	if err != nil {
+		if isMySQLError(err, mysqlErrDuplicateEntry) {
+			return nil, domain.ErrTagExists
+		}
+		return nil, err
+	}
+	return toDomainTag(tag), nil
+}
+
+// Delete deletes a tag and detaches it from every todo
+func (a *TagRepositoryAdapter) Delete(ctx context.Context, id string) error {
+	return a.repo.Delete(ctx, id)
+}
+
+// toDomainTag converts a db.Tag to domain.Tag
+func toDomainTag(t *Tag) *domain.Tag {
+	return &domain.Tag{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// toDomainTags converts a slice of db.Tag to domain.Tag, never returning nil
+func toDomainTags(tags []Tag) []domain.Tag {
+	result := make([]domain.Tag, len(tags))
+	for i, t := range tags {
+		result[i] = *toDomainTag(&t)
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// maxTxAttempts bounds how often a deadlocked transaction is run
+	maxTxAttempts = 3
+	// txRetryDelay is the base delay before running a deadlocked transaction
+	// again; it grows with every attempt and is jittered so the transactions
+	// that deadlocked do not meet again in lockstep
+	txRetryDelay = 20 * time.Millisecond
+)
+
+// UnitOfWork runs repository operations in MySQL transactions. The zero
+// depth unit begins transactions; the units handed to a transaction's
+// function nest savepoints inside it.
+type UnitOfWork struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	depth int
+}
+
+// NewUnitOfWork creates a unit of work beginning transactions on db
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction, retrying it when MySQL aborts it to break a
+// deadlock. Within a transaction it runs fn under a savepoint instead and
+// leaves retrying to the outermost unit, since a deadlock rolls back the
+// whole transaction.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	if u.tx != nil {
+		return u.savepoint(ctx, fn)
+	}
+	return retryDeadlocks(ctx, func() error {
+		return u.transaction(ctx, fn)
+	})
+}
+
+// transaction runs fn in a new transaction
+func (u *UnitOfWork) transaction(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// A no-op once committed; otherwise it undoes a failed or panicking fn
+	defer tx.Rollback()
+
+	if err := fn(repositories(&UnitOfWork{db: u.db, tx: tx})); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs fn under a savepoint of the current transaction. Sibling
+// savepoints reuse the name of their level, which MySQL allows once the
+// previous one is released or rolled back to.
+func (u *UnitOfWork) savepoint(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	name := fmt.Sprintf("sp_%d", u.depth+1)
+	if _, err := u.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to set savepoint: %w", err)
+	}
+
+	if err := fn(repositories(&UnitOfWork{db: u.db, tx: u.tx, depth: u.depth + 1})); err != nil {
+		// After a deadlock the savepoint is gone with the rest of the transaction
+		if isMySQLError(err, mysqlErrLockDeadlock) {
+			return err
+		}
+		if _, rbErr := u.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w (after %v)", rbErr, err)
+		}
+		return err
+	}
+	if _, err := u.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// repositories returns the repositories of a unit bound to a transaction
+func repositories(u *UnitOfWork) domain.Repositories {
+	return domain.Repositories{
+		Todos:      NewTodoRepositoryAdapter(newTodoRepository(u.tx)),
+		Tags:       NewTagRepositoryAdapter(&TagRepository{queries: New(u.tx)}),
+		Lists:      NewListRepositoryAdapter(&ListRepository{queries: New(u.tx)}),
+		UnitOfWork: u,
+	}
+}
+
+// retryDeadlocks runs fn until it does not fail with a deadlock, at most
+// maxTxAttempts times, and returns its last error
+func retryDeadlocks(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if attempt == maxTxAttempts || !isMySQLError(err, mysqlErrLockDeadlock) {
+			return err
+		}
+		delay := time.Duration(attempt)*txRetryDelay + time.Duration(rand.Int63n(int64(txRetryDelay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
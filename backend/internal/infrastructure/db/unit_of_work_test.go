@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRetryDeadlocks(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: mysqlErrLockDeadlock, Message: "Deadlock found when trying to get lock"}
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "runs once on success", errs: []error{nil}, wantAttempts: 1},
+		{name: "retries after a deadlock", errs: []error{deadlock, nil}, wantAttempts: 2},
+		{name: "retries wrapped deadlocks", errs: []error{errors.Join(errors.New("failed to update todo"), deadlock), nil}, wantAttempts: 2},
+		{name: "gives up after the last attempt", errs: []error{deadlock, deadlock, deadlock}, wantErr: deadlock, wantAttempts: maxTxAttempts},
+		{name: "does not retry other errors", errs: []error{other}, wantErr: other, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryDeadlocks(context.Background(), func() error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryDeadlocks_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadlock := &mysql.MySQLError{Number: mysqlErrLockDeadlock}
+
+	attempts := 0
+	err := retryDeadlocks(ctx, func() error {
+		attempts++
+		return deadlock
+	})
+	if !errors.Is(err, deadlock) || attempts != 1 {
+		t.Errorf("expected one attempt ending in a deadlock, got %d attempts and %v", attempts, err)
+	}
+}
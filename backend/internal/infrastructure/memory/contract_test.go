@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"backend/internal/infrastructure/repotest"
+	"testing"
+)
+
+func TestTodoRepository_Contract(t *testing.T) {
+	repotest.TestTodoRepository(t, func(t *testing.T) domain.Repositories {
+		store := NewStore()
+		return domain.Repositories{
+			Todos:      NewTodoRepository(store),
+			Tags:       NewTagRepository(store),
+			Lists:      NewListRepository(store),
+			UnitOfWork: store,
+		}
+	})
+}
+
+func TestIdempotencyRepository_Contract(t *testing.T) {
+	repotest.TestIdempotencyRepository(t, func(t *testing.T) domain.IdempotencyRepository {
+		return NewIdempotencyRepository(NewStore())
+	})
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"time"
+)
+
+// IdempotencyRepository implements domain.IdempotencyRepository in memory
+type IdempotencyRepository struct {
+	conn conn
+}
+
+// NewIdempotencyRepository creates an idempotency repository on store
+func NewIdempotencyRepository(store *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{conn: store}
+}
+
+// Claim stores an in-flight record unless the key is still held, in which
+// case it returns the record holding it
+func (r *IdempotencyRepository) Claim(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, bool, error) {
+	var held *domain.IdempotencyRecord
+	err := r.conn.write(func(s *state) error {
+		if existing, ok := s.idempotency[record.Key]; ok && existing.ExpiresAt.After(now) {
+			held = &existing
+			return nil
+		}
+		record.Response = nil
+		s.putIdempotency(record)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return held, held == nil, nil
+}
+
+// Complete stores the response of an in-flight claim. It fails if the claim
+// was released or has expired and been taken over in the meantime.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, token string, response domain.StoredResponse, expiresAt time.Time) error {
+	return r.conn.write(func(s *state) error {
+		record, ok := s.idempotency[key]
+		if !ok || record.Token != token || record.Response != nil {
+			return fmt.Errorf("idempotency key %q is no longer claimed", key)
+		}
+		record.Response = copyResponse(response)
+		record.ExpiresAt = expiresAt
+		s.putIdempotency(record)
+		return nil
+	})
+}
+
+// Release deletes a claim whose request has not completed, unless another
+// claim has taken its place
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, token string) error {
+	return r.conn.write(func(s *state) error {
+		if record, ok := s.idempotency[key]; ok && record.Token == token && record.Response == nil {
+			s.deleteIdempotency(key)
+		}
+		return nil
+	})
+}
+
+// PurgeExpired deletes every record that expired by now and returns how many were deleted
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	purged := 0
+	err := r.conn.write(func(s *state) error {
+		for key, record := range s.idempotency {
+			if !record.ExpiresAt.After(now) {
+				s.deleteIdempotency(key)
+				purged++
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// copyResponse returns a copy of a response that shares no memory with the
+// caller's, so the stored record cannot change after the fact
+func copyResponse(response domain.StoredResponse) *domain.StoredResponse {
+	copied := &domain.StoredResponse{Status: response.Status, Body: append([]byte(nil), response.Body...)}
+	if response.Header != nil {
+		copied.Header = make(map[string]string, len(response.Header))
+		for k, v := range response.Header {
+			copied.Header[k] = v
+		}
+	}
+	return copied
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// ListRepository implements domain.ListRepository in memory
+type ListRepository struct {
+	conn conn
+}
+
+// NewListRepository creates a list repository on store
+func NewListRepository(store *Store) *ListRepository {
+	return &ListRepository{conn: store}
+}
+
+// List returns every list with its todo counts, oldest first
+func (r *ListRepository) List(ctx context.Context) ([]domain.List, error) {
+	var lists []domain.List
+	err := r.conn.read(func(s *state) error {
+		lists = make([]domain.List, 0, len(s.lists))
+		for _, l := range s.lists {
+			lists = append(lists, l)
+		}
+		sort.Slice(lists, func(i, j int) bool {
+			if c := lists[i].CreatedAt.Compare(lists[j].CreatedAt); c != 0 {
+				return c < 0
+			}
+			return lists[i].ID < lists[j].ID
+		})
+		s.countTodos(lists)
+		return nil
+	})
+	return lists, err
+}
+
+// GetByID returns a list with its todo counts, or domain.ErrListNotFound if it does not exist
+func (r *ListRepository) GetByID(ctx context.Context, id string) (*domain.List, error) {
+	var list *domain.List
+	err := r.conn.read(func(s *state) error {
+		l, ok := s.lists[id]
+		if !ok {
+			return domain.ErrListNotFound
+		}
+		lists := []domain.List{l}
+		s.countTodos(lists)
+		list = &lists[0]
+		return nil
+	})
+	return list, err
+}
+
+// Create creates a new, empty list
+func (r *ListRepository) Create(ctx context.Context, name string) (*domain.List, error) {
+	now := r.conn.clock()
+	l := domain.List{ID: uuid.New().String(), Name: name, CreatedAt: now, UpdatedAt: now}
+	err := r.conn.write(func(s *state) error {
+		s.putList(l)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Rename changes a list's name. It returns domain.ErrListNotFound if the list does not exist.
+func (r *ListRepository) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	now := r.conn.clock()
+	var list *domain.List
+	err := r.conn.write(func(s *state) error {
+		l, ok := s.lists[id]
+		if !ok {
+			return domain.ErrListNotFound
+		}
+		// ON UPDATE CURRENT_TIMESTAMP only fires when the row changes
+		if l.Name != name {
+			l.Name = name
+			l.UpdatedAt = now
+			s.putList(l)
+		}
+		lists := []domain.List{l}
+		s.countTodos(lists)
+		list = &lists[0]
+		return nil
+	})
+	return list, err
+}
+
+// Delete deletes a list that holds no todos, trashed ones included. It
+// returns domain.ErrListNotFound if the list does not exist and
+// domain.ErrListNotEmpty if it still has todos.
+func (r *ListRepository) Delete(ctx context.Context, id string) error {
+	return r.conn.write(func(s *state) error {
+		if _, ok := s.lists[id]; !ok {
+			return domain.ErrListNotFound
+		}
+		for _, t := range s.todos {
+			if t.ListID == id {
+				return domain.ErrListNotEmpty
+			}
+		}
+		s.deleteList(id)
+		return nil
+	})
+}
+
+// countTodos fills in the counts of live todos of lists and marks the default list
+func (s *state) countTodos(lists []domain.List) {
+	index := make(map[string]int, len(lists))
+	for i := range lists {
+		lists[i].IsDefault = lists[i].ID == domain.DefaultListID
+		lists[i].TodoCount, lists[i].CompletedCount = 0, 0
+		index[lists[i].ID] = i
+	}
+	for _, t := range s.todos {
+		i, ok := index[t.ListID]
+		if !ok || t.DeletedAt != nil {
+			continue
+		}
+		lists[i].TodoCount++
+		if t.IsCompleted {
+			lists[i].CompletedCount++
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package memory implements the repositories in process memory, with the
+// same ordering and semantics as the MySQL ones. Data lives as long as the
+// process does, which suits demos and tests that should run without a database.
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store holds the data of the in-memory repositories. It is safe for
+// concurrent use: reads share a lock while writes and units of work hold it
+// exclusively, so units of work are serializable.
+type Store struct {
+	mu    sync.RWMutex
+	state *state
+	// now is the clock stamping created_at and updated_at
+	now func() time.Time
+}
+
+// NewStore creates a store holding only the default list, as a freshly
+// migrated database does
+func NewStore() *Store {
+	s := &Store{state: newState(), now: time.Now}
+	at := s.clock()
+	s.state.lists[domain.DefaultListID] = domain.List{ID: domain.DefaultListID, Name: "Inbox", CreatedAt: at, UpdatedAt: at}
+	return s
+}
+
+// conn is how a repository reaches the data: through the store's lock, or
+// directly within a unit of work that already holds it. Write functions
+// check everything before changing anything, so a failed write leaves no trace.
+type conn interface {
+	read(fn func(s *state) error) error
+	write(fn func(s *state) error) error
+	clock() time.Time
+}
+
+func (s *Store) read(fn func(s *state) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.state)
+}
+
+func (s *Store) write(fn func(s *state) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.state)
+}
+
+// clock returns the current time as MySQL's CURRENT_TIMESTAMP would store it
+func (s *Store) clock() time.Time {
+	return s.now().UTC().Truncate(time.Second)
+}
+
+// Do runs fn as a unit of work, holding the store's lock throughout so that
+// nothing fn does is seen by other callers before it returns. Every change fn
+// makes is journaled, and a failing or panicking fn has its changes undone,
+// leaving the data as it was. fn must only use the repositories it is given:
+// the store's own repositories would wait for the lock fn's unit of work holds.
+func (s *Store) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.journal = &journal{}
+	defer func() { s.state.journal = nil }()
+	t := &tx{store: s}
+	return t.run(fn)
+}
+
+// tx is the conn of a unit of work. It works on the store's data directly,
+// which the lock taken by Store.Do keeps to itself.
+type tx struct {
+	store *Store
+}
+
+func (t *tx) read(fn func(s *state) error) error { return fn(t.store.state) }
+
+func (t *tx) write(fn func(s *state) error) error { return fn(t.store.state) }
+
+func (t *tx) clock() time.Time { return t.store.clock() }
+
+// Do runs fn as a nested unit of work, keeping its changes only if it succeeds
+func (t *tx) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.run(fn)
+}
+
+// run calls fn and undoes the changes it journaled unless it returns nil.
+// The length of the journal when fn starts plays the part of a savepoint.
+func (t *tx) run(fn func(repos domain.Repositories) error) error {
+	j := t.store.state.journal
+	savepoint := len(j.undo)
+	committed := false
+	defer func() {
+		if !committed {
+			j.rollback(savepoint)
+		}
+	}()
+	if err := fn(repositories(t, t)); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// repositories returns the repositories of a unit of work
+func repositories(c conn, uow domain.UnitOfWork) domain.Repositories {
+	return domain.Repositories{
+		Todos:      &TodoRepository{conn: c},
+		Tags:       &TagRepository{conn: c},
+		Lists:      &ListRepository{conn: c},
+		UnitOfWork: uow,
+	}
+}
+
+// state is the data of a store. Repositories change it only through the
+// methods below, which journal the previous values while a unit of work runs.
+type state struct {
+	// todos are stored without their Tags and Subtasks, which reads fill in
+	todos map[string]domain.Todo
+	// todoTags holds the IDs of the tags attached to each todo
+	todoTags map[string]map[string]bool
+	tags     map[string]domain.Tag
+	// lists are stored without their counts
+	lists       map[string]domain.List
+	idempotency map[string]domain.IdempotencyRecord
+	// journal is set while a unit of work runs
+	journal *journal
+}
+
+func newState() *state {
+	return &state{
+		todos:       make(map[string]domain.Todo),
+		todoTags:    make(map[string]map[string]bool),
+		tags:        make(map[string]domain.Tag),
+		lists:       make(map[string]domain.List),
+		idempotency: make(map[string]domain.IdempotencyRecord),
+	}
+}
+
+// journal records how to undo each change of a unit of work, so that a
+// rollback costs as much as the changes it undoes rather than the whole data
+type journal struct {
+	undo []func()
+}
+
+// rollback undoes the changes journaled since savepoint, latest first
+func (j *journal) rollback(savepoint int) {
+	for i := len(j.undo) - 1; i >= savepoint; i-- {
+		j.undo[i]()
+	}
+	j.undo = j.undo[:savepoint]
+}
+
+// remember journals the current entry of m under key, if a unit of work is
+// running, so that a rollback puts it back or removes it again
+func remember[K comparable, V any](j *journal, m map[K]V, key K) {
+	if j == nil {
+		return
+	}
+	old, existed := m[key]
+	j.undo = append(j.undo, func() {
+		if existed {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	})
+}
+
+func (s *state) putTodo(t domain.Todo) {
+	remember(s.journal, s.todos, t.ID)
+	s.todos[t.ID] = t
+}
+
+// deleteTodo deletes a todo together with its tag links
+func (s *state) deleteTodo(id string) {
+	remember(s.journal, s.todos, id)
+	remember(s.journal, s.todoTags, id)
+	delete(s.todos, id)
+	delete(s.todoTags, id)
+}
+
+func (s *state) attachTag(todoID string, tagID string) {
+	if s.todoTags[todoID] == nil {
+		remember(s.journal, s.todoTags, todoID)
+		s.todoTags[todoID] = make(map[string]bool)
+	}
+	remember(s.journal, s.todoTags[todoID], tagID)
+	s.todoTags[todoID][tagID] = true
+}
+
+func (s *state) detachTag(todoID string, tagID string) {
+	if tags := s.todoTags[todoID]; tags != nil {
+		remember(s.journal, tags, tagID)
+		delete(tags, tagID)
+	}
+}
+
+func (s *state) putTag(t domain.Tag) {
+	remember(s.journal, s.tags, t.ID)
+	s.tags[t.ID] = t
+}
+
+// deleteTag deletes a tag and detaches it from every todo
+func (s *state) deleteTag(id string) {
+	remember(s.journal, s.tags, id)
+	delete(s.tags, id)
+	for todoID, tags := range s.todoTags {
+		if tags[id] {
+			s.detachTag(todoID, id)
+		}
+	}
+}
+
+func (s *state) putList(l domain.List) {
+	remember(s.journal, s.lists, l.ID)
+	s.lists[l.ID] = l
+}
+
+func (s *state) deleteList(id string) {
+	remember(s.journal, s.lists, id)
+	delete(s.lists, id)
+}
+
+func (s *state) putIdempotency(record domain.IdempotencyRecord) {
+	remember(s.journal, s.idempotency, record.Key)
+	s.idempotency[record.Key] = record
+}
+
+func (s *state) deleteIdempotency(key string) {
+	remember(s.journal, s.idempotency, key)
+	delete(s.idempotency, key)
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func createTodo(t *testing.T, repo domain.TodoRepository, title string) *domain.Todo {
+	t.Helper()
+	todo, err := repo.Create(context.Background(), domain.CreateTodoParams{Title: title, ListID: domain.DefaultListID})
+	if err != nil {
+		t.Fatalf("failed to create todo: %v", err)
+	}
+	return todo
+}
+
+func TestStore_Do_RollsBackOnPanic(t *testing.T) {
+	store := NewStore()
+
+	func() {
+		defer func() { recover() }()
+		store.Do(context.Background(), func(repos domain.Repositories) error {
+			createTodo(t, repos.Todos, "Discarded")
+			panic("boom")
+		})
+	}()
+
+	// The lock must have been released as well
+	todos, err := NewTodoRepository(store).List(context.Background(), domain.TodoQuery{})
+	if err != nil || len(todos) != 0 {
+		t.Errorf("expected no todos after panic, got %d (%v)", len(todos), err)
+	}
+}
+
+func TestStore_ConcurrentUpdates(t *testing.T) {
+	store := NewStore()
+	repo := NewTodoRepository(store)
+	todo := createTodo(t, repo, "Contended")
+
+	// Every writer reads the current version and updates at it within one
+	// unit of work, so none of them may see a version mismatch
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Do(context.Background(), func(repos domain.Repositories) error {
+				current, err := repos.Todos.GetByID(context.Background(), todo.ID)
+				if err != nil {
+					return err
+				}
+				_, err = repos.Todos.Update(context.Background(), domain.UpdateTodoParams{
+					ID:       current.ID,
+					Title:    current.Title,
+					Priority: current.Priority,
+					ListID:   current.ListID,
+					Position: current.Position,
+					Version:  current.Version,
+				})
+				return err
+			})
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo.List(context.Background(), domain.TodoQuery{})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	got, _ := repo.GetByID(context.Background(), todo.ID)
+	if got.Version != 1+writers {
+		t.Errorf("expected version %d, got %d", 1+writers, got.Version)
+	}
+}
+
+func TestStore_Do_RollsBackDeletes(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+	todo := createTodo(t, NewTodoRepository(store), "Kept")
+	tag, err := NewTagRepository(store).Create(ctx, "home")
+	if err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+	if err := NewTodoRepository(store).AttachTag(ctx, todo.ID, tag.ID); err != nil {
+		t.Fatalf("failed to attach tag: %v", err)
+	}
+
+	failed := errors.New("failed")
+	err = store.Do(ctx, func(repos domain.Repositories) error {
+		if err := repos.Tags.Delete(ctx, tag.ID); err != nil {
+			return err
+		}
+		if err := repos.Todos.Delete(ctx, todo.ID); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the function's error, got %v", err)
+	}
+
+	got, err := NewTodoRepository(store).GetByID(ctx, todo.ID)
+	if err != nil {
+		t.Fatalf("expected the todo to be restored, got %v", err)
+	}
+	if len(got.Tags) != 1 || got.Tags[0].ID != tag.ID || got.Version != todo.Version+1 {
+		t.Errorf("expected the todo to be back with its tag at version %d, got %+v", todo.Version+1, got)
+	}
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TagRepository implements domain.TagRepository in memory
+type TagRepository struct {
+	conn conn
+}
+
+// NewTagRepository creates a tag repository on store
+func NewTagRepository(store *Store) *TagRepository {
+	return &TagRepository{conn: store}
+}
+
+// List returns all tags ordered by name
+func (r *TagRepository) List(ctx context.Context) ([]domain.Tag, error) {
+	var tags []domain.Tag
+	err := r.conn.read(func(s *state) error {
+		tags = make([]domain.Tag, 0, len(s.tags))
+		for _, t := range s.tags {
+			tags = append(tags, t)
+		}
+		sortTags(tags)
+		return nil
+	})
+	return tags, err
+}
+
+// GetByID returns a tag, or domain.ErrTagNotFound if it does not exist
+func (r *TagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	var tag *domain.Tag
+	err := r.conn.read(func(s *state) error {
+		t, ok := s.tags[id]
+		if !ok {
+			return domain.ErrTagNotFound
+		}
+		tag = &t
+		return nil
+	})
+	return tag, err
+}
+
+// Create creates a tag. It returns domain.ErrTagExists if the name is taken.
+func (r *TagRepository) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	now := r.conn.clock()
+	var tag *domain.Tag
+	err := r.conn.write(func(s *state) error {
+		if s.tagNamed(name, "") {
+			return domain.ErrTagExists
+		}
+		t := domain.Tag{ID: uuid.New().String(), Name: name, CreatedAt: now}
+		s.putTag(t)
+		tag = &t
+		return nil
+	})
+	return tag, err
+}
+
+// Rename changes a tag's name. It returns domain.ErrTagNotFound if the tag
+// does not exist and domain.ErrTagExists if another tag has the name.
+func (r *TagRepository) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	var tag *domain.Tag
+	err := r.conn.write(func(s *state) error {
+		t, ok := s.tags[id]
+		if !ok {
+			return domain.ErrTagNotFound
+		}
+		if s.tagNamed(name, id) {
+			return domain.ErrTagExists
+		}
+		t.Name = name
+		s.putTag(t)
+		tag = &t
+		return nil
+	})
+	return tag, err
+}
+
+// Delete deletes a tag and detaches it from every todo. It returns
+// domain.ErrTagNotFound if the tag does not exist.
+func (r *TagRepository) Delete(ctx context.Context, id string) error {
+	return r.conn.write(func(s *state) error {
+		if _, ok := s.tags[id]; !ok {
+			return domain.ErrTagNotFound
+		}
+		s.deleteTag(id)
+		return nil
+	})
+}
+
+// tagNamed reports whether a tag other than exceptID has the name, compared
+// case-insensitively as the unique key on tags.name does
+func (s *state) tagNamed(name string, exceptID string) bool {
+	for id, t := range s.tags {
+		if id != exceptID && strings.EqualFold(t.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTags orders tags by name, case-insensitively as the column's collation does
+func sortTags(tags []domain.Tag) {
+	sort.Slice(tags, func(i, j int) bool {
+		a, b := strings.ToLower(tags[i].Name), strings.ToLower(tags[j].Name)
+		if a != b {
+			return a < b
+		}
+		return tags[i].ID < tags[j].ID
+	})
+}
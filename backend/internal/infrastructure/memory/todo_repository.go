@@ -0,0 +1,521 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TodoRepository implements domain.TodoRepository in memory
+type TodoRepository struct {
+	conn conn
+}
+
+// NewTodoRepository creates a todo repository on store
+func NewTodoRepository(store *Store) *TodoRepository {
+	return &TodoRepository{conn: store}
+}
+
+// List returns a page of the todos matching the query
+func (r *TodoRepository) List(ctx context.Context, query domain.TodoQuery) ([]domain.Todo, error) {
+	order := query.Sort
+	if !order.IsValid() {
+		order = domain.DefaultSort
+	}
+	now := time.Now()
+
+	var result []domain.Todo
+	err := r.conn.read(func(s *state) error {
+		var todos []domain.Todo
+		for _, t := range s.todos {
+			if t.DeletedAt == nil && query.Filter.Matches(s.withTags(t), now) {
+				todos = append(todos, t)
+			}
+		}
+		sort.Slice(todos, func(i, j int) bool {
+			return order.Compare(todos[i], todos[j]) < 0
+		})
+
+		if c := query.Page.Cursor; c != nil {
+			boundary := c.Todo()
+			var kept []domain.Todo
+			for _, t := range todos {
+				cmp := order.Compare(t, boundary)
+				if (c.Backward && cmp < 0) || (!c.Backward && cmp > 0) {
+					kept = append(kept, t)
+				}
+			}
+			todos = kept
+			// Walking backwards the page ends right before the cursor
+			if c.Backward && query.Page.Limit > 0 && len(todos) > query.Page.Limit {
+				todos = todos[len(todos)-query.Page.Limit:]
+			}
+		}
+		if query.Page.Limit > 0 && len(todos) > query.Page.Limit {
+			todos = todos[:query.Page.Limit]
+		}
+		result = s.views(todos)
+		return nil
+	})
+	return result, err
+}
+
+// GetByID returns a todo, or domain.ErrTodoNotFound if it does not exist or is trashed
+func (r *TodoRepository) GetByID(ctx context.Context, id string) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := r.conn.read(func(s *state) error {
+		t, ok := s.todos[id]
+		if !ok || t.DeletedAt != nil {
+			return domain.ErrTodoNotFound
+		}
+		todo = &s.views([]domain.Todo{t})[0]
+		return nil
+	})
+	return todo, err
+}
+
+// Create inserts a todo under a freshly generated id
+func (r *TodoRepository) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	now := r.conn.clock()
+	var todo *domain.Todo
+	err := r.conn.write(func(s *state) error {
+		if err := s.checkReferences(params.ListID, params.ParentID); err != nil {
+			return err
+		}
+		t := domain.Todo{
+			ID:         uuid.New().String(),
+			Title:      params.Title,
+			DueAt:      storedTime(params.DueAt),
+			Priority:   params.Priority,
+			ListID:     params.ListID,
+			ParentID:   copyString(params.ParentID),
+			Position:   params.Position,
+			Recurrence: copyRecurrence(params.Recurrence),
+			Version:    1,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		s.putTodo(t)
+		todo = &s.views([]domain.Todo{t})[0]
+		return nil
+	})
+	return todo, err
+}
+
+// Update writes every mutable field of a live todo that is still at
+// params.Version, moving it to the next version. It returns
+// domain.ErrTodoNotFound if the todo does not exist or is trashed and
+// domain.ErrVersionMismatch if it is at another version.
+func (r *TodoRepository) Update(ctx context.Context, params domain.UpdateTodoParams) (*domain.Todo, error) {
+	now := r.conn.clock()
+	var todo *domain.Todo
+	err := r.conn.write(func(s *state) error {
+		t, ok := s.todos[params.ID]
+		if !ok || t.DeletedAt != nil {
+			return domain.ErrTodoNotFound
+		}
+		if t.Version != params.Version {
+			return domain.ErrVersionMismatch
+		}
+		if err := s.checkReferences(params.ListID, params.ParentID); err != nil {
+			return err
+		}
+		t.Title = params.Title
+		t.IsCompleted = params.IsCompleted
+		t.DueAt = storedTime(params.DueAt)
+		t.Priority = params.Priority
+		t.ListID = params.ListID
+		t.ParentID = copyString(params.ParentID)
+		t.Position = params.Position
+		t.Recurrence = copyRecurrence(params.Recurrence)
+		t.Version++
+		t.UpdatedAt = now
+		s.putTodo(t)
+		todo = &s.views([]domain.Todo{t})[0]
+		return nil
+	})
+	return todo, err
+}
+
+// Reposition sets the position of a todo without touching UpdatedAt. Like
+// the UPDATE it stands for, it does nothing when the todo does not exist.
+func (r *TodoRepository) Reposition(ctx context.Context, id string, position string) error {
+	return r.conn.write(func(s *state) error {
+		if t, ok := s.todos[id]; ok {
+			t.Position = position
+			t.Version++
+			s.putTodo(t)
+		}
+		return nil
+	})
+}
+
+// Delete permanently deletes a todo, trashed or not, together with its
+// subtasks. It returns domain.ErrTodoNotFound if there is no such todo.
+func (r *TodoRepository) Delete(ctx context.Context, id string) error {
+	return r.conn.write(func(s *state) error {
+		if _, ok := s.todos[id]; !ok {
+			return domain.ErrTodoNotFound
+		}
+		s.deleteTodos(func(t domain.Todo) bool { return t.ID == id })
+		return nil
+	})
+}
+
+// Trash moves the live todos among ids to the trash as the operation trashID
+func (r *TodoRepository) Trash(ctx context.Context, ids []string, trashID string, at time.Time) error {
+	return r.conn.write(func(s *state) error {
+		for _, id := range ids {
+			if t, ok := s.todos[id]; ok && t.DeletedAt == nil {
+				t.DeletedAt = storedTime(&at)
+				t.TrashID = trashID
+				t.Version++
+				s.putTodo(t)
+			}
+		}
+		return nil
+	})
+}
+
+// Restore takes todos out of the trash
+func (r *TodoRepository) Restore(ctx context.Context, ids []string) error {
+	return r.conn.write(func(s *state) error {
+		for _, id := range ids {
+			if t, ok := s.todos[id]; ok {
+				t.DeletedAt = nil
+				t.TrashID = ""
+				t.Version++
+				s.putTodo(t)
+			}
+		}
+		return nil
+	})
+}
+
+// GetTrashed returns a trashed todo, or domain.ErrTodoNotFound if it is not in the trash
+func (r *TodoRepository) GetTrashed(ctx context.Context, id string) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := r.conn.read(func(s *state) error {
+		t, ok := s.todos[id]
+		if !ok || t.DeletedAt == nil {
+			return domain.ErrTodoNotFound
+		}
+		todo = &s.views([]domain.Todo{t})[0]
+		return nil
+	})
+	return todo, err
+}
+
+// TrashedSubtree returns a trashed todo followed by its trashed descendants,
+// shallower levels first and by creation within a level. It returns nothing
+// if the todo is not in the trash.
+func (r *TodoRepository) TrashedSubtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	var result []domain.Todo
+	err := r.conn.read(func(s *state) error {
+		result = s.views(s.subtree(id, true))
+		return nil
+	})
+	return result, err
+}
+
+// ListTrash returns the trashed todos, most recently trashed first
+func (r *TodoRepository) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	var result []domain.Todo
+	err := r.conn.read(func(s *state) error {
+		var todos []domain.Todo
+		for _, t := range s.todos {
+			if t.DeletedAt != nil {
+				todos = append(todos, t)
+			}
+		}
+		sort.Slice(todos, func(i, j int) bool {
+			if c := todos[i].DeletedAt.Compare(*todos[j].DeletedAt); c != 0 {
+				return c > 0
+			}
+			return todos[i].ID < todos[j].ID
+		})
+		result = s.views(todos)
+		return nil
+	})
+	return result, err
+}
+
+// PurgeTrash permanently deletes the todos trashed before cutoff, or the
+// whole trash if cutoff is zero, together with their subtasks, and returns
+// how many trashed todos it deleted
+func (r *TodoRepository) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	purged := 0
+	err := r.conn.write(func(s *state) error {
+		purged = s.deleteTodos(func(t domain.Todo) bool {
+			return t.DeletedAt != nil && (cutoff.IsZero() || t.DeletedAt.Before(cutoff))
+		})
+		return nil
+	})
+	return purged, err
+}
+
+// Subtree returns a live todo followed by its live descendants, shallower
+// levels first and by creation within a level. It returns nothing if the
+// todo does not exist or is trashed.
+func (r *TodoRepository) Subtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	var result []domain.Todo
+	err := r.conn.read(func(s *state) error {
+		result = s.views(s.subtree(id, false))
+		return nil
+	})
+	return result, err
+}
+
+// subtree returns id followed by its descendants, shallower levels first and
+// by creation within a level, keeping to trashed todos or to live ones
+func (s *state) subtree(id string, trashed bool) []domain.Todo {
+	root, ok := s.todos[id]
+	if !ok || (root.DeletedAt != nil) != trashed {
+		return nil
+	}
+	children := make(map[string][]domain.Todo)
+	for _, t := range s.todos {
+		if t.ParentID != nil && (t.DeletedAt != nil) == trashed {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+	}
+	byCreation := domain.TodoSort{Field: domain.SortByCreatedAt, Direction: domain.SortAsc}
+
+	todos := []domain.Todo{root}
+	for level := todos; len(level) > 0; {
+		var next []domain.Todo
+		for _, t := range level {
+			next = append(next, children[t.ID]...)
+		}
+		sort.Slice(next, func(i, j int) bool {
+			return byCreation.Compare(next[i], next[j]) < 0
+		})
+		todos = append(todos, next...)
+		level = next
+	}
+	return todos
+}
+
+// Search returns the live todos whose title matches the search,
+// case-insensitively and newest first
+func (r *TodoRepository) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	terms := search.Terms()
+	for i, term := range terms {
+		terms[i] = strings.ToLower(term)
+	}
+
+	var result []domain.Todo
+	err := r.conn.read(func(s *state) error {
+		var todos []domain.Todo
+		for _, t := range s.todos {
+			if t.DeletedAt == nil && titleMatches(strings.ToLower(t.Title), terms, search.Mode) {
+				todos = append(todos, t)
+			}
+		}
+		newest := domain.TodoSort{Field: domain.SortByCreatedAt, Direction: domain.SortDesc}
+		sort.Slice(todos, func(i, j int) bool {
+			return newest.Compare(todos[i], todos[j]) < 0
+		})
+		result = s.views(todos)
+		return nil
+	})
+	return result, err
+}
+
+// titleMatches reports whether a lower-cased title matches every lower-cased term
+func titleMatches(title string, terms []string, mode domain.SearchMode) bool {
+	if len(terms) == 0 {
+		return false
+	}
+	for _, term := range terms {
+		if mode == domain.SearchModePrefix {
+			if !strings.HasPrefix(title, term) {
+				return false
+			}
+		} else if !strings.Contains(title, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// CountByPriority returns the number of live todos matching the filter per
+// priority. Priorities without todos are absent from the result.
+func (r *TodoRepository) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	now := time.Now()
+	counts := make(map[domain.Priority]int)
+	err := r.conn.read(func(s *state) error {
+		for _, t := range s.todos {
+			if t.DeletedAt == nil && filter.Matches(s.withTags(t), now) {
+				counts[t.Priority]++
+			}
+		}
+		return nil
+	})
+	return counts, err
+}
+
+// AttachTag labels a todo with a tag, moving the todo to a new version
+// unless the tag was already attached. It returns domain.ErrTagNotFound if
+// the tag does not exist.
+func (r *TodoRepository) AttachTag(ctx context.Context, todoID string, tagID string) error {
+	return r.conn.write(func(s *state) error {
+		if _, ok := s.tags[tagID]; !ok {
+			return domain.ErrTagNotFound
+		}
+		t, ok := s.todos[todoID]
+		if !ok {
+			return domain.ErrTodoNotFound
+		}
+		if s.todoTags[todoID][tagID] {
+			return nil
+		}
+		s.attachTag(todoID, tagID)
+		t.Version++
+		s.putTodo(t)
+		return nil
+	})
+}
+
+// DetachTag removes a tag from a todo, moving the todo to a new version if
+// the tag was attached
+func (r *TodoRepository) DetachTag(ctx context.Context, todoID string, tagID string) error {
+	return r.conn.write(func(s *state) error {
+		if !s.todoTags[todoID][tagID] {
+			return nil
+		}
+		s.detachTag(todoID, tagID)
+		t := s.todos[todoID]
+		t.Version++
+		s.putTodo(t)
+		return nil
+	})
+}
+
+// checkReferences fails like the foreign keys of todos do: list_id must name
+// a list and parent_id, if set, any todo, trashed or not
+func (s *state) checkReferences(listID string, parentID *string) error {
+	if _, ok := s.lists[listID]; !ok {
+		return domain.ErrInvalidListID
+	}
+	if parentID != nil {
+		if _, ok := s.todos[*parentID]; !ok {
+			return domain.ErrParentNotFound
+		}
+	}
+	return nil
+}
+
+// deleteTodos deletes the todos matching match together with their
+// descendants and tag links, as the cascading foreign keys do, and returns
+// how many todos matched
+func (s *state) deleteTodos(match func(t domain.Todo) bool) int {
+	var queue []string
+	for id, t := range s.todos {
+		if match(t) {
+			queue = append(queue, id)
+		}
+	}
+	matched := len(queue)
+
+	doomed := make(map[string]bool)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if doomed[id] {
+			continue
+		}
+		doomed[id] = true
+		for childID, t := range s.todos {
+			if t.ParentID != nil && *t.ParentID == id {
+				queue = append(queue, childID)
+			}
+		}
+	}
+	for id := range doomed {
+		s.deleteTodo(id)
+	}
+	return matched
+}
+
+// withTags returns a stored todo with its tags, which filters need
+func (s *state) withTags(t domain.Todo) domain.Todo {
+	t.Tags = s.tagsOf(t.ID)
+	return t
+}
+
+// tagsOf returns the tags attached to a todo, ordered by name
+func (s *state) tagsOf(todoID string) []domain.Tag {
+	tags := make([]domain.Tag, 0, len(s.todoTags[todoID]))
+	for tagID := range s.todoTags[todoID] {
+		tags = append(tags, s.tags[tagID])
+	}
+	sortTags(tags)
+	return tags
+}
+
+// views fills in the tags and subtask progress of stored todos, as the
+// repositories return them
+func (s *state) views(todos []domain.Todo) []domain.Todo {
+	var progress map[string]domain.SubtaskProgress
+	result := make([]domain.Todo, len(todos))
+	for i, t := range todos {
+		if progress == nil {
+			progress = s.subtaskProgress()
+		}
+		t.Tags = s.tagsOf(t.ID)
+		t.Subtasks = progress[t.ID]
+		result[i] = t
+	}
+	return result
+}
+
+// subtaskProgress counts the live direct subtasks of every todo, keyed by parent
+func (s *state) subtaskProgress() map[string]domain.SubtaskProgress {
+	progress := make(map[string]domain.SubtaskProgress)
+	for _, t := range s.todos {
+		if t.ParentID == nil || t.DeletedAt != nil {
+			continue
+		}
+		p := progress[*t.ParentID]
+		p.Total++
+		if t.IsCompleted {
+			p.Completed++
+		}
+		progress[*t.ParentID] = p
+	}
+	return progress
+}
+
+// storedTime returns a copy of an optional time as a TIMESTAMP column holds
+// it: in UTC, rounded to the second
+func storedTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := t.UTC().Round(time.Second)
+	return &stored
+}
+
+// copyString returns a copy of an optional string, so the caller's variable
+// cannot change the stored todo
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	return &copied
+}
+
+// copyRecurrence returns a copy of an optional recurrence rule
+func copyRecurrence(r *domain.Recurrence) *domain.Recurrence {
+	if r == nil {
+		return nil
+	}
+	copied := *r
+	return &copied
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTodoRepository_Delete_Cascades(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	repo := NewTodoRepository(store)
+	parent := createTodo(t, repo, "Parent")
+	child, err := repo.Create(ctx, domain.CreateTodoParams{Title: "Child", ListID: domain.DefaultListID, ParentID: &parent.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := NewTagRepository(store).Create(ctx, "work")
+	if err := repo.AttachTag(ctx, child.ID, tag.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := repo.GetByID(ctx, parent.ID)
+	if got.Subtasks.Total != 1 {
+		t.Errorf("expected one subtask, got %d", got.Subtasks.Total)
+	}
+	if err := repo.Delete(ctx, parent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(ctx, child.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected the subtask to be deleted, got %v", err)
+	}
+	if len(store.state.todoTags) != 0 {
+		t.Errorf("expected the subtask's tags to be detached, got %v", store.state.todoTags)
+	}
+}
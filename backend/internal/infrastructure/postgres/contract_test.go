@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"backend/internal/infrastructure/repotest"
+	"testing"
+)
+
+func TestTodoRepository_Contract(t *testing.T) {
+	repotest.TestTodoRepository(t, func(t *testing.T) domain.Repositories {
+		pool := openTestDB(t)
+		return domain.Repositories{
+			Todos:      NewTodoRepository(pool),
+			Tags:       NewTagRepository(pool),
+			Lists:      NewListRepository(pool),
+			UnitOfWork: NewUnitOfWork(pool),
+		}
+	})
+}
+
+func TestIdempotencyRepository_Contract(t *testing.T) {
+	repotest.TestIdempotencyRepository(t, func(t *testing.T) domain.IdempotencyRepository {
+		return NewIdempotencyRepository(openTestDB(t))
+	})
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository implements domain.IdempotencyRepository on PostgreSQL
+type IdempotencyRepository struct {
+	queries *Queries
+}
+
+// NewIdempotencyRepository creates an idempotency repository on pool
+func NewIdempotencyRepository(pool *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{queries: New(pool)}
+}
+
+// Claim inserts an in-flight row for record.Key. If the key is taken it
+// returns the record holding it and false; a row that expired by now is
+// deleted and the insert tried again. The primary key makes concurrent claims
+// of one key safe: exactly one insert succeeds.
+func (r *IdempotencyRepository) Claim(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, bool, error) {
+	params := ClaimIdempotencyKeyParams{
+		IdempotencyKey: record.Key,
+		RequestHash:    record.RequestHash,
+		ClaimToken:     record.Token,
+		ExpiresAt:      record.ExpiresAt.UTC(),
+	}
+	// A second attempt follows a deleted or vanished row; if the key is taken
+	// again by then, a concurrent request claimed it first
+	for attempt := 0; attempt < 2; attempt++ {
+		err := r.queries.ClaimIdempotencyKey(ctx, params)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !isPgError(err, pgErrUniqueViolation) {
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		existing, err := r.queries.GetIdempotencyKey(ctx, params.IdempotencyKey)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Released between the insert and the read
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		if existing.ExpiresAt.After(now) {
+			held, err := toDomainIdempotencyRecord(existing)
+			if err != nil {
+				return nil, false, err
+			}
+			return held, false, nil
+		}
+		err = r.queries.DeleteExpiredIdempotencyKey(ctx, DeleteExpiredIdempotencyKeyParams{IdempotencyKey: params.IdempotencyKey, ExpiresAt: now.UTC()})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+		}
+	}
+	return nil, false, domain.ErrIdempotencyKeyInFlight
+}
+
+// Complete stores the response of an in-flight claim. It fails if the claim
+// was released or has expired and been taken over in the meantime.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, token string, response domain.StoredResponse, expiresAt time.Time) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode response header: %w", err)
+	}
+	body := response.Body
+	if body == nil {
+		// A NULL body would read back as a response still in flight
+		body = []byte{}
+	}
+	status := int16(response.Status)
+	tag, err := r.queries.CompleteIdempotencyKey(ctx, CompleteIdempotencyKeyParams{
+		StatusCode:     &status,
+		ResponseHeader: header,
+		ResponseBody:   body,
+		ExpiresAt:      expiresAt.UTC(),
+		IdempotencyKey: key,
+		ClaimToken:     token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return requireAffected(tag, fmt.Errorf("idempotency key %q is no longer claimed", key))
+}
+
+// Release deletes a claim whose request has not completed, unless another
+// claim has taken its place
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, token string) error {
+	if err := r.queries.ReleaseIdempotencyKey(ctx, ReleaseIdempotencyKeyParams{IdempotencyKey: key, ClaimToken: token}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes every row that expired by now and returns how many were deleted
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	tag, err := r.queries.PurgeIdempotencyKeys(ctx, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// toDomainIdempotencyRecord converts an IdempotencyKey to domain.IdempotencyRecord
+func toDomainIdempotencyRecord(k IdempotencyKey) (*domain.IdempotencyRecord, error) {
+	record := &domain.IdempotencyRecord{
+		Key:         k.IdempotencyKey,
+		RequestHash: k.RequestHash,
+		Token:       k.ClaimToken,
+		ExpiresAt:   k.ExpiresAt.UTC(),
+	}
+	if k.StatusCode == nil {
+		return record, nil
+	}
+	response := &domain.StoredResponse{Status: int(*k.StatusCode), Body: k.ResponseBody}
+	if k.ResponseHeader != nil {
+		if err := json.Unmarshal(k.ResponseHeader, &response.Header); err != nil {
+			return nil, fmt.Errorf("failed to decode response header: %w", err)
+		}
+	}
+	record.Response = response
+	return record, nil
+}
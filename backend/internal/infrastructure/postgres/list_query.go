@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// todoColumns is the column list scanned by queryTodos, in the order it scans them
+const todoColumns = "id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id"
+
+// nullDueAtKey stands in for a NULL due_at in sort keys. Rows without a due
+// date are grouped after dated ones by a separate "due_at IS NULL" key, so the
+// sentinel only has to be a constant; nullDueAt is the same instant in Go.
+const nullDueAtKey = "'1970-01-02 00:00:00+00'::timestamptz"
+
+var nullDueAt = time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// titleKey sorts titles case-insensitively like the domain's sort: lowered
+// under the database's collation, then compared byte by byte
+const titleKey = `lower(title) COLLATE "C"`
+
+// listQuery is a SELECT over todos built from a domain.TodoQuery.
+// Only expressions from the fixed whitelists below are interpolated into the
+// SQL text; every user-supplied value is bound as a numbered parameter.
+type listQuery struct {
+	sql      string
+	args     []interface{}
+	backward bool
+}
+
+// queryArgs collects the arguments of a query as its parameters are written
+type queryArgs []interface{}
+
+// bind appends v and returns the parameter that refers to it
+func (a *queryArgs) bind(v interface{}) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// sortKey is one ORDER BY expression together with the cursor's value for it.
+// param, if set, is a format wrapping the value's parameter so that it
+// compares like expr.
+type sortKey struct {
+	expr  string
+	desc  bool
+	value interface{}
+	param string
+}
+
+// bindValue binds the cursor's value for k and returns the expression to compare expr with
+func (k sortKey) bindValue(args *queryArgs) string {
+	p := args.bind(k.value)
+	if k.param == "" {
+		return p
+	}
+	return fmt.Sprintf(k.param, p)
+}
+
+// sortKeys maps a whitelisted sort to its ORDER BY expressions, ending with id
+func sortKeys(sort domain.TodoSort, cursor *domain.Cursor) []sortKey {
+	desc := sort.Direction == domain.SortDesc
+	var c domain.Todo
+	if cursor != nil {
+		c = cursor.Todo()
+	}
+
+	var keys []sortKey
+	switch sort.Field {
+	case domain.SortByUpdatedAt:
+		keys = append(keys, sortKey{expr: "updated_at", desc: desc, value: c.UpdatedAt.UTC()})
+	case domain.SortByDueAt:
+		keys = append(keys, dueAtKeys(c.DueAt, desc)...)
+	case domain.SortByPriority:
+		// Within a priority the soonest due date comes first, whatever the direction
+		keys = append(keys, sortKey{expr: "priority", desc: desc, value: int16(c.Priority)})
+		keys = append(keys, dueAtKeys(c.DueAt, false)...)
+	case domain.SortByTitle:
+		keys = append(keys, sortKey{expr: titleKey, desc: desc, value: c.Title, param: `lower(%s) COLLATE "C"`})
+	case domain.SortByPosition:
+		keys = append(keys, sortKey{expr: "position", desc: desc, value: c.Position})
+	default:
+		keys = append(keys, sortKey{expr: "created_at", desc: desc, value: c.CreatedAt.UTC()})
+	}
+	// A cursor forged with an ID that is not a UUID restarts from the first one
+	id, _ := parseID(c.ID)
+	return append(keys, sortKey{expr: "id", desc: desc, value: id})
+}
+
+// dueAtKeys orders by due date with undated todos always last
+func dueAtKeys(dueAt *time.Time, desc bool) []sortKey {
+	due := nullDueAt
+	if dueAt != nil {
+		due = dueAt.UTC()
+	}
+	return []sortKey{
+		{expr: "(due_at IS NULL)", desc: false, value: dueAt == nil},
+		{expr: "COALESCE(due_at, " + nullDueAtKey + ")", desc: desc, value: due},
+	}
+}
+
+// filterConditions translates a domain.TodoFilter into WHERE conditions,
+// binding their arguments to args
+func filterConditions(f domain.TodoFilter, now time.Time, args *queryArgs) []string {
+	var conditions []string
+	add := func(cond string) {
+		conditions = append(conditions, cond)
+	}
+
+	// Trashed todos only show up in the trash
+	add("deleted_at IS NULL")
+	if f.ListID != "" {
+		if id, ok := parseID(f.ListID); ok {
+			add("list_id = " + args.bind(id))
+		} else {
+			add("FALSE")
+		}
+	}
+	switch f.Status {
+	case domain.StatusActive:
+		add("is_completed = FALSE")
+	case domain.StatusCompleted:
+		add("is_completed = TRUE")
+	}
+	if f.CreatedAfter != nil {
+		add("created_at >= " + args.bind(f.CreatedAfter.UTC()))
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < " + args.bind(f.CreatedBefore.UTC()))
+	}
+	if f.UpdatedAfter != nil {
+		add("updated_at >= " + args.bind(f.UpdatedAfter.UTC()))
+	}
+	if f.UpdatedBefore != nil {
+		add("updated_at < " + args.bind(f.UpdatedBefore.UTC()))
+	}
+	if f.DueAfter != nil {
+		add("due_at >= " + args.bind(f.DueAfter.UTC()))
+	}
+	if f.DueBefore != nil {
+		add("due_at < " + args.bind(f.DueBefore.UTC()))
+	}
+	if f.Overdue {
+		add("(is_completed = FALSE AND due_at < " + args.bind(now.UTC()) + ")")
+	}
+	if len(f.Tags) > 0 {
+		// Tag names are unique regardless of case, so they are matched that way
+		subquery := `id IN (SELECT todo_tags.todo_id
+    FROM todo_tags
+    JOIN tags ON tags.id = todo_tags.tag_id
+    WHERE lower(tags.name) IN (SELECT lower(name) FROM unnest(` + args.bind(f.Tags) + `::text[]) AS name)`
+		if f.TagMatch == domain.TagMatchAll {
+			// Every name matched means one tag per name
+			add(subquery + `
+    GROUP BY todo_tags.todo_id
+    HAVING COUNT(DISTINCT tags.id) = ` + args.bind(len(f.Tags)) + ")")
+		} else {
+			add(subquery + ")")
+		}
+	}
+	return conditions
+}
+
+// whereClause joins conditions into a WHERE clause, or returns "" if there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "\nWHERE " + strings.Join(conditions, "\n  AND ")
+}
+
+// newListQuery translates q into SQL. now is used for the overdue filter.
+func newListQuery(q domain.TodoQuery, now time.Time) listQuery {
+	var args queryArgs
+	conditions := filterConditions(q.Filter, now, &args)
+
+	cursor := q.Page.Cursor
+	backward := cursor != nil && cursor.Backward
+	keys := sortKeys(q.Sort, cursor)
+
+	// Keyset predicate: (k1, k2, ...) strictly beyond the cursor, expanded
+	// into OR-ed prefixes because the keys may run in different directions
+	if cursor != nil {
+		var alternatives []string
+		for i, key := range keys {
+			var parts []string
+			for _, prev := range keys[:i] {
+				parts = append(parts, prev.expr+" = "+prev.bindValue(&args))
+			}
+			op := ">"
+			if key.desc != backward {
+				op = "<"
+			}
+			parts = append(parts, key.expr+" "+op+" "+key.bindValue(&args))
+			alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		// Walking backwards reads the list in reverse; the caller flips it back
+		if key.desc != backward {
+			order[i] = key.expr + " DESC"
+		} else {
+			order[i] = key.expr + " ASC"
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT " + todoColumns + "\nFROM todos")
+	sb.WriteString(whereClause(conditions))
+	sb.WriteString("\nORDER BY " + strings.Join(order, ", "))
+	if q.Page.Limit > 0 {
+		sb.WriteString("\nLIMIT " + args.bind(q.Page.Limit))
+	}
+
+	return listQuery{sql: sb.String(), args: args, backward: backward}
+}
+
+// newCountByPriorityQuery counts the todos matching f per priority
+func newCountByPriorityQuery(f domain.TodoFilter, now time.Time) (string, []interface{}) {
+	var args queryArgs
+	conditions := filterConditions(f, now, &args)
+	return "SELECT priority, COUNT(*)\nFROM todos" + whereClause(conditions) + "\nGROUP BY priority", args
+}
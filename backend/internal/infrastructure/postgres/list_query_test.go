@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewListQuery_NumbersParameters(t *testing.T) {
+	since := time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	q := newListQuery(domain.TodoQuery{
+		Filter: domain.TodoFilter{ListID: domain.DefaultListID, Status: domain.StatusCompleted, UpdatedAfter: &since},
+		Sort:   domain.TodoSort{Field: domain.SortByUpdatedAt, Direction: domain.SortAsc},
+		Page:   domain.PageRequest{Limit: 10},
+	}, time.Now())
+
+	want := "SELECT " + todoColumns + `
+FROM todos
+WHERE deleted_at IS NULL
+  AND list_id = $1
+  AND is_completed = TRUE
+  AND updated_at >= $2
+ORDER BY updated_at ASC, id ASC
+LIMIT $3`
+	if q.sql != want {
+		t.Errorf("unexpected SQL:\n%s\nwant:\n%s", q.sql, want)
+	}
+	if len(q.args) != 3 || q.args[0] != uuid.Nil || !q.args[1].(time.Time).Equal(since) || q.args[1].(time.Time).Location() != time.UTC || q.args[2] != 10 {
+		t.Errorf("unexpected args: %v", q.args)
+	}
+}
+
+func TestNewListQuery_ListThatIsNotAUUID(t *testing.T) {
+	q := newListQuery(domain.TodoQuery{Filter: domain.TodoFilter{ListID: "missing"}, Sort: domain.DefaultSort}, time.Now())
+	if !strings.Contains(q.sql, "AND FALSE") || len(q.args) != 0 {
+		t.Errorf("expected the query to match nothing, got:\n%s\n%v", q.sql, q.args)
+	}
+}
+
+func TestNewListQuery_TitleCursor(t *testing.T) {
+	id := uuid.New()
+	title := "x' OR 1=1 --"
+	cursor := domain.NewCursor(domain.Todo{ID: id.String(), Title: title}, domain.TodoSort{Field: domain.SortByTitle, Direction: domain.SortAsc}, false)
+	q := newListQuery(domain.TodoQuery{
+		Sort: cursor.Sort,
+		Page: domain.PageRequest{Cursor: cursor, Limit: 5},
+	}, time.Now())
+
+	if strings.Contains(q.sql, title) {
+		t.Fatalf("cursor value leaked into SQL: %s", q.sql)
+	}
+	want := `AND ((lower(title) COLLATE "C" > lower($1) COLLATE "C") OR (lower(title) COLLATE "C" = lower($2) COLLATE "C" AND id > $3))`
+	if !strings.Contains(q.sql, want) {
+		t.Errorf("unexpected keyset predicate:\n%s\nwant:\n%s", q.sql, want)
+	}
+	if len(q.args) != 4 || q.args[0] != title || q.args[1] != title || q.args[2] != id || q.args[3] != 5 {
+		t.Errorf("unexpected args: %v", q.args)
+	}
+}
+
+func TestNewListQuery_TagsMatchAll(t *testing.T) {
+	q := newListQuery(domain.TodoQuery{
+		Filter: domain.TodoFilter{Tags: []string{"work", "Home"}, TagMatch: domain.TagMatchAll},
+		Sort:   domain.DefaultSort,
+	}, time.Now())
+
+	if !strings.Contains(q.sql, "unnest($1::text[])") || !strings.Contains(q.sql, "HAVING COUNT(DISTINCT tags.id) = $2)") {
+		t.Errorf("unexpected tag filter:\n%s", q.sql)
+	}
+	if len(q.args) != 2 || q.args[1] != 2 {
+		t.Errorf("unexpected args: %v", q.args)
+	}
+}
+
+func TestNewCountByPriorityQuery(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	query, args := newCountByPriorityQuery(domain.TodoFilter{Overdue: true}, now)
+
+	want := `SELECT priority, COUNT(*)
+FROM todos
+WHERE deleted_at IS NULL
+  AND (is_completed = FALSE AND due_at < $1)
+GROUP BY priority`
+	if query != want {
+		t.Errorf("unexpected SQL:\n%s\nwant:\n%s", query, want)
+	}
+	if len(args) != 1 || args[0] != now {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
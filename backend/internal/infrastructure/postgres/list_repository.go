@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ListRepository implements domain.ListRepository on PostgreSQL
+type ListRepository struct {
+	queries *Queries
+}
+
+// NewListRepository creates a list repository on pool
+func NewListRepository(pool *pgxpool.Pool) *ListRepository {
+	return &ListRepository{queries: New(pool)}
+}
+
+// List returns every list with its todo counts, oldest first
+func (r *ListRepository) List(ctx context.Context) ([]domain.List, error) {
+	rows, err := r.queries.ListLists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+	lists := make([]domain.List, len(rows))
+	for i, row := range rows {
+		lists[i] = toDomainList(GetListRow(row))
+	}
+	return lists, nil
+}
+
+// GetByID returns a list with its todo counts, or domain.ErrListNotFound if it does not exist
+func (r *ListRepository) GetByID(ctx context.Context, id string) (*domain.List, error) {
+	listID, ok := parseID(id)
+	if !ok {
+		return nil, domain.ErrListNotFound
+	}
+	row, err := r.queries.GetList(ctx, listID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrListNotFound
+		}
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+	list := toDomainList(row)
+	return &list, nil
+}
+
+// Create creates a new, empty list
+func (r *ListRepository) Create(ctx context.Context, name string) (*domain.List, error) {
+	id := uuid.New()
+	if err := r.queries.CreateList(ctx, CreateListParams{ID: id, Name: name}); err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+	return r.GetByID(ctx, id.String())
+}
+
+// Rename changes a list's name. It returns domain.ErrListNotFound if the list does not exist.
+func (r *ListRepository) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	listID, ok := parseID(id)
+	if !ok {
+		return nil, domain.ErrListNotFound
+	}
+	tag, err := r.queries.RenameList(ctx, RenameListParams{ID: listID, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename list: %w", err)
+	}
+	if err := requireAffected(tag, domain.ErrListNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a list that holds no todos, trashed ones included. It
+// returns domain.ErrListNotFound if the list does not exist and
+// domain.ErrListNotEmpty if it still has todos.
+func (r *ListRepository) Delete(ctx context.Context, id string) error {
+	listID, ok := parseID(id)
+	if !ok {
+		return domain.ErrListNotFound
+	}
+	tag, err := r.queries.DeleteList(ctx, listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	if err := requireAffected(tag, domain.ErrListNotFound); err != nil {
+		// Nothing was deleted: either the list is missing or it has todos
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return getErr
+		}
+		return domain.ErrListNotEmpty
+	}
+	return nil
+}
+
+// toDomainList converts a list row with counts to domain.List
+func toDomainList(row GetListRow) domain.List {
+	id := row.ID.String()
+	return domain.List{
+		ID:             id,
+		Name:           row.Name,
+		IsDefault:      id == domain.DefaultListID,
+		TodoCount:      int(row.TodoCount),
+		CompletedCount: int(row.CompletedCount),
+		CreatedAt:      row.CreatedAt.UTC(),
+		UpdatedAt:      row.UpdatedAt.UTC(),
+	}
+}
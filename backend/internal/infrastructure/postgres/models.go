@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyKey struct {
+	IdempotencyKey string    `json:"idempotency_key"`
+	RequestHash    string    `json:"request_hash"`
+	StatusCode     *int16    `json:"status_code"`
+	ResponseHeader []byte    `json:"response_header"`
+	ResponseBody   []byte    `json:"response_body"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	ClaimToken     string    `json:"claim_token"`
+}
+
+type List struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Todo struct {
+	ID             uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	IsCompleted    bool       `json:"is_completed"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DueAt          *time.Time `json:"due_at"`
+	Priority       int16      `json:"priority"`
+	ListID         uuid.UUID  `json:"list_id"`
+	ParentID       *uuid.UUID `json:"parent_id"`
+	Position       string     `json:"position"`
+	RecurrenceRule *string    `json:"recurrence_rule"`
+	RecurrenceTz   *string    `json:"recurrence_tz"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+	Version        int32      `json:"version"`
+	TrashID        string     `json:"trash_id"`
+}
+
+type TodoTag struct {
+	TodoID uuid.UUID `json:"todo_id"`
+	TagID  uuid.UUID `json:"tag_id"`
+}
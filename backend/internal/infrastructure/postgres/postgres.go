@@ -0,0 +1,142 @@
+// Package postgres implements the repositories on PostgreSQL through pgx.
+// Its queries are generated by sqlc from db/postgres/queries.sql against the
+// schema in migrations/postgres, which Migrate applies at startup. IDs are
+// native UUIDs; the domain's string IDs are parsed on the way in, and one
+// that is not a UUID names nothing.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// PostgreSQL error codes mapped to domain errors
+const (
+	pgErrUniqueViolation      = "23505"
+	pgErrForeignKeyViolation  = "23503"
+	pgErrSerializationFailure = "40001"
+	pgErrDeadlockDetected     = "40P01"
+)
+
+// Constraints whose violations are told apart, as named by the migrations
+const (
+	constraintTodosList  = "fk_todos_list"
+	constraintTodoTagTag = "fk_todo_tags_tag"
+)
+
+// migrationLockID keys the advisory lock that keeps instances starting
+// together from applying the same migration twice
+const migrationLockID = 0x746f646f
+
+// ConnectDB opens a connection pool on the database at url, a postgres://
+// URL or key=value connection string, and checks that it is reachable
+func ConnectDB(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return pool, nil
+}
+
+// Migrate applies the goose migrations in fsys that the database has not
+// seen yet, each in its own transaction and in version order. It holds an
+// advisory lock while it runs so concurrent callers take turns.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) error {
+	locker, err := lock.NewPostgresSessionLocker(lock.WithLockID(migrationLockID))
+	if err != nil {
+		return fmt.Errorf("failed to create migration lock: %w", err)
+	}
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// asPgError returns the PostgreSQL error err wraps if it has the given code
+func asPgError(err error, code string) (*pgconn.PgError, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != code {
+		return nil, false
+	}
+	return pgErr, true
+}
+
+// isPgError reports whether err wraps a PostgreSQL error with the given code
+func isPgError(err error, code string) bool {
+	_, ok := asPgError(err, code)
+	return ok
+}
+
+// parseID parses a domain ID. IDs that are not UUIDs cannot name a row, so
+// callers treat them as not found.
+func parseID(id string) (uuid.UUID, bool) {
+	parsed, err := uuid.Parse(id)
+	return parsed, err == nil
+}
+
+// parseIDs parses domain IDs, dropping the ones that are not UUIDs
+func parseIDs(ids []string) []uuid.UUID {
+	parsed := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if u, ok := parseID(id); ok {
+			parsed = append(parsed, u)
+		}
+	}
+	return parsed
+}
+
+// storedTime converts a time to the value a TIMESTAMPTZ(0) column holds: UTC
+// rounded to the second, as MySQL's TIMESTAMP columns store it
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Round(time.Second)
+}
+
+// toStoredTime converts an optional time to the value it is stored as
+func toStoredTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := storedTime(*t)
+	return &stored
+}
+
+// fromStoredTime converts an optional time read back, which pgx returns in
+// the local time zone, to UTC
+func fromStoredTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// requireAffected returns notFound if a statement matched no rows
+func requireAffected(tag pgconn.CommandTag, notFound error) error {
+	if tag.RowsAffected() == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Querier interface {
+	AttachTag(ctx context.Context, arg AttachTagParams) (pgconn.CommandTag, error)
+	// Tags are part of a todo, so changing them moves it to a new version
+	BumpTodoVersion(ctx context.Context, id uuid.UUID) error
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) error
+	// Only the claim that is still in flight under the same token is completed
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (pgconn.CommandTag, error)
+	CountSubtasks(ctx context.Context, parentIds []uuid.UUID) ([]CountSubtasksRow, error)
+	CreateList(ctx context.Context, arg CreateListParams) error
+	CreateTag(ctx context.Context, arg CreateTagParams) error
+	CreateTodo(ctx context.Context, arg CreateTodoParams) error
+	DeleteExpiredIdempotencyKey(ctx context.Context, arg DeleteExpiredIdempotencyKeyParams) error
+	// A list is deleted only while it holds no todos, trashed ones included, so
+	// that deleting a list never bypasses the trash
+	DeleteList(ctx context.Context, id uuid.UUID) (pgconn.CommandTag, error)
+	DeleteTag(ctx context.Context, id uuid.UUID) (pgconn.CommandTag, error)
+	DeleteTodo(ctx context.Context, id uuid.UUID) (pgconn.CommandTag, error)
+	DetachTag(ctx context.Context, arg DetachTagParams) (pgconn.CommandTag, error)
+	GetIdempotencyKey(ctx context.Context, idempotencyKey string) (IdempotencyKey, error)
+	GetList(ctx context.Context, id uuid.UUID) (GetListRow, error)
+	GetTag(ctx context.Context, id uuid.UUID) (Tag, error)
+	GetTodo(ctx context.Context, id uuid.UUID) (Todo, error)
+	// ILIKE ignores case as MySQL's collation does; backslash is LIKE's default escape
+	GetTodoByTitle(ctx context.Context, title string) ([]Todo, error)
+	GetTrashedTodo(ctx context.Context, id uuid.UUID) (Todo, error)
+	ListLists(ctx context.Context) ([]ListListsRow, error)
+	ListTags(ctx context.Context) ([]Tag, error)
+	ListTagsForTodos(ctx context.Context, todoIds []uuid.UUID) ([]ListTagsForTodosRow, error)
+	ListTrash(ctx context.Context) ([]Todo, error)
+	PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) (pgconn.CommandTag, error)
+	PurgeTrash(ctx context.Context) (pgconn.CommandTag, error)
+	PurgeTrashBefore(ctx context.Context, deletedAt *time.Time) (pgconn.CommandTag, error)
+	// A claim that lapsed and was taken over has another token and is kept
+	ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error
+	RenameList(ctx context.Context, arg RenameListParams) (pgconn.CommandTag, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) (pgconn.CommandTag, error)
+	RestoreTodos(ctx context.Context, ids []uuid.UUID) error
+	TrashTodos(ctx context.Context, arg TrashTodosParams) error
+	// The version check makes the update fail, rather than overwrite, when the
+	// todo changed since it was read
+	UpdateTodo(ctx context.Context, arg UpdateTodoParams) (pgconn.CommandTag, error)
+	// Reordering is not an edit, so it leaves out the columns the updated_at
+	// trigger watches
+	UpdateTodoPosition(ctx context.Context, arg UpdateTodoPositionParams) error
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,731 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: queries.sql
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const attachTag = `-- name: AttachTag :execresult
+INSERT INTO todo_tags (todo_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AttachTagParams struct {
+	TodoID uuid.UUID `json:"todo_id"`
+	TagID  uuid.UUID `json:"tag_id"`
+}
+
+func (q *Queries) AttachTag(ctx context.Context, arg AttachTagParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, attachTag, arg.TodoID, arg.TagID)
+}
+
+const bumpTodoVersion = `-- name: BumpTodoVersion :exec
+UPDATE todos
+SET version = version + 1
+WHERE id = $1
+`
+
+// Tags are part of a todo, so changing them moves it to a new version
+func (q *Queries) BumpTodoVersion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, bumpTodoVersion, id)
+	return err
+}
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :exec
+INSERT INTO idempotency_keys (idempotency_key, request_hash, claim_token, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type ClaimIdempotencyKeyParams struct {
+	IdempotencyKey string    `json:"idempotency_key"`
+	RequestHash    string    `json:"request_hash"`
+	ClaimToken     string    `json:"claim_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, claimIdempotencyKey,
+		arg.IdempotencyKey,
+		arg.RequestHash,
+		arg.ClaimToken,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :execresult
+UPDATE idempotency_keys
+SET status_code = $1, response_header = $2, response_body = $3, expires_at = $4
+WHERE idempotency_key = $5 AND claim_token = $6 AND status_code IS NULL
+`
+
+type CompleteIdempotencyKeyParams struct {
+	StatusCode     *int16    `json:"status_code"`
+	ResponseHeader []byte    `json:"response_header"`
+	ResponseBody   []byte    `json:"response_body"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	ClaimToken     string    `json:"claim_token"`
+}
+
+// Only the claim that is still in flight under the same token is completed
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, completeIdempotencyKey,
+		arg.StatusCode,
+		arg.ResponseHeader,
+		arg.ResponseBody,
+		arg.ExpiresAt,
+		arg.IdempotencyKey,
+		arg.ClaimToken,
+	)
+}
+
+const countSubtasks = `-- name: CountSubtasks :many
+SELECT parent_id::uuid AS parent_id,
+    COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE is_completed) AS completed
+FROM todos
+WHERE parent_id = ANY($1::uuid[]) AND deleted_at IS NULL
+GROUP BY parent_id
+`
+
+type CountSubtasksRow struct {
+	ParentID  uuid.UUID `json:"parent_id"`
+	Total     int64     `json:"total"`
+	Completed int64     `json:"completed"`
+}
+
+func (q *Queries) CountSubtasks(ctx context.Context, parentIds []uuid.UUID) ([]CountSubtasksRow, error) {
+	rows, err := q.db.Query(ctx, countSubtasks, parentIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSubtasksRow
+	for rows.Next() {
+		var i CountSubtasksRow
+		if err := rows.Scan(&i.ParentID, &i.Total, &i.Completed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createList = `-- name: CreateList :exec
+INSERT INTO lists (id, name)
+VALUES ($1, $2)
+`
+
+type CreateListParams struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+func (q *Queries) CreateList(ctx context.Context, arg CreateListParams) error {
+	_, err := q.db.Exec(ctx, createList, arg.ID, arg.Name)
+	return err
+}
+
+const createTag = `-- name: CreateTag :exec
+INSERT INTO tags (id, name)
+VALUES ($1, $2)
+`
+
+type CreateTagParams struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) error {
+	_, err := q.db.Exec(ctx, createTag, arg.ID, arg.Name)
+	return err
+}
+
+const createTodo = `-- name: CreateTodo :exec
+INSERT INTO todos (id, title, is_completed, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+
+type CreateTodoParams struct {
+	ID             uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	IsCompleted    bool       `json:"is_completed"`
+	DueAt          *time.Time `json:"due_at"`
+	Priority       int16      `json:"priority"`
+	ListID         uuid.UUID  `json:"list_id"`
+	ParentID       *uuid.UUID `json:"parent_id"`
+	Position       string     `json:"position"`
+	RecurrenceRule *string    `json:"recurrence_rule"`
+	RecurrenceTz   *string    `json:"recurrence_tz"`
+}
+
+func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) error {
+	_, err := q.db.Exec(ctx, createTodo,
+		arg.ID,
+		arg.Title,
+		arg.IsCompleted,
+		arg.DueAt,
+		arg.Priority,
+		arg.ListID,
+		arg.ParentID,
+		arg.Position,
+		arg.RecurrenceRule,
+		arg.RecurrenceTz,
+	)
+	return err
+}
+
+const deleteExpiredIdempotencyKey = `-- name: DeleteExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = $1 AND expires_at <= $2
+`
+
+type DeleteExpiredIdempotencyKeyParams struct {
+	IdempotencyKey string    `json:"idempotency_key"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) DeleteExpiredIdempotencyKey(ctx context.Context, arg DeleteExpiredIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, deleteExpiredIdempotencyKey, arg.IdempotencyKey, arg.ExpiresAt)
+	return err
+}
+
+const deleteList = `-- name: DeleteList :execresult
+DELETE FROM lists
+WHERE lists.id = $1
+  AND NOT EXISTS (SELECT 1 FROM todos WHERE todos.list_id = $1)
+`
+
+// A list is deleted only while it holds no todos, trashed ones included, so
+// that deleting a list never bypasses the trash
+func (q *Queries) DeleteList(ctx context.Context, id uuid.UUID) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, deleteList, id)
+}
+
+const deleteTag = `-- name: DeleteTag :execresult
+DELETE FROM tags
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id uuid.UUID) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, deleteTag, id)
+}
+
+const deleteTodo = `-- name: DeleteTodo :execresult
+DELETE FROM todos
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTodo(ctx context.Context, id uuid.UUID) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, deleteTodo, id)
+}
+
+const detachTag = `-- name: DetachTag :execresult
+DELETE FROM todo_tags
+WHERE todo_id = $1 AND tag_id = $2
+`
+
+type DetachTagParams struct {
+	TodoID uuid.UUID `json:"todo_id"`
+	TagID  uuid.UUID `json:"tag_id"`
+}
+
+func (q *Queries) DetachTag(ctx context.Context, arg DetachTagParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, detachTag, arg.TodoID, arg.TagID)
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT idempotency_key, request_hash, status_code, response_header, response_body, expires_at, created_at, claim_token
+FROM idempotency_keys
+WHERE idempotency_key = $1
+`
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, idempotencyKey string) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, idempotencyKey)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.IdempotencyKey,
+		&i.RequestHash,
+		&i.StatusCode,
+		&i.ResponseHeader,
+		&i.ResponseBody,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClaimToken,
+	)
+	return i, err
+}
+
+const getList = `-- name: GetList :one
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    COUNT(todos.id) FILTER (WHERE todos.is_completed) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+WHERE lists.id = $1
+GROUP BY lists.id
+`
+
+type GetListRow struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TodoCount      int64     `json:"todo_count"`
+	CompletedCount int64     `json:"completed_count"`
+}
+
+func (q *Queries) GetList(ctx context.Context, id uuid.UUID) (GetListRow, error) {
+	row := q.db.QueryRow(ctx, getList, id)
+	var i GetListRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TodoCount,
+		&i.CompletedCount,
+	)
+	return i, err
+}
+
+const getTag = `-- name: GetTag :one
+SELECT id, name, created_at
+FROM tags
+WHERE id = $1
+`
+
+func (q *Queries) GetTag(ctx context.Context, id uuid.UUID) (Tag, error) {
+	row := q.db.QueryRow(ctx, getTag, id)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const getTodo = `-- name: GetTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetTodo(ctx context.Context, id uuid.UUID) (Todo, error) {
+	row := q.db.QueryRow(ctx, getTodo, id)
+	var i Todo
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.IsCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.ParentID,
+		&i.Position,
+		&i.RecurrenceRule,
+		&i.RecurrenceTz,
+		&i.DeletedAt,
+		&i.Version,
+		&i.TrashID,
+	)
+	return i, err
+}
+
+const getTodoByTitle = `-- name: GetTodoByTitle :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE title ILIKE $1 AND deleted_at IS NULL
+ORDER BY created_at DESC, id DESC
+`
+
+// ILIKE ignores case as MySQL's collation does; backslash is LIKE's default escape
+func (q *Queries) GetTodoByTitle(ctx context.Context, title string) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, getTodoByTitle, title)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.IsCompleted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.ParentID,
+			&i.Position,
+			&i.RecurrenceRule,
+			&i.RecurrenceTz,
+			&i.DeletedAt,
+			&i.Version,
+			&i.TrashID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedTodo = `-- name: GetTrashedTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetTrashedTodo(ctx context.Context, id uuid.UUID) (Todo, error) {
+	row := q.db.QueryRow(ctx, getTrashedTodo, id)
+	var i Todo
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.IsCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.ParentID,
+		&i.Position,
+		&i.RecurrenceRule,
+		&i.RecurrenceTz,
+		&i.DeletedAt,
+		&i.Version,
+		&i.TrashID,
+	)
+	return i, err
+}
+
+const listLists = `-- name: ListLists :many
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    COUNT(todos.id) FILTER (WHERE todos.is_completed) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+GROUP BY lists.id
+ORDER BY lists.created_at, lists.id
+`
+
+type ListListsRow struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TodoCount      int64     `json:"todo_count"`
+	CompletedCount int64     `json:"completed_count"`
+}
+
+func (q *Queries) ListLists(ctx context.Context) ([]ListListsRow, error) {
+	rows, err := q.db.Query(ctx, listLists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListListsRow
+	for rows.Next() {
+		var i ListListsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TodoCount,
+			&i.CompletedCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT id, name, created_at
+FROM tags
+ORDER BY lower(name), id
+`
+
+func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := q.db.Query(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsForTodos = `-- name: ListTagsForTodos :many
+SELECT todo_tags.todo_id, tags.id, tags.name, tags.created_at
+FROM todo_tags
+JOIN tags ON tags.id = todo_tags.tag_id
+WHERE todo_tags.todo_id = ANY($1::uuid[])
+ORDER BY lower(tags.name), tags.id
+`
+
+type ListTagsForTodosRow struct {
+	TodoID    uuid.UUID `json:"todo_id"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListTagsForTodos(ctx context.Context, todoIds []uuid.UUID) ([]ListTagsForTodosRow, error) {
+	rows, err := q.db.Query(ctx, listTagsForTodos, todoIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsForTodosRow
+	for rows.Next() {
+		var i ListTagsForTodosRow
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrash = `-- name: ListTrash :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+`
+
+func (q *Queries) ListTrash(ctx context.Context) ([]Todo, error) {
+	rows, err := q.db.Query(ctx, listTrash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.IsCompleted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.ParentID,
+			&i.Position,
+			&i.RecurrenceRule,
+			&i.RecurrenceTz,
+			&i.DeletedAt,
+			&i.Version,
+			&i.TrashID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :execresult
+DELETE FROM idempotency_keys
+WHERE expires_at <= $1
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, purgeIdempotencyKeys, expiresAt)
+}
+
+const purgeTrash = `-- name: PurgeTrash :execresult
+DELETE FROM todos
+WHERE deleted_at IS NOT NULL
+`
+
+func (q *Queries) PurgeTrash(ctx context.Context) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, purgeTrash)
+}
+
+const purgeTrashBefore = `-- name: PurgeTrashBefore :execresult
+DELETE FROM todos
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeTrashBefore(ctx context.Context, deletedAt *time.Time) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, purgeTrashBefore, deletedAt)
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = $1 AND claim_token = $2 AND status_code IS NULL
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	ClaimToken     string `json:"claim_token"`
+}
+
+// A claim that lapsed and was taken over has another token and is kept
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, releaseIdempotencyKey, arg.IdempotencyKey, arg.ClaimToken)
+	return err
+}
+
+const renameList = `-- name: RenameList :execresult
+UPDATE lists
+SET name = $2
+WHERE id = $1
+`
+
+type RenameListParams struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+func (q *Queries) RenameList(ctx context.Context, arg RenameListParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, renameList, arg.ID, arg.Name)
+}
+
+const renameTag = `-- name: RenameTag :execresult
+UPDATE tags
+SET name = $2
+WHERE id = $1
+`
+
+type RenameTagParams struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+func (q *Queries) RenameTag(ctx context.Context, arg RenameTagParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, renameTag, arg.ID, arg.Name)
+}
+
+const restoreTodos = `-- name: RestoreTodos :exec
+UPDATE todos
+SET deleted_at = NULL, trash_id = '', version = version + 1
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) RestoreTodos(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.Exec(ctx, restoreTodos, ids)
+	return err
+}
+
+const trashTodos = `-- name: TrashTodos :exec
+UPDATE todos
+SET deleted_at = $1, trash_id = $2, version = version + 1
+WHERE id = ANY($3::uuid[]) AND deleted_at IS NULL
+`
+
+type TrashTodosParams struct {
+	DeletedAt *time.Time  `json:"deleted_at"`
+	TrashID   string      `json:"trash_id"`
+	Ids       []uuid.UUID `json:"ids"`
+}
+
+func (q *Queries) TrashTodos(ctx context.Context, arg TrashTodosParams) error {
+	_, err := q.db.Exec(ctx, trashTodos, arg.DeletedAt, arg.TrashID, arg.Ids)
+	return err
+}
+
+const updateTodo = `-- name: UpdateTodo :execresult
+UPDATE todos
+SET title = $2, is_completed = $3, due_at = $4, priority = $5, list_id = $6, parent_id = $7, position = $8,
+    recurrence_rule = $9, recurrence_tz = $10, version = version + 1
+WHERE id = $1 AND version = $11 AND deleted_at IS NULL
+`
+
+type UpdateTodoParams struct {
+	ID             uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	IsCompleted    bool       `json:"is_completed"`
+	DueAt          *time.Time `json:"due_at"`
+	Priority       int16      `json:"priority"`
+	ListID         uuid.UUID  `json:"list_id"`
+	ParentID       *uuid.UUID `json:"parent_id"`
+	Position       string     `json:"position"`
+	RecurrenceRule *string    `json:"recurrence_rule"`
+	RecurrenceTz   *string    `json:"recurrence_tz"`
+	Version        int32      `json:"version"`
+}
+
+// The version check makes the update fail, rather than overwrite, when the
+// todo changed since it was read
+func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, updateTodo,
+		arg.ID,
+		arg.Title,
+		arg.IsCompleted,
+		arg.DueAt,
+		arg.Priority,
+		arg.ListID,
+		arg.ParentID,
+		arg.Position,
+		arg.RecurrenceRule,
+		arg.RecurrenceTz,
+		arg.Version,
+	)
+}
+
+const updateTodoPosition = `-- name: UpdateTodoPosition :exec
+UPDATE todos
+SET position = $2, version = version + 1
+WHERE id = $1
+`
+
+type UpdateTodoPositionParams struct {
+	ID       uuid.UUID `json:"id"`
+	Position string    `json:"position"`
+}
+
+// Reordering is not an edit, so it leaves out the columns the updated_at
+// trigger watches
+func (q *Queries) UpdateTodoPosition(ctx context.Context, arg UpdateTodoPositionParams) error {
+	_, err := q.db.Exec(ctx, updateTodoPosition, arg.ID, arg.Position)
+	return err
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// openTestDB returns a pool on a freshly migrated schema of the database at
+// TEST_POSTGRES_URL, a postgres:// URL, and skips the test without one. The
+// schema is dropped when the test ends.
+func openTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	base := os.Getenv("TEST_POSTGRES_URL")
+	if base == "" {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := ConnectDB(ctx, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	schema := fmt.Sprintf("test_%d_%d", time.Now().UnixNano(), rand.Int63())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if admin, err := ConnectDB(context.Background(), base); err == nil {
+			admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+			admin.Close()
+		}
+	})
+
+	u, err := url.Parse(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	pool, err := ConnectDB(ctx, u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if err := Migrate(ctx, pool, os.DirFS("../../../migrations/postgres")); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return pool
+}
+
+func mustCreateTodo(t *testing.T, repo domain.TodoRepository, title string) *domain.Todo {
+	t.Helper()
+	todo, err := repo.Create(context.Background(), domain.CreateTodoParams{Title: title, ListID: domain.DefaultListID})
+	if err != nil {
+		t.Fatalf("failed to create todo: %v", err)
+	}
+	return todo
+}
+
+func TestMigrate_RunsOnce(t *testing.T) {
+	pool := openTestDB(t)
+	if err := Migrate(context.Background(), pool, os.DirFS("../../../migrations/postgres")); err != nil {
+		t.Fatalf("migrating again failed: %v", err)
+	}
+	list, err := NewListRepository(pool).GetByID(context.Background(), domain.DefaultListID)
+	if err != nil || list.Name != "Inbox" || !list.IsDefault {
+		t.Errorf("expected the default list, got %+v (%v)", list, err)
+	}
+}
+
+func TestTodoRepository_UpdatedAtTrigger(t *testing.T) {
+	ctx := context.Background()
+	pool := openTestDB(t)
+	repo := NewTodoRepository(pool)
+	todo := mustCreateTodo(t, repo, "Edited")
+
+	wantPast := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := pool.Exec(ctx, "UPDATE todos SET updated_at = $1 WHERE id = $2", wantPast, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Reposition(ctx, todo.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Trash(ctx, []string{todo.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Restore(ctx, []string{todo.ID}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repo.GetByID(ctx, todo.ID)
+	if !got.UpdatedAt.Equal(wantPast) || got.Version != 4 {
+		t.Fatalf("expected updated_at kept at %v and version 4, got %v and %d", wantPast, got.UpdatedAt, got.Version)
+	}
+
+	updated, err := repo.Update(ctx, domain.UpdateTodoParams{ID: todo.ID, Title: "Edited", ListID: domain.DefaultListID, Position: "a", Version: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.UpdatedAt.After(wantPast) || updated.Version != 5 || updated.UpdatedAt.Location() != time.UTC {
+		t.Errorf("expected an edit to move updated_at and the version, got %v and %d", updated.UpdatedAt, updated.Version)
+	}
+}
+
+func TestTodoRepository_ReferenceErrors(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoRepository(openTestDB(t))
+	missing := "6f1c1a51-3c1d-4b43-9a35-0d6f2f7e6a10"
+	notUUID := "missing"
+
+	tests := []struct {
+		name    string
+		params  domain.CreateTodoParams
+		wantErr error
+	}{
+		{name: "missing list", params: domain.CreateTodoParams{Title: "x", ListID: missing}, wantErr: domain.ErrInvalidListID},
+		{name: "list that is not a UUID", params: domain.CreateTodoParams{Title: "x", ListID: notUUID}, wantErr: domain.ErrInvalidListID},
+		{name: "missing parent", params: domain.CreateTodoParams{Title: "x", ListID: domain.DefaultListID, ParentID: &missing}, wantErr: domain.ErrParentNotFound},
+		{name: "parent that is not a UUID", params: domain.CreateTodoParams{Title: "x", ListID: domain.DefaultListID, ParentID: &notUUID}, wantErr: domain.ErrParentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := repo.Create(ctx, tt.params); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := repo.GetByID(ctx, notUUID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected an ID that is not a UUID to be not found, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TagRepository implements domain.TagRepository on PostgreSQL
+type TagRepository struct {
+	queries *Queries
+}
+
+// NewTagRepository creates a tag repository on pool
+func NewTagRepository(pool *pgxpool.Pool) *TagRepository {
+	return &TagRepository{queries: New(pool)}
+}
+
+// List returns all tags ordered by name
+func (r *TagRepository) List(ctx context.Context) ([]domain.Tag, error) {
+	tags, err := r.queries.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	result := make([]domain.Tag, len(tags))
+	for i, t := range tags {
+		result[i] = toDomainTag(t)
+	}
+	return result, nil
+}
+
+// GetByID returns a tag, or domain.ErrTagNotFound if it does not exist
+func (r *TagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tagID, ok := parseID(id)
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	tag, err := r.queries.GetTag(ctx, tagID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	result := toDomainTag(tag)
+	return &result, nil
+}
+
+// Create creates a tag. It returns domain.ErrTagExists if the name is taken.
+func (r *TagRepository) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	id := uuid.New()
+	if err := r.queries.CreateTag(ctx, CreateTagParams{ID: id, Name: name}); err != nil {
+		if isPgError(err, pgErrUniqueViolation) {
+			return nil, domain.ErrTagExists
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return r.GetByID(ctx, id.String())
+}
+
+// Rename changes a tag's name. It returns domain.ErrTagNotFound if the tag
+// does not exist and domain.ErrTagExists if another tag has the name.
+func (r *TagRepository) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	tagID, ok := parseID(id)
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	tag, err := r.queries.RenameTag(ctx, RenameTagParams{ID: tagID, Name: name})
+	if err != nil {
+		if isPgError(err, pgErrUniqueViolation) {
+			return nil, domain.ErrTagExists
+		}
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	// PostgreSQL counts matched rows, so an unchanged name still affects one
+	if err := requireAffected(tag, domain.ErrTagNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a tag and detaches it from every todo. It returns
+// domain.ErrTagNotFound if the tag does not exist.
+func (r *TagRepository) Delete(ctx context.Context, id string) error {
+	tagID, ok := parseID(id)
+	if !ok {
+		return domain.ErrTagNotFound
+	}
+	tag, err := r.queries.DeleteTag(ctx, tagID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return requireAffected(tag, domain.ErrTagNotFound)
+}
+
+// toDomainTag converts a Tag to domain.Tag
+func toDomainTag(t Tag) domain.Tag {
+	return domain.Tag{ID: t.ID.String(), Name: t.Name, CreatedAt: t.CreatedAt.UTC()}
+}
@@ -0,0 +1,568 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TodoRepository implements domain.TodoRepository on PostgreSQL
+type TodoRepository struct {
+	queries *Queries
+	// conn runs the hand-written queries: the pool, or a transaction
+	// within a unit of work
+	conn DBTX
+}
+
+// NewTodoRepository creates a todo repository on pool
+func NewTodoRepository(pool *pgxpool.Pool) *TodoRepository {
+	return newTodoRepository(pool)
+}
+
+func newTodoRepository(conn DBTX) *TodoRepository {
+	return &TodoRepository{queries: New(conn), conn: conn}
+}
+
+// List returns a page of the todos matching the query
+func (r *TodoRepository) List(ctx context.Context, query domain.TodoQuery) ([]domain.Todo, error) {
+	q := newListQuery(query, time.Now())
+	todos, err := r.queryTodos(ctx, q.sql, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+	if q.backward {
+		// The query walked towards the start of the list, so flip it back
+		for i, j := 0, len(todos)-1; i < j; i, j = i+1, j-1 {
+			todos[i], todos[j] = todos[j], todos[i]
+		}
+	}
+	return r.withTags(ctx, todos)
+}
+
+// GetTrashed returns a trashed todo, or domain.ErrTodoNotFound if it is not in the trash
+func (r *TodoRepository) GetTrashed(ctx context.Context, id string) (*domain.Todo, error) {
+	todoID, ok := parseID(id)
+	if !ok {
+		return nil, domain.ErrTodoNotFound
+	}
+	todo, err := r.queries.GetTrashedTodo(ctx, todoID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to get trashed todo: %w", err)
+	}
+	return r.withTagsOne(ctx, todo)
+}
+
+// GetByID returns a todo, or domain.ErrTodoNotFound if it does not exist or is trashed
+func (r *TodoRepository) GetByID(ctx context.Context, id string) (*domain.Todo, error) {
+	todoID, ok := parseID(id)
+	if !ok {
+		return nil, domain.ErrTodoNotFound
+	}
+	todo, err := r.queries.GetTodo(ctx, todoID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+	return r.withTagsOne(ctx, todo)
+}
+
+// Create inserts a todo under a freshly generated id
+func (r *TodoRepository) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	listID, parentID, err := references(params.ListID, params.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New()
+	err = r.queries.CreateTodo(ctx, CreateTodoParams{
+		ID:             id,
+		Title:          params.Title,
+		IsCompleted:    false,
+		DueAt:          toStoredTime(params.DueAt),
+		Priority:       int16(params.Priority),
+		ListID:         listID,
+		ParentID:       parentID,
+		Position:       params.Position,
+		RecurrenceRule: recurrenceRule(params.Recurrence),
+		RecurrenceTz:   recurrenceTimeZone(params.Recurrence),
+	})
+	if err != nil {
+		return nil, referenceError(fmt.Errorf("failed to create todo: %w", err))
+	}
+	return r.GetByID(ctx, id.String())
+}
+
+// Update writes every field of a todo that is still at params.Version. It
+// returns domain.ErrTodoNotFound if the todo does not exist or is trashed,
+// and domain.ErrVersionMismatch if it has moved on to another version.
+func (r *TodoRepository) Update(ctx context.Context, params domain.UpdateTodoParams) (*domain.Todo, error) {
+	id, ok := parseID(params.ID)
+	if !ok {
+		return nil, domain.ErrTodoNotFound
+	}
+	listID, parentID, err := references(params.ListID, params.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := r.queries.UpdateTodo(ctx, UpdateTodoParams{
+		ID:             id,
+		Title:          params.Title,
+		IsCompleted:    params.IsCompleted,
+		DueAt:          toStoredTime(params.DueAt),
+		Priority:       int16(params.Priority),
+		ListID:         listID,
+		ParentID:       parentID,
+		Position:       params.Position,
+		RecurrenceRule: recurrenceRule(params.Recurrence),
+		RecurrenceTz:   recurrenceTimeZone(params.Recurrence),
+		Version:        int32(params.Version),
+	})
+	if err != nil {
+		return nil, referenceError(fmt.Errorf("failed to update todo: %w", err))
+	}
+	// No match means the todo is gone or another write got there first
+	if tag.RowsAffected() == 0 {
+		if _, err := r.queries.GetTodo(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTodoNotFound
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get todo: %w", err)
+		}
+		return nil, domain.ErrVersionMismatch
+	}
+	return r.GetByID(ctx, params.ID)
+}
+
+// references parses the list and parent a todo write points at. An ID that
+// is not a UUID dangles just as one that names no row would.
+func references(listID string, parentID *string) (uuid.UUID, *uuid.UUID, error) {
+	list, ok := parseID(listID)
+	if !ok {
+		return uuid.UUID{}, nil, domain.ErrInvalidListID
+	}
+	if parentID == nil {
+		return list, nil, nil
+	}
+	parent, ok := parseID(*parentID)
+	if !ok {
+		return uuid.UUID{}, nil, domain.ErrParentNotFound
+	}
+	return list, &parent, nil
+}
+
+// referenceError maps a foreign key violation of a todo write to the
+// matching domain error by the name of the violated constraint. The failed
+// statement aborts the transaction, so nothing is looked up.
+func referenceError(err error) error {
+	pgErr, ok := asPgError(err, pgErrForeignKeyViolation)
+	if !ok {
+		return err
+	}
+	if pgErr.ConstraintName == constraintTodosList {
+		return domain.ErrInvalidListID
+	}
+	return domain.ErrParentNotFound
+}
+
+// Reposition sets the position of a todo without touching updated_at
+func (r *TodoRepository) Reposition(ctx context.Context, id string, position string) error {
+	todoID, ok := parseID(id)
+	if !ok {
+		return nil
+	}
+	err := r.queries.UpdateTodoPosition(ctx, UpdateTodoPositionParams{ID: todoID, Position: position})
+	if err != nil {
+		return fmt.Errorf("failed to reposition todo: %w", err)
+	}
+	return nil
+}
+
+// Delete permanently deletes a todo. It returns domain.ErrTodoNotFound if
+// there is no such row, trashed or not.
+func (r *TodoRepository) Delete(ctx context.Context, id string) error {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.ErrTodoNotFound
+	}
+	tag, err := r.queries.DeleteTodo(ctx, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+	return requireAffected(tag, domain.ErrTodoNotFound)
+}
+
+// Trash moves todos to the trash at the given time as the operation trashID.
+// Todos already in the trash keep their original time and operation.
+func (r *TodoRepository) Trash(ctx context.Context, ids []string, trashID string, at time.Time) error {
+	todoIDs := parseIDs(ids)
+	if len(todoIDs) == 0 {
+		return nil
+	}
+	deletedAt := storedTime(at)
+	if err := r.queries.TrashTodos(ctx, TrashTodosParams{DeletedAt: &deletedAt, TrashID: trashID, Ids: todoIDs}); err != nil {
+		return fmt.Errorf("failed to trash todos: %w", err)
+	}
+	return nil
+}
+
+// Restore takes todos out of the trash
+func (r *TodoRepository) Restore(ctx context.Context, ids []string) error {
+	todoIDs := parseIDs(ids)
+	if len(todoIDs) == 0 {
+		return nil
+	}
+	if err := r.queries.RestoreTodos(ctx, todoIDs); err != nil {
+		return fmt.Errorf("failed to restore todos: %w", err)
+	}
+	return nil
+}
+
+// ListTrash returns the trashed todos, most recently trashed first
+func (r *TodoRepository) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	todos, err := r.queries.ListTrash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	return r.withTags(ctx, todos)
+}
+
+// PurgeTrash permanently deletes the todos trashed before cutoff, or the
+// whole trash if cutoff is zero, and returns how many rows were deleted.
+// Subtasks trashed with their parent go with it through the foreign key.
+func (r *TodoRepository) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	var (
+		tag pgconn.CommandTag
+		err error
+	)
+	if cutoff.IsZero() {
+		tag, err = r.queries.PurgeTrash(ctx)
+	} else {
+		before := cutoff.UTC()
+		tag, err = r.queries.PurgeTrashBefore(ctx, &before)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// subtreeQuery walks todos.parent_id downwards from a root through live
+// todos, and trashedSubtreeQuery through trashed ones
+var (
+	subtreeQuery        = walkSubtreeQuery("deleted_at IS NULL")
+	trashedSubtreeQuery = walkSubtreeQuery("deleted_at IS NOT NULL")
+)
+
+// walkSubtreeQuery builds a subtree walk that keeps to todos matching the
+// given condition on deleted_at
+func walkSubtreeQuery(deleted string) string {
+	return `WITH RECURSIVE subtree (id, depth) AS (
+    SELECT id, 0 FROM todos WHERE id = $1 AND ` + deleted + `
+    UNION ALL
+    SELECT todos.id, subtree.depth + 1 FROM todos JOIN subtree ON todos.parent_id = subtree.id
+    WHERE todos.` + deleted + `
+)
+SELECT ` + todoColumns + `
+FROM todos
+JOIN subtree USING (id)
+ORDER BY subtree.depth, created_at, id`
+}
+
+// Subtree returns a todo followed by all its descendants, shallower levels
+// first, skipping trashed todos. It returns no todos if the todo does not
+// exist or is trashed.
+func (r *TodoRepository) Subtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	rootID, ok := parseID(id)
+	if !ok {
+		return []domain.Todo{}, nil
+	}
+	todos, err := r.queryTodos(ctx, subtreeQuery, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtree: %w", err)
+	}
+	return r.withTags(ctx, todos)
+}
+
+// TrashedSubtree returns a trashed todo followed by all its trashed
+// descendants, shallower levels first. It returns no todos if the todo does
+// not exist or is not in the trash.
+func (r *TodoRepository) TrashedSubtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	rootID, ok := parseID(id)
+	if !ok {
+		return []domain.Todo{}, nil
+	}
+	todos, err := r.queryTodos(ctx, trashedSubtreeQuery, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed subtree: %w", err)
+	}
+	return r.withTags(ctx, todos)
+}
+
+// Search returns todos whose title matches the search, newest first.
+// Matching ignores case through ILIKE.
+func (r *TodoRepository) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	var (
+		todos []Todo
+		err   error
+	)
+	switch search.Mode {
+	case domain.SearchModePrefix:
+		todos, err = r.queries.GetTodoByTitle(ctx, escapeLike(search.Query)+"%")
+	case domain.SearchModeAllWords:
+		todos, err = r.searchByTitleWords(ctx, search.Terms())
+	default:
+		todos, err = r.queries.GetTodoByTitle(ctx, "%"+escapeLike(search.Query)+"%")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	return r.withTags(ctx, todos)
+}
+
+// searchByTitleWords returns todos whose title contains every term. sqlc
+// cannot express a variable number of predicates, so the query is built here
+// from parameters only.
+func (r *TodoRepository) searchByTitleWords(ctx context.Context, terms []string) ([]Todo, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	var args queryArgs
+	for _, term := range terms {
+		conditions = append(conditions, "title ILIKE "+args.bind("%"+escapeLike(term)+"%"))
+	}
+
+	query := "SELECT " + todoColumns + `
+FROM todos
+WHERE ` + strings.Join(conditions, " AND ") + `
+ORDER BY created_at DESC, id DESC`
+	return r.queryTodos(ctx, query, args...)
+}
+
+// CountByPriority returns the number of todos matching the filter per
+// priority. Priorities without todos are absent from the result.
+func (r *TodoRepository) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	query, args := newCountByPriorityQuery(filter, time.Now())
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todos by priority: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.Priority]int)
+	for rows.Next() {
+		var (
+			priority int16
+			count    int64
+		)
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan priority count: %w", err)
+		}
+		counts[domain.Priority(priority)] = int(count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count todos by priority: %w", err)
+	}
+	return counts, nil
+}
+
+// AttachTag links a tag to a todo, ignoring links that already exist
+func (r *TodoRepository) AttachTag(ctx context.Context, todoID string, tagID string) error {
+	todo, ok := parseID(todoID)
+	if !ok {
+		return domain.ErrTodoNotFound
+	}
+	tagUUID, ok := parseID(tagID)
+	if !ok {
+		return domain.ErrTagNotFound
+	}
+	tag, err := r.queries.AttachTag(ctx, AttachTagParams{TodoID: todo, TagID: tagUUID})
+	if err != nil {
+		if pgErr, ok := asPgError(err, pgErrForeignKeyViolation); ok {
+			if pgErr.ConstraintName == constraintTodoTagTag {
+				return domain.ErrTagNotFound
+			}
+			return domain.ErrTodoNotFound
+		}
+		return fmt.Errorf("failed to attach tag: %w", err)
+	}
+	return r.bumpVersionIfAffected(ctx, todo, tag)
+}
+
+// DetachTag unlinks a tag from a todo
+func (r *TodoRepository) DetachTag(ctx context.Context, todoID string, tagID string) error {
+	todo, ok := parseID(todoID)
+	if !ok {
+		return nil
+	}
+	tagUUID, ok := parseID(tagID)
+	if !ok {
+		return nil
+	}
+	tag, err := r.queries.DetachTag(ctx, DetachTagParams{TodoID: todo, TagID: tagUUID})
+	if err != nil {
+		return fmt.Errorf("failed to detach tag: %w", err)
+	}
+	return r.bumpVersionIfAffected(ctx, todo, tag)
+}
+
+// bumpVersionIfAffected moves a todo to a new version when a change to its
+// tags touched any rows, so attaching a tag twice stays a no-op
+func (r *TodoRepository) bumpVersionIfAffected(ctx context.Context, todoID uuid.UUID, tag pgconn.CommandTag) error {
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	if err := r.queries.BumpTodoVersion(ctx, todoID); err != nil {
+		return fmt.Errorf("failed to bump todo version: %w", err)
+	}
+	return nil
+}
+
+// queryTodos runs a hand-built SELECT of todoColumns and scans every row
+func (r *TodoRepository) queryTodos(ctx context.Context, query string, args ...interface{}) ([]Todo, error) {
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []Todo
+	for rows.Next() {
+		var t Todo
+		if err := rows.Scan(&t.ID, &t.Title, &t.IsCompleted, &t.CreatedAt, &t.UpdatedAt, &t.DueAt, &t.Priority, &t.ListID, &t.ParentID, &t.Position, &t.RecurrenceRule, &t.RecurrenceTz, &t.DeletedAt, &t.Version, &t.TrashID); err != nil {
+			return nil, err
+		}
+		todos = append(todos, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// withTags converts todos to domain.Todo, loading all their tags and subtask
+// counts with one query each
+func (r *TodoRepository) withTags(ctx context.Context, todos []Todo) ([]domain.Todo, error) {
+	ids := make([]uuid.UUID, len(todos))
+	for i, t := range todos {
+		ids[i] = t.ID
+	}
+
+	tags := make(map[uuid.UUID][]domain.Tag, len(todos))
+	subtasks := make(map[uuid.UUID]domain.SubtaskProgress, len(todos))
+	if len(todos) > 0 {
+		tagRows, err := r.queries.ListTagsForTodos(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags for todos: %w", err)
+		}
+		for _, row := range tagRows {
+			tags[row.TodoID] = append(tags[row.TodoID], domain.Tag{ID: row.ID.String(), Name: row.Name, CreatedAt: row.CreatedAt.UTC()})
+		}
+		countRows, err := r.queries.CountSubtasks(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count subtasks: %w", err)
+		}
+		for _, row := range countRows {
+			subtasks[row.ParentID] = domain.SubtaskProgress{Completed: int(row.Completed), Total: int(row.Total)}
+		}
+	}
+
+	result := make([]domain.Todo, len(todos))
+	for i, t := range todos {
+		result[i] = toDomainTodo(t, tags[t.ID], subtasks[t.ID])
+	}
+	return result, nil
+}
+
+// withTagsOne is withTags for a single todo
+func (r *TodoRepository) withTagsOne(ctx context.Context, todo Todo) (*domain.Todo, error) {
+	todos, err := r.withTags(ctx, []Todo{todo})
+	if err != nil {
+		return nil, err
+	}
+	return &todos[0], nil
+}
+
+// toDomainTodo converts a Todo, its tags and its subtask counts to domain.Todo
+func toDomainTodo(t Todo, tags []domain.Tag, subtasks domain.SubtaskProgress) domain.Todo {
+	if tags == nil {
+		tags = []domain.Tag{}
+	}
+	var parentID *string
+	if t.ParentID != nil {
+		id := t.ParentID.String()
+		parentID = &id
+	}
+	return domain.Todo{
+		ID:          t.ID.String(),
+		Title:       t.Title,
+		IsCompleted: t.IsCompleted,
+		DueAt:       fromStoredTime(t.DueAt),
+		Priority:    domain.Priority(t.Priority),
+		Tags:        tags,
+		ListID:      t.ListID.String(),
+		ParentID:    parentID,
+		Position:    t.Position,
+		Recurrence:  fromRecurrence(t.RecurrenceRule, t.RecurrenceTz),
+		DeletedAt:   fromStoredTime(t.DeletedAt),
+		TrashID:     t.TrashID,
+		Version:     int(t.Version),
+		Subtasks:    subtasks,
+		CreatedAt:   t.CreatedAt.UTC(),
+		UpdatedAt:   t.UpdatedAt.UTC(),
+	}
+}
+
+// fromRecurrence parses the stored recurrence columns. Rules are validated
+// before they are written, so one that no longer parses is treated as absent.
+func fromRecurrence(rule, timeZone *string) *domain.Recurrence {
+	if rule == nil {
+		return nil
+	}
+	var tz string
+	if timeZone != nil {
+		tz = *timeZone
+	}
+	r, err := domain.ParseRecurrence(*rule, tz)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// recurrenceRule returns the RRULE column value for an optional recurrence
+func recurrenceRule(r *domain.Recurrence) *string {
+	if r == nil {
+		return nil
+	}
+	rule := r.String()
+	return &rule
+}
+
+// recurrenceTimeZone returns the time zone column value for an optional recurrence
+func recurrenceTimeZone(r *domain.Recurrence) *string {
+	if r == nil {
+		return nil
+	}
+	return &r.TimeZone
+}
+
+// likeEscaper escapes the LIKE wildcards and backslash, LIKE's default escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
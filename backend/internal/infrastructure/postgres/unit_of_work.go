@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxTxAttempts bounds how often a deadlocked or serialization-failed
+	// transaction is run
+	maxTxAttempts = 3
+	// txRetryDelay is the base delay before running such a transaction again;
+	// it grows with every attempt and is jittered so the transactions that
+	// collided do not meet again in lockstep
+	txRetryDelay = 20 * time.Millisecond
+)
+
+// UnitOfWork runs repository operations in PostgreSQL transactions. The zero
+// depth unit begins transactions; the units handed to a transaction's
+// function nest savepoints inside it.
+type UnitOfWork struct {
+	pool  *pgxpool.Pool
+	tx    pgx.Tx
+	depth int
+}
+
+// NewUnitOfWork creates a unit of work beginning transactions on pool
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{pool: pool}
+}
+
+// Do runs fn in a transaction, retrying it when PostgreSQL aborts it to
+// break a deadlock or keep transactions serializable. Within a transaction
+// it runs fn under a savepoint instead and leaves retrying to the outermost
+// unit, which sees the error through the savepoint.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	if u.tx != nil {
+		return u.savepoint(ctx, fn)
+	}
+	return retryConflicts(ctx, func() error {
+		return u.transaction(ctx, fn)
+	})
+}
+
+// transaction runs fn in a new transaction
+func (u *UnitOfWork) transaction(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// A no-op once committed; otherwise it undoes a failed or panicking fn
+	defer tx.Rollback(context.Background())
+
+	if err := fn(repositories(&UnitOfWork{pool: u.pool, tx: tx})); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs fn under a savepoint of the current transaction. A failed
+// statement aborts the transaction up to the innermost savepoint, so rolling
+// back to it makes the transaction usable again.
+func (u *UnitOfWork) savepoint(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	name := fmt.Sprintf("sp_%d", u.depth+1)
+	if _, err := u.tx.Exec(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to set savepoint: %w", err)
+	}
+
+	if err := fn(repositories(&UnitOfWork{pool: u.pool, tx: u.tx, depth: u.depth + 1})); err != nil {
+		if _, rbErr := u.tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w (after %v)", rbErr, err)
+		}
+		return err
+	}
+	if _, err := u.tx.Exec(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// repositories returns the repositories of a unit bound to a transaction
+func repositories(u *UnitOfWork) domain.Repositories {
+	return domain.Repositories{
+		Todos:      newTodoRepository(u.tx),
+		Tags:       &TagRepository{queries: New(u.tx)},
+		Lists:      &ListRepository{queries: New(u.tx)},
+		UnitOfWork: u,
+	}
+}
+
+// retryConflicts runs fn until it does not fail with a deadlock or
+// serialization failure, at most maxTxAttempts times, and returns its last error
+func retryConflicts(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if attempt == maxTxAttempts || !(isPgError(err, pgErrDeadlockDetected) || isPgError(err, pgErrSerializationFailure)) {
+			return err
+		}
+		delay := time.Duration(attempt)*txRetryDelay + time.Duration(rand.Int63n(int64(txRetryDelay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,894 @@
+// Package repotest holds the contracts every domain.TodoRepository and
+// domain.IdempotencyRepository have to honour, whatever they store data in.
+// The usecase tests run against hand-written mocks that follow the same
+// rules; each backend runs these suites from its own tests to show that it
+// does too.
+package repotest
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Open returns the repositories of a backend over storage that holds
+// nothing but the default list. It is called once per subtest and should
+// skip the test when the backend is not available.
+type Open func(t *testing.T) domain.Repositories
+
+// TestTodoRepository runs the contract of domain.TodoRepository against the
+// repositories open returns
+func TestTodoRepository(t *testing.T, open Open) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repos domain.Repositories)
+	}{
+		{name: "CreateAndGet", fn: testCreateAndGet},
+		{name: "NotFound", fn: testNotFound},
+		{name: "VersionMismatch", fn: testVersionMismatch},
+		{name: "Timestamps", fn: testTimestamps},
+		{name: "Ordering", fn: testOrdering},
+		{name: "Trash", fn: testTrash},
+		{name: "DeleteNonEmptyList", fn: testDeleteNonEmptyList},
+		{name: "Subtree", fn: testSubtree},
+		{name: "TrashedSubtree", fn: testTrashedSubtree},
+		{name: "Tags", fn: testTags},
+		{name: "UnicodeTitles", fn: testUnicodeTitles},
+		{name: "Search", fn: testSearch},
+		{name: "CountByPriority", fn: testCountByPriority},
+		{name: "UnitOfWork", fn: testUnitOfWork},
+		{name: "ConcurrentCreates", fn: testConcurrentCreates},
+		{name: "ConcurrentUpdates", fn: testConcurrentUpdates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, open(t))
+		})
+	}
+}
+
+// missingID is a well-formed ID that names nothing in any backend
+func missingID() string {
+	return uuid.New().String()
+}
+
+func mustCreate(t *testing.T, repo domain.TodoRepository, params domain.CreateTodoParams) *domain.Todo {
+	t.Helper()
+	if params.ListID == "" {
+		params.ListID = domain.DefaultListID
+	}
+	todo, err := repo.Create(context.Background(), params)
+	if err != nil {
+		t.Fatalf("failed to create %q: %v", params.Title, err)
+	}
+	return todo
+}
+
+func mustGet(t *testing.T, repo domain.TodoRepository, id string) *domain.Todo {
+	t.Helper()
+	todo, err := repo.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", id, err)
+	}
+	return todo
+}
+
+// updateParams returns the params that rewrite todo as it is
+func updateParams(todo *domain.Todo) domain.UpdateTodoParams {
+	return domain.UpdateTodoParams{
+		ID:          todo.ID,
+		Title:       todo.Title,
+		IsCompleted: todo.IsCompleted,
+		DueAt:       todo.DueAt,
+		Priority:    todo.Priority,
+		ListID:      todo.ListID,
+		ParentID:    todo.ParentID,
+		Position:    todo.Position,
+		Recurrence:  todo.Recurrence,
+		Version:     todo.Version,
+	}
+}
+
+// titles returns the titles of todos, in order
+func titles(todos []domain.Todo) []string {
+	result := make([]string, len(todos))
+	for i, todo := range todos {
+		result[i] = todo.Title
+	}
+	return result
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testCreateAndGet(t *testing.T, repos domain.Repositories) {
+	repo := repos.Todos
+	before := time.Now().Add(-time.Minute)
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	recurrence, err := domain.ParseRecurrence("FREQ=WEEKLY;BYDAY=MO", "Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := mustCreate(t, repo, domain.CreateTodoParams{Title: "Write report", DueAt: &due, Priority: domain.PriorityHigh, Position: "m", Recurrence: recurrence})
+	got := mustGet(t, repo, created.ID)
+
+	if got.ID != created.ID || got.Title != "Write report" || got.IsCompleted || got.Priority != domain.PriorityHigh || got.ListID != domain.DefaultListID || got.ParentID != nil || got.Position != "m" || got.DeletedAt != nil {
+		t.Errorf("unexpected todo: %+v", got)
+	}
+	if got.DueAt == nil || !got.DueAt.Equal(due) || got.DueAt.Location() != time.UTC {
+		t.Errorf("expected due date %v in UTC, got %v", due, got.DueAt)
+	}
+	if got.Recurrence == nil || got.Recurrence.String() != recurrence.String() || got.Recurrence.TimeZone != "Asia/Tokyo" {
+		t.Errorf("expected recurrence %v, got %+v", recurrence, got.Recurrence)
+	}
+	if got.Version != 1 {
+		t.Errorf("expected version 1, got %d", got.Version)
+	}
+	if got.Tags == nil || len(got.Tags) != 0 {
+		t.Errorf("expected an empty, non-nil tag list, got %#v", got.Tags)
+	}
+	if got.Subtasks != (domain.SubtaskProgress{}) {
+		t.Errorf("expected no subtasks, got %+v", got.Subtasks)
+	}
+	if got.CreatedAt.Location() != time.UTC || got.UpdatedAt.Location() != time.UTC {
+		t.Errorf("expected UTC timestamps, got %v and %v", got.CreatedAt, got.UpdatedAt)
+	}
+	if got.CreatedAt.Before(before) || got.CreatedAt.After(time.Now().Add(time.Minute)) || got.CreatedAt.Nanosecond() != 0 {
+		t.Errorf("expected created_at to be now in whole seconds, got %v", got.CreatedAt)
+	}
+	if !got.UpdatedAt.Equal(got.CreatedAt) {
+		t.Errorf("expected updated_at %v to equal created_at %v", got.UpdatedAt, got.CreatedAt)
+	}
+}
+
+func testNotFound(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	trashed := mustCreate(t, repo, domain.CreateTodoParams{Title: "Trashed"})
+	if err := repo.Trash(ctx, []string{trashed.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	live := mustCreate(t, repo, domain.CreateTodoParams{Title: "Live"})
+	missing := missingID()
+
+	checks := []struct {
+		name    string
+		err     func() error
+		wantErr error
+	}{
+		{name: "get missing", wantErr: domain.ErrTodoNotFound, err: func() error {
+			_, err := repo.GetByID(ctx, missing)
+			return err
+		}},
+		{name: "get trashed", wantErr: domain.ErrTodoNotFound, err: func() error {
+			_, err := repo.GetByID(ctx, trashed.ID)
+			return err
+		}},
+		{name: "get missing from trash", wantErr: domain.ErrTodoNotFound, err: func() error {
+			_, err := repo.GetTrashed(ctx, missing)
+			return err
+		}},
+		{name: "get live from trash", wantErr: domain.ErrTodoNotFound, err: func() error {
+			_, err := repo.GetTrashed(ctx, live.ID)
+			return err
+		}},
+		{name: "update missing", wantErr: domain.ErrTodoNotFound, err: func() error {
+			_, err := repo.Update(ctx, domain.UpdateTodoParams{ID: missing, Title: "x", ListID: domain.DefaultListID, Version: 1})
+			return err
+		}},
+		{name: "update trashed", wantErr: domain.ErrTodoNotFound, err: func() error {
+			_, err := repo.Update(ctx, updateParams(trashed))
+			return err
+		}},
+		{name: "delete missing", wantErr: domain.ErrTodoNotFound, err: func() error {
+			return repo.Delete(ctx, missing)
+		}},
+		{name: "create in missing list", wantErr: domain.ErrInvalidListID, err: func() error {
+			_, err := repo.Create(ctx, domain.CreateTodoParams{Title: "x", ListID: missing})
+			return err
+		}},
+		{name: "create under missing parent", wantErr: domain.ErrParentNotFound, err: func() error {
+			_, err := repo.Create(ctx, domain.CreateTodoParams{Title: "x", ListID: domain.DefaultListID, ParentID: &missing})
+			return err
+		}},
+		{name: "move to missing list", wantErr: domain.ErrInvalidListID, err: func() error {
+			params := updateParams(live)
+			params.ListID = missing
+			_, err := repo.Update(ctx, params)
+			return err
+		}},
+		{name: "attach missing tag", wantErr: domain.ErrTagNotFound, err: func() error {
+			return repo.AttachTag(ctx, live.ID, missing)
+		}},
+	}
+	for _, c := range checks {
+		if err := c.err(); !errors.Is(err, c.wantErr) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.wantErr, err)
+		}
+	}
+
+	// A trashed todo still exists, so it can be deleted for good
+	if err := repo.Delete(ctx, trashed.ID); err != nil {
+		t.Errorf("expected a trashed todo to be deletable, got %v", err)
+	}
+	subtree, err := repo.Subtree(ctx, missing)
+	if err != nil || len(subtree) != 0 {
+		t.Errorf("expected no subtree for a missing todo, got %v (%v)", subtree, err)
+	}
+	subtree, err = repo.TrashedSubtree(ctx, missing)
+	if err != nil || len(subtree) != 0 {
+		t.Errorf("expected no trashed subtree for a missing todo, got %v (%v)", subtree, err)
+	}
+}
+
+func testVersionMismatch(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	todo := mustCreate(t, repo, domain.CreateTodoParams{Title: "Versioned"})
+
+	params := updateParams(todo)
+	params.Title = "First"
+	updated, err := repo.Update(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "First" || updated.Version != 2 {
+		t.Errorf("expected the first update at version 2, got %q at %d", updated.Title, updated.Version)
+	}
+
+	params.Title = "Stale"
+	if _, err := repo.Update(ctx, params); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected %v for a stale version, got %v", domain.ErrVersionMismatch, err)
+	}
+	if got := mustGet(t, repo, todo.ID); got.Title != "First" || got.Version != 2 {
+		t.Errorf("expected the stale update to change nothing, got %q at %d", got.Title, got.Version)
+	}
+}
+
+// testTimestamps checks that only edits move updated_at while every write
+// moves the version. Timestamps keep whole seconds, so it waits for the next one.
+func testTimestamps(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	tag, err := repos.Tags.Create(ctx, "timestamps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo := mustCreate(t, repo, domain.CreateTodoParams{Title: "Stamped"})
+	time.Sleep(1100 * time.Millisecond)
+
+	if err := repo.Reposition(ctx, todo.ID, "z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AttachTag(ctx, todo.ID, tag.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DetachTag(ctx, todo.ID, tag.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Trash(ctx, []string{todo.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Restore(ctx, []string{todo.ID}); err != nil {
+		t.Fatal(err)
+	}
+	kept := mustGet(t, repo, todo.ID)
+	if !kept.UpdatedAt.Equal(todo.UpdatedAt) {
+		t.Errorf("expected reordering, tagging and trashing to keep updated_at %v, got %v", todo.UpdatedAt, kept.UpdatedAt)
+	}
+	if kept.Version != 6 || kept.Position != "z" {
+		t.Errorf("expected version 6 at position z, got %d at %q", kept.Version, kept.Position)
+	}
+
+	params := updateParams(kept)
+	params.IsCompleted = true
+	edited, err := repo.Update(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !edited.UpdatedAt.After(todo.UpdatedAt) {
+		t.Errorf("expected an edit to move updated_at past %v, got %v", todo.UpdatedAt, edited.UpdatedAt)
+	}
+	if !edited.CreatedAt.Equal(todo.CreatedAt) {
+		t.Errorf("expected created_at to stay %v, got %v", todo.CreatedAt, edited.CreatedAt)
+	}
+}
+
+// testOrdering checks every sort against domain.TodoSort.Compare, which the
+// in-process sorts of the usecase and the mock follow, whole and page by page
+func testOrdering(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := due.Add(d)
+		return &t
+	}
+	for _, params := range []domain.CreateTodoParams{
+		{Title: "banana", Priority: domain.PriorityMedium, DueAt: at(2 * time.Hour), Position: "c"},
+		{Title: "Apple", Priority: domain.PriorityHigh, Position: "a"},
+		{Title: "cherry", Priority: domain.PriorityMedium, DueAt: at(0), Position: "b"},
+		{Title: "apple pie", Priority: domain.PriorityNone, DueAt: at(time.Hour), Position: "e"},
+		{Title: "Date", Priority: domain.PriorityHigh, DueAt: at(0), Position: "d"},
+		{Title: "elderberry", Priority: domain.PriorityNone, Position: "f"},
+		// Folds to "éclat", after "éclair": a fold of ASCII letters only puts it first
+		{Title: "Éclat", Priority: domain.PriorityLow, DueAt: at(3 * time.Hour), Position: "g"},
+		{Title: "éclair", Priority: domain.PriorityLow, Position: "h"},
+	} {
+		mustCreate(t, repo, params)
+	}
+	all, err := repo.List(ctx, domain.TodoQuery{Sort: domain.DefaultSort})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []domain.SortField{domain.SortByCreatedAt, domain.SortByUpdatedAt, domain.SortByDueAt, domain.SortByPriority, domain.SortByTitle, domain.SortByPosition} {
+		for _, direction := range []domain.SortDirection{domain.SortAsc, domain.SortDesc} {
+			s := domain.TodoSort{Field: field, Direction: direction}
+			t.Run(fmt.Sprintf("%s_%s", field, direction), func(t *testing.T) {
+				want := append([]domain.Todo(nil), all...)
+				sort.Slice(want, func(i, j int) bool { return s.Compare(want[i], want[j]) < 0 })
+
+				got, err := repo.List(ctx, domain.TodoQuery{Sort: s})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !sameStrings(titles(got), titles(want)) {
+					t.Fatalf("expected %v, got %v", titles(want), titles(got))
+				}
+
+				// Forwards two at a time, each page following the last todo of the one before
+				var paged []domain.Todo
+				var cursor *domain.Cursor
+				for len(paged) < len(want)+1 {
+					page, err := repo.List(ctx, domain.TodoQuery{Sort: s, Page: domain.PageRequest{Limit: 2, Cursor: cursor}})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(page) == 0 {
+						break
+					}
+					paged = append(paged, page...)
+					cursor = domain.NewCursor(page[len(page)-1], s, false)
+				}
+				if !sameStrings(titles(paged), titles(want)) {
+					t.Errorf("expected pages to read %v, got %v", titles(want), titles(paged))
+				}
+
+				// Backwards, the page before a todo ends right before it
+				before, err := repo.List(ctx, domain.TodoQuery{Sort: s, Page: domain.PageRequest{Limit: 2, Cursor: domain.NewCursor(want[4], s, true)}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !sameStrings(titles(before), titles(want[2:4])) {
+					t.Errorf("expected the page before %q to be %v, got %v", want[4].Title, titles(want[2:4]), titles(before))
+				}
+			})
+		}
+	}
+}
+
+func testTrash(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	older := mustCreate(t, repo, domain.CreateTodoParams{Title: "Older"})
+	newer := mustCreate(t, repo, domain.CreateTodoParams{Title: "Newer"})
+	mustCreate(t, repo, domain.CreateTodoParams{Title: "Kept"})
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	if err := repo.Trash(ctx, []string{older.ID}, "first", first); err != nil {
+		t.Fatal(err)
+	}
+	// Trashing again keeps the original time
+	if err := repo.Trash(ctx, []string{older.ID, newer.ID}, "second", second); err != nil {
+		t.Fatal(err)
+	}
+
+	live, err := repo.List(ctx, domain.TodoQuery{Sort: domain.DefaultSort})
+	if err != nil || !sameStrings(titles(live), []string{"Kept"}) {
+		t.Errorf("expected trashed todos to be left out of the list, got %v (%v)", titles(live), err)
+	}
+	trash, err := repo.ListTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameStrings(titles(trash), []string{"Newer", "Older"}) {
+		t.Fatalf("expected the most recently trashed first, got %v", titles(trash))
+	}
+	if trash[1].DeletedAt == nil || !trash[1].DeletedAt.Equal(first) || trash[1].DeletedAt.Location() != time.UTC {
+		t.Errorf("expected the first trashing time %v to be kept, got %v", first, trash[1].DeletedAt)
+	}
+	if trash[0].TrashID != "second" || trash[1].TrashID != "first" {
+		t.Errorf("expected each todo to keep the operation that trashed it, got %q and %q", trash[0].TrashID, trash[1].TrashID)
+	}
+	if got, err := repo.GetTrashed(ctx, older.ID); err != nil || got.TrashID != "first" || got.DeletedAt == nil {
+		t.Errorf("expected to get the trashed todo with its operation, got %+v (%v)", got, err)
+	}
+
+	purged, err := repo.PurgeTrash(ctx, second)
+	if err != nil || purged != 1 {
+		t.Errorf("expected one todo trashed before the cutoff to be purged, got %d (%v)", purged, err)
+	}
+	if err := repo.Restore(ctx, []string{newer.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustGet(t, repo, newer.ID); got.DeletedAt != nil || got.TrashID != "" {
+		t.Errorf("expected the restored todo to leave the trash, got %v in %q", got.DeletedAt, got.TrashID)
+	}
+	if _, err := repo.GetByID(ctx, older.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected the purged todo to be gone, got %v", err)
+	}
+}
+
+// testDeleteNonEmptyList checks that a list is only deleted once it holds no
+// todos, so that deleting it never removes todos behind the trash
+func testDeleteNonEmptyList(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	list, err := repos.Lists.Create(ctx, "Errands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo := mustCreate(t, repos.Todos, domain.CreateTodoParams{Title: "Post letter", ListID: list.ID})
+
+	if err := repos.Lists.Delete(ctx, list.ID); !errors.Is(err, domain.ErrListNotEmpty) {
+		t.Fatalf("expected ErrListNotEmpty for a list with a todo, got %v", err)
+	}
+	if err := repos.Todos.Trash(ctx, []string{todo.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Lists.Delete(ctx, list.ID); !errors.Is(err, domain.ErrListNotEmpty) {
+		t.Fatalf("expected ErrListNotEmpty for a list with a trashed todo, got %v", err)
+	}
+	if trash, err := repos.Todos.ListTrash(ctx); err != nil || len(trash) != 1 || trash[0].ID != todo.ID {
+		t.Errorf("expected the todo to stay in the trash, got %v (%v)", titles(trash), err)
+	}
+
+	if err := repos.Todos.Delete(ctx, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Lists.Delete(ctx, list.ID); err != nil {
+		t.Fatalf("expected the empty list to be deleted, got %v", err)
+	}
+	if err := repos.Lists.Delete(ctx, list.ID); !errors.Is(err, domain.ErrListNotFound) {
+		t.Errorf("expected ErrListNotFound once the list is gone, got %v", err)
+	}
+}
+
+func testTrashedSubtree(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	root := mustCreate(t, repo, domain.CreateTodoParams{Title: "Root"})
+	child := mustCreate(t, repo, domain.CreateTodoParams{Title: "Child", ParentID: &root.ID})
+	grandchild := mustCreate(t, repo, domain.CreateTodoParams{Title: "Grandchild", ParentID: &child.ID})
+	live := mustCreate(t, repo, domain.CreateTodoParams{Title: "Live", ParentID: &root.ID})
+	// Two operations: the child's branch first, then the root
+	if err := repo.Trash(ctx, []string{child.ID, grandchild.ID}, "branch", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Trash(ctx, []string{root.ID}, "root", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	subtree, err := repo.TrashedSubtree(ctx, root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameStrings(titles(subtree), []string{"Root", "Child", "Grandchild"}) {
+		t.Fatalf("expected the root and its trashed descendants, parents first, got %v", titles(subtree))
+	}
+	if subtree[0].TrashID != "root" || subtree[1].TrashID != "branch" || subtree[2].TrashID != "branch" {
+		t.Errorf("expected each todo to keep its operation, got %q, %q and %q", subtree[0].TrashID, subtree[1].TrashID, subtree[2].TrashID)
+	}
+	if subtree, err := repo.TrashedSubtree(ctx, live.ID); err != nil || len(subtree) != 0 {
+		t.Errorf("expected no trashed subtree for a live todo, got %v (%v)", titles(subtree), err)
+	}
+}
+
+func testSubtree(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	root := mustCreate(t, repo, domain.CreateTodoParams{Title: "Root"})
+	child := mustCreate(t, repo, domain.CreateTodoParams{Title: "Child", ParentID: &root.ID})
+	grandchild := mustCreate(t, repo, domain.CreateTodoParams{Title: "Grandchild", ParentID: &child.ID})
+	done := mustCreate(t, repo, domain.CreateTodoParams{Title: "Done", ParentID: &root.ID})
+	trashed := mustCreate(t, repo, domain.CreateTodoParams{Title: "Trashed", ParentID: &root.ID})
+	if err := repo.Trash(ctx, []string{trashed.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	params := updateParams(done)
+	params.IsCompleted = true
+	if _, err := repo.Update(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+
+	subtree, err := repo.Subtree(ctx, root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subtree) != 4 || subtree[0].ID != root.ID {
+		t.Fatalf("expected the root and its three live descendants, got %v", titles(subtree))
+	}
+	position := make(map[string]int, len(subtree))
+	for i, todo := range subtree {
+		position[todo.ID] = i
+	}
+	if position[grandchild.ID] < position[child.ID] {
+		t.Errorf("expected parents before their children, got %v", titles(subtree))
+	}
+
+	if got := mustGet(t, repo, root.ID); got.Subtasks != (domain.SubtaskProgress{Completed: 1, Total: 2}) {
+		t.Errorf("expected 1 of 2 live subtasks completed, got %+v", got.Subtasks)
+	}
+	if got := mustGet(t, repo, grandchild.ID); got.ParentID == nil || *got.ParentID != child.ID {
+		t.Errorf("expected the grandchild under %s, got %v", child.ID, got.ParentID)
+	}
+}
+
+func testTags(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	beta, err := repos.Tags.Create(ctx, "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha, err := repos.Tags.Create(ctx, "Alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Tags.Create(ctx, "BETA"); !errors.Is(err, domain.ErrTagExists) {
+		t.Errorf("expected tag names to ignore case, got %v", err)
+	}
+	tagged := mustCreate(t, repo, domain.CreateTodoParams{Title: "Tagged"})
+	mustCreate(t, repo, domain.CreateTodoParams{Title: "Untagged"})
+
+	for _, tag := range []*domain.Tag{beta, alpha, beta} {
+		if err := repo.AttachTag(ctx, tagged.ID, tag.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := mustGet(t, repo, tagged.ID)
+	if len(got.Tags) != 2 || got.Tags[0].Name != "Alpha" || got.Tags[1].Name != "beta" {
+		t.Errorf("expected the tags ordered by name regardless of case, got %+v", got.Tags)
+	}
+	if got.Version != 3 {
+		t.Errorf("expected attaching a tag twice to move the version once, got version %d", got.Version)
+	}
+
+	matching, err := repo.List(ctx, domain.TodoQuery{Filter: domain.TodoFilter{Tags: []string{"Alpha", "beta"}, TagMatch: domain.TagMatchAll}, Sort: domain.DefaultSort})
+	if err != nil || !sameStrings(titles(matching), []string{"Tagged"}) {
+		t.Errorf("expected only the tagged todo to match, got %v (%v)", titles(matching), err)
+	}
+
+	if err := repo.DetachTag(ctx, tagged.ID, alpha.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DetachTag(ctx, tagged.ID, alpha.ID); err != nil {
+		t.Errorf("expected detaching a tag that is not attached to be a no-op, got %v", err)
+	}
+	got = mustGet(t, repo, tagged.ID)
+	if len(got.Tags) != 1 || got.Tags[0].ID != beta.ID || got.Version != 4 {
+		t.Errorf("expected only beta left at version 4, got %+v at %d", got.Tags, got.Version)
+	}
+}
+
+func testUnicodeTitles(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	unicodeTitles := []string{
+		"日本語のタスク",
+		"🎉 リリースのお祝い 👩‍👩‍👧",
+		"Crème brûlée",
+		"Café au lait",
+		"Ελληνικά και кириллица",
+		"مرحبا بالعالم",
+	}
+	for _, title := range unicodeTitles {
+		created := mustCreate(t, repo, domain.CreateTodoParams{Title: title})
+		if got := mustGet(t, repo, created.ID); got.Title != title {
+			t.Errorf("expected %q to round-trip, got %q", title, got.Title)
+		}
+	}
+
+	searches := []struct {
+		search domain.TodoSearch
+		want   string
+	}{
+		{search: domain.TodoSearch{Query: "語のタ", Mode: domain.SearchModeSubstring}, want: "日本語のタスク"},
+		{search: domain.TodoSearch{Query: "🎉", Mode: domain.SearchModePrefix}, want: "🎉 リリースのお祝い 👩‍👩‍👧"},
+		// Case folding is only promised for ASCII letters
+		{search: domain.TodoSearch{Query: "CRème", Mode: domain.SearchModeSubstring}, want: "Crème brûlée"},
+		{search: domain.TodoSearch{Query: "кириллица Ελληνικά", Mode: domain.SearchModeAllWords}, want: "Ελληνικά και кириллица"},
+	}
+	for _, s := range searches {
+		found, err := repo.Search(ctx, s.search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 1 || found[0].Title != s.want {
+			t.Errorf("expected %+v to find %q, got %q", s.search, s.want, titles(found))
+		}
+	}
+}
+
+func testSearch(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	for _, title := range []string{"100% done", "100 done", "snake_case", "snakecase", `back\slash`, "Buy milk"} {
+		mustCreate(t, repo, domain.CreateTodoParams{Title: title})
+	}
+	trashed := mustCreate(t, repo, domain.CreateTodoParams{Title: "Buy bread"})
+	if err := repo.Trash(ctx, []string{trashed.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	searches := []struct {
+		search domain.TodoSearch
+		want   []string
+	}{
+		{search: domain.TodoSearch{Query: "0%", Mode: domain.SearchModeSubstring}, want: []string{"100% done"}},
+		{search: domain.TodoSearch{Query: "e_c", Mode: domain.SearchModeSubstring}, want: []string{"snake_case"}},
+		{search: domain.TodoSearch{Query: `k\s`, Mode: domain.SearchModeSubstring}, want: []string{`back\slash`}},
+		{search: domain.TodoSearch{Query: "BUY", Mode: domain.SearchModePrefix}, want: []string{"Buy milk"}},
+		{search: domain.TodoSearch{Query: "done 100%", Mode: domain.SearchModeAllWords}, want: []string{"100% done"}},
+	}
+	for _, s := range searches {
+		found, err := repo.Search(ctx, s.search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sameStrings(titles(found), s.want) {
+			t.Errorf("expected %+v to find %v, got %v", s.search, s.want, titles(found))
+		}
+	}
+}
+
+func testCountByPriority(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	for _, priority := range []domain.Priority{domain.PriorityHigh, domain.PriorityHigh, domain.PriorityLow, domain.PriorityNone} {
+		mustCreate(t, repo, domain.CreateTodoParams{Title: "Counted", Priority: priority})
+	}
+	trashed := mustCreate(t, repo, domain.CreateTodoParams{Title: "Trashed", Priority: domain.PriorityUrgent})
+	if err := repo.Trash(ctx, []string{trashed.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := repo.CountByPriority(ctx, domain.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[domain.Priority]int{domain.PriorityHigh: 2, domain.PriorityLow: 1, domain.PriorityNone: 1}
+	if len(counts) != len(want) {
+		t.Fatalf("expected %v, got %v", want, counts)
+	}
+	for priority, n := range want {
+		if counts[priority] != n {
+			t.Errorf("expected %v, got %v", want, counts)
+		}
+	}
+}
+
+// testUnitOfWork checks that a failing unit of work leaves nothing behind,
+// and that a failing nested one undoes only itself, even after a statement
+// of it failed, while the work around it carries on
+func testUnitOfWork(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	failed := errors.New("failed")
+	err := repos.UnitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		mustCreate(t, repos.Todos, domain.CreateTodoParams{Title: "Discarded"})
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the function's error, got %v", err)
+	}
+
+	missing := missingID()
+	err = repos.UnitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		mustCreate(t, repos.Todos, domain.CreateTodoParams{Title: "Kept"})
+		for i := 0; i < 2; i++ {
+			nested := repos.UnitOfWork.Do(ctx, func(repos domain.Repositories) error {
+				mustCreate(t, repos.Todos, domain.CreateTodoParams{Title: "Undone"})
+				_, err := repos.Todos.Create(ctx, domain.CreateTodoParams{Title: "Orphan", ListID: domain.DefaultListID, ParentID: &missing})
+				return err
+			})
+			if !errors.Is(nested, domain.ErrParentNotFound) {
+				t.Errorf("expected the nested error, got %v", nested)
+			}
+		}
+		return repos.UnitOfWork.Do(ctx, func(repos domain.Repositories) error {
+			mustCreate(t, repos.Todos, domain.CreateTodoParams{Title: "Also kept"})
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byTitle := domain.TodoSort{Field: domain.SortByTitle, Direction: domain.SortAsc}
+	todos, err := repos.Todos.List(ctx, domain.TodoQuery{Sort: byTitle})
+	if err != nil || !sameStrings(titles(todos), []string{"Also kept", "Kept"}) {
+		t.Errorf("expected only the kept todos, got %v (%v)", titles(todos), err)
+	}
+}
+
+// concurrency is how many goroutines the concurrency checks run at once
+const concurrency = 8
+
+func testConcurrentCreates(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+
+	var wg sync.WaitGroup
+	ids := make([]string, concurrency)
+	errs := make([]error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			todo, err := repo.Create(ctx, domain.CreateTodoParams{Title: fmt.Sprintf("Concurrent %d", i), ListID: domain.DefaultListID})
+			if err == nil {
+				ids[i] = todo.ID
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, concurrency)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("create %d failed: %v", i, err)
+		}
+		if seen[ids[i]] {
+			t.Errorf("expected distinct IDs, got %s twice", ids[i])
+		}
+		seen[ids[i]] = true
+	}
+	todos, err := repo.List(ctx, domain.TodoQuery{Sort: domain.DefaultSort})
+	if err != nil || len(todos) != concurrency {
+		t.Errorf("expected %d todos, got %d (%v)", concurrency, len(todos), err)
+	}
+}
+
+// testConcurrentUpdates races updates from the same version: exactly one
+// wins and the rest see a version mismatch rather than overwriting it
+func testConcurrentUpdates(t *testing.T, repos domain.Repositories) {
+	ctx := context.Background()
+	repo := repos.Todos
+	todo := mustCreate(t, repo, domain.CreateTodoParams{Title: "Contended"})
+
+	var wg sync.WaitGroup
+	errs := make([]error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			params := updateParams(todo)
+			params.Title = fmt.Sprintf("Writer %d", i)
+			_, errs[i] = repo.Update(ctx, params)
+		}(i)
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner >= 0 {
+				t.Errorf("expected one update to win, but writers %d and %d both did", winner, i)
+			}
+			winner = i
+		case !errors.Is(err, domain.ErrVersionMismatch):
+			t.Errorf("writer %d: expected %v, got %v", i, domain.ErrVersionMismatch, err)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("expected one update to win")
+	}
+	got := mustGet(t, repo, todo.ID)
+	if got.Title != fmt.Sprintf("Writer %d", winner) || got.Version != 2 {
+		t.Errorf("expected the winner's title at version 2, got %q at %d", got.Title, got.Version)
+	}
+}
+
+// OpenIdempotency returns an idempotency repository over storage that holds
+// no keys, like Open
+type OpenIdempotency func(t *testing.T) domain.IdempotencyRepository
+
+// TestIdempotencyRepository runs the contract of domain.IdempotencyRepository
+// against the repositories open returns
+func TestIdempotencyRepository(t *testing.T, open OpenIdempotency) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo domain.IdempotencyRepository)
+	}{
+		{name: "ClaimAndComplete", fn: testClaimAndComplete},
+		{name: "TakenOverClaim", fn: testTakenOverClaim},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, open(t))
+		})
+	}
+}
+
+// claim claims key at now for a minute under token and fails unless it got it
+func claim(t *testing.T, repo domain.IdempotencyRepository, key string, token string, now time.Time) {
+	t.Helper()
+	record := domain.IdempotencyRecord{Key: key, RequestHash: "hash", Token: token, ExpiresAt: now.Add(time.Minute)}
+	held, claimed, err := repo.Claim(context.Background(), record, now)
+	if err != nil || !claimed {
+		t.Fatalf("expected %s to claim %q, got %+v, %v", token, key, held, err)
+	}
+}
+
+func testClaimAndComplete(t *testing.T, repo domain.IdempotencyRepository) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	claim(t, repo, "key", "first", now)
+
+	held, claimed, err := repo.Claim(ctx, domain.IdempotencyRecord{Key: "key", RequestHash: "hash", Token: "second", ExpiresAt: now.Add(time.Minute)}, now)
+	if err != nil || claimed || held == nil || held.Token != "first" || held.Response != nil {
+		t.Fatalf("expected the in-flight claim to hold the key, got %+v, %v, %v", held, claimed, err)
+	}
+
+	response := domain.StoredResponse{Status: 201, Header: map[string]string{"ETag": `"1"`}, Body: []byte(`{"id":"1"}`)}
+	if err := repo.Complete(ctx, "key", "first", response, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	held, claimed, err = repo.Claim(ctx, domain.IdempotencyRecord{Key: "key", RequestHash: "hash", Token: "third", ExpiresAt: now.Add(time.Minute)}, now)
+	if err != nil || claimed || held.Response == nil || held.Response.Status != 201 || string(held.Response.Body) != `{"id":"1"}` || held.Response.Header["ETag"] != `"1"` {
+		t.Errorf("expected the stored response, got %+v, %v, %v", held, claimed, err)
+	}
+	// A completed claim is not released
+	if err := repo.Release(ctx, "key", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, claimed, _ := repo.Claim(ctx, domain.IdempotencyRecord{Key: "key", RequestHash: "hash", Token: "fourth", ExpiresAt: now.Add(time.Minute)}, now); claimed {
+		t.Error("expected the completed key to stay held")
+	}
+}
+
+// testTakenOverClaim checks that a request whose claim lapsed and was taken
+// over can neither release nor complete the claim that replaced it
+func testTakenOverClaim(t *testing.T, repo domain.IdempotencyRepository) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	claim(t, repo, "key", "first", now)
+	later := now.Add(2 * time.Minute)
+	claim(t, repo, "key", "second", later)
+
+	if err := repo.Release(ctx, "key", "first"); err != nil {
+		t.Fatal(err)
+	}
+	held, claimed, err := repo.Claim(ctx, domain.IdempotencyRecord{Key: "key", RequestHash: "hash", Token: "third", ExpiresAt: later.Add(time.Minute)}, later)
+	if err != nil || claimed || held == nil || held.Token != "second" {
+		t.Fatalf("expected the second claim to survive the first one's release, got %+v, %v, %v", held, claimed, err)
+	}
+	if err := repo.Complete(ctx, "key", "first", domain.StoredResponse{Status: 201}, later.Add(time.Hour)); err == nil {
+		t.Error("expected the lapsed claim not to complete the second one")
+	}
+
+	if err := repo.Release(ctx, "key", "second"); err != nil {
+		t.Fatal(err)
+	}
+	claim(t, repo, "key", "third", later)
+}
@@ -0,0 +1,25 @@
+package sqlite
+
+import (
+	"backend/internal/domain"
+	"backend/internal/infrastructure/repotest"
+	"testing"
+)
+
+func TestTodoRepository_Contract(t *testing.T) {
+	repotest.TestTodoRepository(t, func(t *testing.T) domain.Repositories {
+		database := openTestDB(t)
+		return domain.Repositories{
+			Todos:      NewTodoRepository(database),
+			Tags:       NewTagRepository(database),
+			Lists:      NewListRepository(database),
+			UnitOfWork: NewUnitOfWork(database),
+		}
+	})
+}
+
+func TestIdempotencyRepository_Contract(t *testing.T) {
+	repotest.TestIdempotencyRepository(t, func(t *testing.T) domain.IdempotencyRepository {
+		return NewIdempotencyRepository(openTestDB(t))
+	})
+}
@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IdempotencyRepository implements domain.IdempotencyRepository on SQLite
+type IdempotencyRepository struct {
+	queries *Queries
+}
+
+// NewIdempotencyRepository creates an idempotency repository on db
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{queries: New(db)}
+}
+
+// Claim inserts an in-flight row for record.Key. If the key is taken it
+// returns the record holding it and false; a row that expired by now is
+// deleted and the insert tried again. The primary key makes concurrent claims
+// of one key safe: exactly one insert succeeds.
+func (r *IdempotencyRepository) Claim(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, bool, error) {
+	params := ClaimIdempotencyKeyParams{
+		IdempotencyKey: record.Key,
+		RequestHash:    record.RequestHash,
+		ClaimToken:     record.Token,
+		ExpiresAt:      record.ExpiresAt.UTC(),
+	}
+	// A second attempt follows a deleted or vanished row; if the key is taken
+	// again by then, a concurrent request claimed it first
+	for attempt := 0; attempt < 2; attempt++ {
+		err := r.queries.ClaimIdempotencyKey(ctx, params)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !isSQLiteError(err, sqliteErrConstraintPrimaryKey) {
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		existing, err := r.queries.GetIdempotencyKey(ctx, params.IdempotencyKey)
+		if err == sql.ErrNoRows {
+			// Released between the insert and the read
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		if existing.ExpiresAt.After(now) {
+			held, err := toDomainIdempotencyRecord(existing)
+			if err != nil {
+				return nil, false, err
+			}
+			return held, false, nil
+		}
+		err = r.queries.DeleteExpiredIdempotencyKey(ctx, DeleteExpiredIdempotencyKeyParams{IdempotencyKey: params.IdempotencyKey, ExpiresAt: now.UTC()})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+		}
+	}
+	return nil, false, domain.ErrIdempotencyKeyInFlight
+}
+
+// Complete stores the response of an in-flight claim. It fails if the claim
+// was released or has expired and been taken over in the meantime.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, token string, response domain.StoredResponse, expiresAt time.Time) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode response header: %w", err)
+	}
+	body := response.Body
+	if body == nil {
+		// A NULL body would read back as a response still in flight
+		body = []byte{}
+	}
+	result, err := r.queries.CompleteIdempotencyKey(ctx, CompleteIdempotencyKeyParams{
+		StatusCode:     sql.NullInt64{Int64: int64(response.Status), Valid: true},
+		ResponseHeader: sql.NullString{String: string(header), Valid: true},
+		ResponseBody:   body,
+		ExpiresAt:      expiresAt.UTC(),
+		IdempotencyKey: key,
+		ClaimToken:     token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return requireAffected(result, fmt.Errorf("idempotency key %q is no longer claimed", key))
+}
+
+// Release deletes a claim whose request has not completed, unless another
+// claim has taken its place
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, token string) error {
+	if err := r.queries.ReleaseIdempotencyKey(ctx, ReleaseIdempotencyKeyParams{IdempotencyKey: key, ClaimToken: token}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes every row that expired by now and returns how many were deleted
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.queries.PurgeIdempotencyKeys(ctx, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+// toDomainIdempotencyRecord converts an IdempotencyKey to domain.IdempotencyRecord
+func toDomainIdempotencyRecord(k IdempotencyKey) (*domain.IdempotencyRecord, error) {
+	record := &domain.IdempotencyRecord{
+		Key:         k.IdempotencyKey,
+		RequestHash: k.RequestHash,
+		Token:       k.ClaimToken,
+		ExpiresAt:   k.ExpiresAt.UTC(),
+	}
+	if !k.StatusCode.Valid {
+		return record, nil
+	}
+	response := &domain.StoredResponse{Status: int(k.StatusCode.Int64), Body: k.ResponseBody}
+	if k.ResponseHeader.Valid {
+		if err := json.Unmarshal([]byte(k.ResponseHeader.String), &response.Header); err != nil {
+			return nil, fmt.Errorf("failed to decode response header: %w", err)
+		}
+	}
+	record.Response = response
+	return record, nil
+}
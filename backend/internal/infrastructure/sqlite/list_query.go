@@ -0,0 +1,207 @@
+package sqlite
+
+import (
+	"backend/internal/domain"
+	"strings"
+	"time"
+)
+
+// todoColumns is the column list scanned by queryTodos, in the order it scans them
+const todoColumns = "id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id"
+
+// nullDueAtKey stands in for a NULL due_at in sort keys. Rows without a due
+// date are grouped after dated ones by a separate "due_at IS NULL" key, so the
+// sentinel only has to be a constant; nullDueAt is the same instant in Go,
+// which the driver writes in the same layout.
+const nullDueAtKey = "'1970-01-02 00:00:00+00:00'"
+
+var nullDueAt = time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// listQuery is a SELECT over todos built from a domain.TodoQuery.
+// Only expressions from the fixed whitelists below are interpolated into the
+// SQL text; every user-supplied value is bound as a placeholder.
+type listQuery struct {
+	sql      string
+	args     []interface{}
+	backward bool
+}
+
+// sortKey is one ORDER BY expression together with the cursor's value for it
+type sortKey struct {
+	expr  string
+	desc  bool
+	value interface{}
+}
+
+// sortKeys maps a whitelisted sort to its ORDER BY expressions, ending with id.
+// Titles are compared lowered and byte by byte, like the domain's sort.
+func sortKeys(sort domain.TodoSort, cursor *domain.Cursor) []sortKey {
+	desc := sort.Direction == domain.SortDesc
+	var c domain.Todo
+	if cursor != nil {
+		c = cursor.Todo()
+	}
+
+	var keys []sortKey
+	switch sort.Field {
+	case domain.SortByUpdatedAt:
+		keys = append(keys, sortKey{expr: "updated_at", desc: desc, value: c.UpdatedAt.UTC()})
+	case domain.SortByDueAt:
+		keys = append(keys, dueAtKeys(c.DueAt, desc)...)
+	case domain.SortByPriority:
+		// Within a priority the soonest due date comes first, whatever the direction
+		keys = append(keys, sortKey{expr: "priority", desc: desc, value: int64(c.Priority)})
+		keys = append(keys, dueAtKeys(c.DueAt, false)...)
+	case domain.SortByTitle:
+		keys = append(keys, sortKey{expr: "lower(title)", desc: desc, value: strings.ToLower(c.Title)})
+	case domain.SortByPosition:
+		keys = append(keys, sortKey{expr: "position", desc: desc, value: c.Position})
+	default:
+		keys = append(keys, sortKey{expr: "created_at", desc: desc, value: c.CreatedAt.UTC()})
+	}
+	return append(keys, sortKey{expr: "id", desc: desc, value: c.ID})
+}
+
+// dueAtKeys orders by due date with undated todos always last
+func dueAtKeys(dueAt *time.Time, desc bool) []sortKey {
+	due := nullDueAt
+	if dueAt != nil {
+		due = dueAt.UTC()
+	}
+	return []sortKey{
+		{expr: "(due_at IS NULL)", desc: false, value: dueAt == nil},
+		{expr: "COALESCE(due_at, " + nullDueAtKey + ")", desc: desc, value: due},
+	}
+}
+
+// filterConditions translates a domain.TodoFilter into WHERE conditions and their arguments
+func filterConditions(f domain.TodoFilter, now time.Time) ([]string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	add := func(cond string, values ...interface{}) {
+		conditions = append(conditions, cond)
+		args = append(args, values...)
+	}
+
+	// Trashed todos only show up in the trash
+	add("deleted_at IS NULL")
+	if f.ListID != "" {
+		add("list_id = ?", f.ListID)
+	}
+	switch f.Status {
+	case domain.StatusActive:
+		add("is_completed = FALSE")
+	case domain.StatusCompleted:
+		add("is_completed = TRUE")
+	}
+	if f.CreatedAfter != nil {
+		add("created_at >= ?", f.CreatedAfter.UTC())
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < ?", f.CreatedBefore.UTC())
+	}
+	if f.UpdatedAfter != nil {
+		add("updated_at >= ?", f.UpdatedAfter.UTC())
+	}
+	if f.UpdatedBefore != nil {
+		add("updated_at < ?", f.UpdatedBefore.UTC())
+	}
+	if f.DueAfter != nil {
+		add("due_at >= ?", f.DueAfter.UTC())
+	}
+	if f.DueBefore != nil {
+		add("due_at < ?", f.DueBefore.UTC())
+	}
+	if f.Overdue {
+		add("(is_completed = FALSE AND due_at < ?)", now.UTC())
+	}
+	if len(f.Tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Tags)), ", ")
+		names := make([]interface{}, len(f.Tags))
+		for i, name := range f.Tags {
+			names[i] = name
+		}
+		subquery := `id IN (SELECT todo_tags.todo_id
+    FROM todo_tags
+    JOIN tags ON tags.id = todo_tags.tag_id
+    WHERE tags.name IN (` + placeholders + `)`
+		if f.TagMatch == domain.TagMatchAll {
+			// Tag names are unique, so every name matched means one row per name
+			add(subquery+`
+    GROUP BY todo_tags.todo_id
+    HAVING COUNT(DISTINCT tags.id) = ?)`, append(names, len(f.Tags))...)
+		} else {
+			add(subquery+")", names...)
+		}
+	}
+	return conditions, args
+}
+
+// whereClause joins conditions into a WHERE clause, or returns "" if there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "\nWHERE " + strings.Join(conditions, "\n  AND ")
+}
+
+// newListQuery translates q into SQL. now is used for the overdue filter.
+func newListQuery(q domain.TodoQuery, now time.Time) listQuery {
+	conditions, args := filterConditions(q.Filter, now)
+
+	cursor := q.Page.Cursor
+	backward := cursor != nil && cursor.Backward
+	keys := sortKeys(q.Sort, cursor)
+
+	// Keyset predicate: (k1, k2, ...) strictly beyond the cursor, expanded
+	// into OR-ed prefixes because the keys may run in different directions
+	if cursor != nil {
+		var alternatives []string
+		var altArgs []interface{}
+		for i, key := range keys {
+			var parts []string
+			for _, prev := range keys[:i] {
+				parts = append(parts, prev.expr+" = ?")
+				altArgs = append(altArgs, prev.value)
+			}
+			op := ">"
+			if key.desc != backward {
+				op = "<"
+			}
+			parts = append(parts, key.expr+" "+op+" ?")
+			altArgs = append(altArgs, key.value)
+			alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+		args = append(args, altArgs...)
+	}
+
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		// Walking backwards reads the list in reverse; the caller flips it back
+		if key.desc != backward {
+			order[i] = key.expr + " DESC"
+		} else {
+			order[i] = key.expr + " ASC"
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT " + todoColumns + "\nFROM todos")
+	sb.WriteString(whereClause(conditions))
+	sb.WriteString("\nORDER BY " + strings.Join(order, ", "))
+	if q.Page.Limit > 0 {
+		sb.WriteString("\nLIMIT ?")
+		args = append(args, q.Page.Limit)
+	}
+
+	return listQuery{sql: sb.String(), args: args, backward: backward}
+}
+
+// newCountByPriorityQuery counts the todos matching f per priority
+func newCountByPriorityQuery(f domain.TodoFilter, now time.Time) (string, []interface{}) {
+	conditions, args := filterConditions(f, now)
+	return "SELECT priority, COUNT(*)\nFROM todos" + whereClause(conditions) + "\nGROUP BY priority", args
+}
@@ -0,0 +1,800 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: queries.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+const attachTag = `-- name: AttachTag :execresult
+INSERT OR IGNORE INTO todo_tags (todo_id, tag_id)
+VALUES (?, ?)
+`
+
+type AttachTagParams struct {
+	TodoID string `json:"todo_id"`
+	TagID  string `json:"tag_id"`
+}
+
+func (q *Queries) AttachTag(ctx context.Context, arg AttachTagParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, attachTag, arg.TodoID, arg.TagID)
+}
+
+const bumpTodoVersion = `-- name: BumpTodoVersion :exec
+UPDATE todos
+SET version = version + 1
+WHERE id = ?
+`
+
+// Tags are part of a todo, so changing them moves it to a new version
+func (q *Queries) BumpTodoVersion(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, bumpTodoVersion, id)
+	return err
+}
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :exec
+INSERT INTO idempotency_keys (idempotency_key, request_hash, claim_token, expires_at)
+VALUES (?, ?, ?, ?)
+`
+
+type ClaimIdempotencyKeyParams struct {
+	IdempotencyKey string    `json:"idempotency_key"`
+	RequestHash    string    `json:"request_hash"`
+	ClaimToken     string    `json:"claim_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, claimIdempotencyKey,
+		arg.IdempotencyKey,
+		arg.RequestHash,
+		arg.ClaimToken,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :execresult
+UPDATE idempotency_keys
+SET status_code = ?, response_header = ?, response_body = ?, expires_at = ?
+WHERE idempotency_key = ? AND claim_token = ? AND status_code IS NULL
+`
+
+type CompleteIdempotencyKeyParams struct {
+	StatusCode     sql.NullInt64  `json:"status_code"`
+	ResponseHeader sql.NullString `json:"response_header"`
+	ResponseBody   []byte         `json:"response_body"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	ClaimToken     string         `json:"claim_token"`
+}
+
+// Only the claim that is still in flight under the same token is completed
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, completeIdempotencyKey,
+		arg.StatusCode,
+		arg.ResponseHeader,
+		arg.ResponseBody,
+		arg.ExpiresAt,
+		arg.IdempotencyKey,
+		arg.ClaimToken,
+	)
+}
+
+const countSubtasks = `-- name: CountSubtasks :many
+SELECT parent_id,
+    COUNT(*) AS total,
+    CAST(COALESCE(SUM(is_completed), 0) AS INTEGER) AS completed
+FROM todos
+WHERE parent_id IN (/*SLICE:parent_ids*/?) AND deleted_at IS NULL
+GROUP BY parent_id
+`
+
+type CountSubtasksRow struct {
+	ParentID  sql.NullString `json:"parent_id"`
+	Total     int64          `json:"total"`
+	Completed int64          `json:"completed"`
+}
+
+func (q *Queries) CountSubtasks(ctx context.Context, parentIds []sql.NullString) ([]CountSubtasksRow, error) {
+	query := countSubtasks
+	var queryParams []interface{}
+	if len(parentIds) > 0 {
+		for _, v := range parentIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:parent_ids*/?", strings.Repeat(",?", len(parentIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:parent_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSubtasksRow
+	for rows.Next() {
+		var i CountSubtasksRow
+		if err := rows.Scan(&i.ParentID, &i.Total, &i.Completed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createList = `-- name: CreateList :exec
+INSERT INTO lists (id, name)
+VALUES (?, ?)
+`
+
+type CreateListParams struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) CreateList(ctx context.Context, arg CreateListParams) error {
+	_, err := q.db.ExecContext(ctx, createList, arg.ID, arg.Name)
+	return err
+}
+
+const createTag = `-- name: CreateTag :exec
+INSERT INTO tags (id, name)
+VALUES (?, ?)
+`
+
+type CreateTagParams struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) error {
+	_, err := q.db.ExecContext(ctx, createTag, arg.ID, arg.Name)
+	return err
+}
+
+const createTodo = `-- name: CreateTodo :exec
+INSERT INTO todos (id, title, is_completed, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateTodoParams struct {
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	IsCompleted    bool           `json:"is_completed"`
+	DueAt          sql.NullTime   `json:"due_at"`
+	Priority       int64          `json:"priority"`
+	ListID         string         `json:"list_id"`
+	ParentID       sql.NullString `json:"parent_id"`
+	Position       string         `json:"position"`
+	RecurrenceRule sql.NullString `json:"recurrence_rule"`
+	RecurrenceTz   sql.NullString `json:"recurrence_tz"`
+}
+
+func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) error {
+	_, err := q.db.ExecContext(ctx, createTodo,
+		arg.ID,
+		arg.Title,
+		arg.IsCompleted,
+		arg.DueAt,
+		arg.Priority,
+		arg.ListID,
+		arg.ParentID,
+		arg.Position,
+		arg.RecurrenceRule,
+		arg.RecurrenceTz,
+	)
+	return err
+}
+
+const deleteExpiredIdempotencyKey = `-- name: DeleteExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = ? AND expires_at <= ?
+`
+
+type DeleteExpiredIdempotencyKeyParams struct {
+	IdempotencyKey string    `json:"idempotency_key"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) DeleteExpiredIdempotencyKey(ctx context.Context, arg DeleteExpiredIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredIdempotencyKey, arg.IdempotencyKey, arg.ExpiresAt)
+	return err
+}
+
+const deleteList = `-- name: DeleteList :execresult
+DELETE FROM lists
+WHERE lists.id = ?1
+  AND NOT EXISTS (SELECT 1 FROM todos WHERE todos.list_id = ?1)
+`
+
+// A list is deleted only while it holds no todos, trashed ones included, so
+// that deleting a list never bypasses the trash
+func (q *Queries) DeleteList(ctx context.Context, id string) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteList, id)
+}
+
+const deleteTag = `-- name: DeleteTag :execresult
+DELETE FROM tags
+WHERE id = ?
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id string) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteTag, id)
+}
+
+const deleteTodo = `-- name: DeleteTodo :execresult
+DELETE FROM todos
+WHERE id = ?
+`
+
+func (q *Queries) DeleteTodo(ctx context.Context, id string) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteTodo, id)
+}
+
+const detachTag = `-- name: DetachTag :execresult
+DELETE FROM todo_tags
+WHERE todo_id = ? AND tag_id = ?
+`
+
+type DetachTagParams struct {
+	TodoID string `json:"todo_id"`
+	TagID  string `json:"tag_id"`
+}
+
+func (q *Queries) DetachTag(ctx context.Context, arg DetachTagParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, detachTag, arg.TodoID, arg.TagID)
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT idempotency_key, request_hash, status_code, response_header, response_body, expires_at, created_at, claim_token
+FROM idempotency_keys
+WHERE idempotency_key = ?
+`
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, idempotencyKey string) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, idempotencyKey)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.IdempotencyKey,
+		&i.RequestHash,
+		&i.StatusCode,
+		&i.ResponseHeader,
+		&i.ResponseBody,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClaimToken,
+	)
+	return i, err
+}
+
+const getList = `-- name: GetList :one
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    CAST(COALESCE(SUM(todos.is_completed), 0) AS INTEGER) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+WHERE lists.id = ?
+GROUP BY lists.id
+`
+
+type GetListRow struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TodoCount      int64     `json:"todo_count"`
+	CompletedCount int64     `json:"completed_count"`
+}
+
+func (q *Queries) GetList(ctx context.Context, id string) (GetListRow, error) {
+	row := q.db.QueryRowContext(ctx, getList, id)
+	var i GetListRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TodoCount,
+		&i.CompletedCount,
+	)
+	return i, err
+}
+
+const getTag = `-- name: GetTag :one
+SELECT id, name, created_at
+FROM tags
+WHERE id = ?
+`
+
+func (q *Queries) GetTag(ctx context.Context, id string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTag, id)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const getTodo = `-- name: GetTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetTodo(ctx context.Context, id string) (Todo, error) {
+	row := q.db.QueryRowContext(ctx, getTodo, id)
+	var i Todo
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.IsCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.ParentID,
+		&i.Position,
+		&i.RecurrenceRule,
+		&i.RecurrenceTz,
+		&i.DeletedAt,
+		&i.Version,
+		&i.TrashID,
+	)
+	return i, err
+}
+
+const getTodoByTitle = `-- name: GetTodoByTitle :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE title LIKE ? ESCAPE '\' AND deleted_at IS NULL
+ORDER BY created_at DESC, id DESC
+`
+
+// SQLite's LIKE has no escape character unless one is named
+func (q *Queries) GetTodoByTitle(ctx context.Context, title string) ([]Todo, error) {
+	rows, err := q.db.QueryContext(ctx, getTodoByTitle, title)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.IsCompleted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.ParentID,
+			&i.Position,
+			&i.RecurrenceRule,
+			&i.RecurrenceTz,
+			&i.DeletedAt,
+			&i.Version,
+			&i.TrashID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedTodo = `-- name: GetTrashedTodo :one
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetTrashedTodo(ctx context.Context, id string) (Todo, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedTodo, id)
+	var i Todo
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.IsCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.ParentID,
+		&i.Position,
+		&i.RecurrenceRule,
+		&i.RecurrenceTz,
+		&i.DeletedAt,
+		&i.Version,
+		&i.TrashID,
+	)
+	return i, err
+}
+
+const listExists = `-- name: ListExists :one
+SELECT EXISTS (SELECT 1 FROM lists WHERE id = ?)
+`
+
+func (q *Queries) ListExists(ctx context.Context, id string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, listExists, id)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listLists = `-- name: ListLists :many
+SELECT lists.id, lists.name, lists.created_at, lists.updated_at,
+    COUNT(todos.id) AS todo_count,
+    CAST(COALESCE(SUM(todos.is_completed), 0) AS INTEGER) AS completed_count
+FROM lists
+LEFT JOIN todos ON todos.list_id = lists.id AND todos.deleted_at IS NULL
+GROUP BY lists.id
+ORDER BY lists.created_at, lists.id
+`
+
+type ListListsRow struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TodoCount      int64     `json:"todo_count"`
+	CompletedCount int64     `json:"completed_count"`
+}
+
+func (q *Queries) ListLists(ctx context.Context) ([]ListListsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListListsRow
+	for rows.Next() {
+		var i ListListsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TodoCount,
+			&i.CompletedCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT id, name, created_at
+FROM tags
+ORDER BY name, id
+`
+
+func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsForTodos = `-- name: ListTagsForTodos :many
+SELECT todo_tags.todo_id, tags.id, tags.name, tags.created_at
+FROM todo_tags
+JOIN tags ON tags.id = todo_tags.tag_id
+WHERE todo_tags.todo_id IN (/*SLICE:todo_ids*/?)
+ORDER BY tags.name, tags.id
+`
+
+type ListTagsForTodosRow struct {
+	TodoID    string    `json:"todo_id"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListTagsForTodos(ctx context.Context, todoIds []string) ([]ListTagsForTodosRow, error) {
+	query := listTagsForTodos
+	var queryParams []interface{}
+	if len(todoIds) > 0 {
+		for _, v := range todoIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:todo_ids*/?", strings.Repeat(",?", len(todoIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:todo_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsForTodosRow
+	for rows.Next() {
+		var i ListTagsForTodosRow
+		if err := rows.Scan(
+			&i.TodoID,
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrash = `-- name: ListTrash :many
+SELECT id, title, is_completed, created_at, updated_at, due_at, priority, list_id, parent_id, position, recurrence_rule, recurrence_tz, deleted_at, version, trash_id
+FROM todos
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+`
+
+func (q *Queries) ListTrash(ctx context.Context) ([]Todo, error) {
+	rows, err := q.db.QueryContext(ctx, listTrash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.IsCompleted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.ParentID,
+			&i.Position,
+			&i.RecurrenceRule,
+			&i.RecurrenceTz,
+			&i.DeletedAt,
+			&i.Version,
+			&i.TrashID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :execresult
+DELETE FROM idempotency_keys
+WHERE expires_at <= ?
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) (sql.Result, error) {
+	return q.db.ExecContext(ctx, purgeIdempotencyKeys, expiresAt)
+}
+
+const purgeTrash = `-- name: PurgeTrash :execresult
+DELETE FROM todos
+WHERE deleted_at IS NOT NULL
+`
+
+func (q *Queries) PurgeTrash(ctx context.Context) (sql.Result, error) {
+	return q.db.ExecContext(ctx, purgeTrash)
+}
+
+const purgeTrashBefore = `-- name: PurgeTrashBefore :execresult
+DELETE FROM todos
+WHERE deleted_at < ?
+`
+
+func (q *Queries) PurgeTrashBefore(ctx context.Context, deletedAt sql.NullTime) (sql.Result, error) {
+	return q.db.ExecContext(ctx, purgeTrashBefore, deletedAt)
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = ? AND claim_token = ? AND status_code IS NULL
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	ClaimToken     string `json:"claim_token"`
+}
+
+// A claim that lapsed and was taken over has another token and is kept
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, releaseIdempotencyKey, arg.IdempotencyKey, arg.ClaimToken)
+	return err
+}
+
+const renameList = `-- name: RenameList :execresult
+UPDATE lists
+SET name = ?
+WHERE id = ?
+`
+
+type RenameListParams struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+func (q *Queries) RenameList(ctx context.Context, arg RenameListParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, renameList, arg.Name, arg.ID)
+}
+
+const renameTag = `-- name: RenameTag :execresult
+UPDATE tags
+SET name = ?
+WHERE id = ?
+`
+
+type RenameTagParams struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+func (q *Queries) RenameTag(ctx context.Context, arg RenameTagParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, renameTag, arg.Name, arg.ID)
+}
+
+const restoreTodos = `-- name: RestoreTodos :exec
+UPDATE todos
+SET deleted_at = NULL, trash_id = '', version = version + 1
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+func (q *Queries) RestoreTodos(ctx context.Context, ids []string) error {
+	query := restoreTodos
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const trashTodos = `-- name: TrashTodos :exec
+UPDATE todos
+SET deleted_at = ?, trash_id = ?, version = version + 1
+WHERE id IN (/*SLICE:ids*/?) AND deleted_at IS NULL
+`
+
+type TrashTodosParams struct {
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	TrashID   string       `json:"trash_id"`
+	Ids       []string     `json:"ids"`
+}
+
+func (q *Queries) TrashTodos(ctx context.Context, arg TrashTodosParams) error {
+	query := trashTodos
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.DeletedAt)
+	queryParams = append(queryParams, arg.TrashID)
+	if len(arg.Ids) > 0 {
+		for _, v := range arg.Ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(arg.Ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const updateTodo = `-- name: UpdateTodo :execresult
+UPDATE todos
+SET title = ?, is_completed = ?, due_at = ?, priority = ?, list_id = ?, parent_id = ?, position = ?,
+    recurrence_rule = ?, recurrence_tz = ?, version = version + 1
+WHERE id = ? AND version = ? AND deleted_at IS NULL
+`
+
+type UpdateTodoParams struct {
+	Title          string         `json:"title"`
+	IsCompleted    bool           `json:"is_completed"`
+	DueAt          sql.NullTime   `json:"due_at"`
+	Priority       int64          `json:"priority"`
+	ListID         string         `json:"list_id"`
+	ParentID       sql.NullString `json:"parent_id"`
+	Position       string         `json:"position"`
+	RecurrenceRule sql.NullString `json:"recurrence_rule"`
+	RecurrenceTz   sql.NullString `json:"recurrence_tz"`
+	ID             string         `json:"id"`
+	Version        int64          `json:"version"`
+}
+
+// The version check makes the update fail, rather than overwrite, when the
+// todo changed since it was read
+func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateTodo,
+		arg.Title,
+		arg.IsCompleted,
+		arg.DueAt,
+		arg.Priority,
+		arg.ListID,
+		arg.ParentID,
+		arg.Position,
+		arg.RecurrenceRule,
+		arg.RecurrenceTz,
+		arg.ID,
+		arg.Version,
+	)
+}
+
+const updateTodoPosition = `-- name: UpdateTodoPosition :exec
+UPDATE todos
+SET position = ?, version = version + 1
+WHERE id = ?
+`
+
+type UpdateTodoPositionParams struct {
+	Position string `json:"position"`
+	ID       string `json:"id"`
+}
+
+// Reordering is not an edit, so it leaves out the columns the updated_at
+// trigger watches
+func (q *Queries) UpdateTodoPosition(ctx context.Context, arg UpdateTodoPositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateTodoPosition, arg.Position, arg.ID)
+	return err
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestDB returns a migrated database in a fresh file
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := ConnectDB(filepath.Join(t.TempDir(), "todo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := Migrate(context.Background(), database, os.DirFS("../../../migrations/sqlite")); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return database
+}
+
+func mustCreateTodo(t *testing.T, repo domain.TodoRepository, title string) *domain.Todo {
+	t.Helper()
+	todo, err := repo.Create(context.Background(), domain.CreateTodoParams{Title: title, ListID: domain.DefaultListID})
+	if err != nil {
+		t.Fatalf("failed to create todo: %v", err)
+	}
+	return todo
+}
+
+func TestMigrate_RunsOnce(t *testing.T) {
+	database := openTestDB(t)
+	if err := Migrate(context.Background(), database, os.DirFS("../../../migrations/sqlite")); err != nil {
+		t.Fatalf("migrating again failed: %v", err)
+	}
+	list, err := NewListRepository(database).GetByID(context.Background(), domain.DefaultListID)
+	if err != nil || list.Name != "Inbox" || !list.IsDefault {
+		t.Errorf("expected the default list, got %+v (%v)", list, err)
+	}
+}
+
+func TestTodoRepository_UpdatedAtTrigger(t *testing.T) {
+	ctx := context.Background()
+	database := openTestDB(t)
+	repo := NewTodoRepository(database)
+	todo := mustCreateTodo(t, repo, "Edited")
+
+	past := "2020-01-01 00:00:00+00:00"
+	if _, err := database.Exec("UPDATE todos SET updated_at = ? WHERE id = ?", past, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	wantPast := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := repo.Reposition(ctx, todo.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Trash(ctx, []string{todo.ID}, "trash", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Restore(ctx, []string{todo.ID}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repo.GetByID(ctx, todo.ID)
+	if !got.UpdatedAt.Equal(wantPast) || got.Version != 4 {
+		t.Fatalf("expected updated_at kept at %v and version 4, got %v and %d", wantPast, got.UpdatedAt, got.Version)
+	}
+
+	updated, err := repo.Update(ctx, domain.UpdateTodoParams{ID: todo.ID, Title: "Edited", ListID: domain.DefaultListID, Position: "a", Version: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.UpdatedAt.After(wantPast) || updated.Version != 5 {
+		t.Errorf("expected an edit to move updated_at and the version, got %v and %d", updated.UpdatedAt, updated.Version)
+	}
+}
+
+func TestConnectDB_EscapesPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo?mode=ro#100%.db")
+	database, err := ConnectDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the database at its literal path: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package sqlite implements the repositories on a SQLite database file
+// through a pure-Go driver, for deployments that ship as a single binary.
+// Its queries are generated by sqlc from db/sqlite/queries.sql against the
+// schema in migrations/sqlite, which Migrate applies at startup.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"modernc.org/sqlite"
+)
+
+// SQLite result codes mapped to domain errors
+const (
+	sqliteErrBusy                 = 5
+	sqliteErrConstraintForeignKey = 787
+	sqliteErrConstraintUnique     = 2067
+	sqliteErrConstraintPrimaryKey = 1555
+)
+
+// SQLite's built-in lower folds ASCII letters only. Every connection gets one
+// that folds like strings.ToLower instead, so titles sorted on lower(title)
+// come out in the same order as under PostgreSQL and the domain's sort.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("lower", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if s, ok := args[0].(string); ok {
+			return strings.ToLower(s), nil
+		}
+		return args[0], nil
+	})
+}
+
+// uriPath escapes the characters that would end the path of a file: URI or
+// start an escape in it; SQLite decodes them again when it opens the file
+var uriPath = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// ConnectDB opens the SQLite database file at path, creating it if needed.
+// Every connection enforces foreign keys, waits for the write lock instead of
+// failing at once, and begins its transactions by taking that lock, so
+// concurrent units of work queue up rather than deadlock. Times are written
+// in the layout the schema's defaults and triggers use.
+func ConnectDB(path string) (*sql.DB, error) {
+	dsn := "file:" + uriPath.Replace(path) + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Migrate applies the goose migrations in fsys that the database has not
+// seen yet, each in its own transaction and in version order
+func Migrate(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// isSQLiteError reports whether err wraps a SQLite error with the given
+// extended result code, or primary code for codes below 256
+func isSQLiteError(err error, code int) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	if code < 256 {
+		return sqliteErr.Code()&0xff == code
+	}
+	return sqliteErr.Code() == code
+}
+
+// storedTime converts a time to the value a DATETIME column holds: UTC
+// rounded to the second, as MySQL's TIMESTAMP columns store it
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Round(time.Second)
+}
+
+// toNullTime converts an optional time to sql.NullTime as it is stored
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: storedTime(*t), Valid: true}
+}
+
+// fromNullTime converts sql.NullTime to an optional UTC time
+func fromNullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// toNullString converts an optional string to sql.NullString
+func toNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// fromNullString converts sql.NullString to an optional string
+func fromNullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// requireAffected returns notFound if a statement matched no rows
+func requireAffected(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
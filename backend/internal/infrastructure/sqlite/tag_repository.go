@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TagRepository implements domain.TagRepository on SQLite
+type TagRepository struct {
+	queries *Queries
+}
+
+// NewTagRepository creates a tag repository on db
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{queries: New(db)}
+}
+
+// List returns all tags ordered by name
+func (r *TagRepository) List(ctx context.Context) ([]domain.Tag, error) {
+	tags, err := r.queries.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	result := make([]domain.Tag, len(tags))
+	for i, t := range tags {
+		result[i] = toDomainTag(t)
+	}
+	return result, nil
+}
+
+// GetByID returns a tag, or domain.ErrTagNotFound if it does not exist
+func (r *TagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tag, err := r.queries.GetTag(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	result := toDomainTag(tag)
+	return &result, nil
+}
+
+// Create creates a tag. It returns domain.ErrTagExists if the name is taken.
+func (r *TagRepository) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	id := uuid.New().String()
+	if err := r.queries.CreateTag(ctx, CreateTagParams{ID: id, Name: name}); err != nil {
+		if isSQLiteError(err, sqliteErrConstraintUnique) {
+			return nil, domain.ErrTagExists
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Rename changes a tag's name. It returns domain.ErrTagNotFound if the tag
+// does not exist and domain.ErrTagExists if another tag has the name.
+func (r *TagRepository) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	result, err := r.queries.RenameTag(ctx, RenameTagParams{ID: id, Name: name})
+	if err != nil {
+		if isSQLiteError(err, sqliteErrConstraintUnique) {
+			return nil, domain.ErrTagExists
+		}
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	// SQLite counts matched rows, so an unchanged name still affects one
+	if err := requireAffected(result, domain.ErrTagNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a tag and detaches it from every todo. It returns
+// domain.ErrTagNotFound if the tag does not exist.
+func (r *TagRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteTag(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return requireAffected(result, domain.ErrTagNotFound)
+}
+
+// toDomainTag converts a Tag to domain.Tag
+func toDomainTag(t Tag) domain.Tag {
+	return domain.Tag{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt.UTC()}
+}
@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// maxTxAttempts bounds how often a transaction that found the database
+	// busy is run
+	maxTxAttempts = 3
+	// txRetryDelay is the base delay before running a busy transaction again;
+	// it grows with every attempt and is jittered
+	txRetryDelay = 20 * time.Millisecond
+)
+
+// UnitOfWork runs repository operations in SQLite transactions. The zero
+// depth unit begins transactions; the units handed to a transaction's
+// function nest savepoints inside it.
+type UnitOfWork struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	depth int
+}
+
+// NewUnitOfWork creates a unit of work beginning transactions on db
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction, retrying it when the database stayed locked
+// by other writers for longer than the busy timeout. Within a transaction it
+// runs fn under a savepoint instead.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	if u.tx != nil {
+		return u.savepoint(ctx, fn)
+	}
+	return retryBusy(ctx, func() error {
+		return u.transaction(ctx, fn)
+	})
+}
+
+// transaction runs fn in a new transaction
+func (u *UnitOfWork) transaction(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// A no-op once committed; otherwise it undoes a failed or panicking fn
+	defer tx.Rollback()
+
+	if err := fn(repositories(&UnitOfWork{db: u.db, tx: tx})); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs fn under a savepoint of the current transaction
+func (u *UnitOfWork) savepoint(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	name := fmt.Sprintf("sp_%d", u.depth+1)
+	if _, err := u.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to set savepoint: %w", err)
+	}
+
+	if err := fn(repositories(&UnitOfWork{db: u.db, tx: u.tx, depth: u.depth + 1})); err != nil {
+		// ROLLBACK TO leaves the savepoint on SQLite's stack; releasing it keeps
+		// siblings of the same name from piling up
+		if _, rbErr := u.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name+"; RELEASE SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w (after %v)", rbErr, err)
+		}
+		return err
+	}
+	if _, err := u.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// repositories returns the repositories of a unit bound to a transaction
+func repositories(u *UnitOfWork) domain.Repositories {
+	return domain.Repositories{
+		Todos:      newTodoRepository(u.tx),
+		Tags:       &TagRepository{queries: New(u.tx)},
+		Lists:      &ListRepository{queries: New(u.tx)},
+		UnitOfWork: u,
+	}
+}
+
+// retryBusy runs fn until it does not fail because the database is busy, at
+// most maxTxAttempts times, and returns its last error
+func retryBusy(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if attempt == maxTxAttempts || !isSQLiteError(err, sqliteErrBusy) {
+			return err
+		}
+		delay := time.Duration(attempt)*txRetryDelay + time.Duration(rand.Int63n(int64(txRetryDelay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
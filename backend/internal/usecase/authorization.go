@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// tokenKey is the context key of the API token a caller presented
+type tokenKey struct{}
+
+// WithToken returns a copy of ctx carrying the API token the caller
+// presented, for Authorization to check
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// tokenFrom returns the API token carried by ctx, or "" if there is none
+func tokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// Tokens maps API tokens to the access they grant
+type Tokens map[string]domain.Access
+
+// ParseTokens parses a comma-separated list of token:access pairs, such as
+// "s3cret:write,readonly:read". Its errors name a bad pair by its position
+// rather than quote it, since it holds a secret.
+func ParseTokens(s string) (Tokens, error) {
+	tokens := make(Tokens)
+	for i, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		token, name, ok := strings.Cut(pair, ":")
+		if !ok || token == "" {
+			return nil, fmt.Errorf("token %d: must be token:access", i+1)
+		}
+		access, err := domain.ParseAccess(name)
+		if err != nil {
+			return nil, fmt.Errorf("token %d: %w", i+1, err)
+		}
+		tokens[token] = access
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("no tokens")
+	}
+	return tokens, nil
+}
+
+// grant returns the access token grants. Every configured token is
+// compared in constant time, so the time taken does not give away how much
+// of a guess was right.
+func (t Tokens) grant(token string) (domain.Access, bool) {
+	if token == "" {
+		return "", false
+	}
+	var (
+		granted domain.Access
+		found   bool
+	)
+	for candidate, access := range t {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			granted, found = access, true
+		}
+	}
+	return granted, found
+}
+
+// Authorization is a Decorator that only lets a call through if its context
+// carries one of tokens, granting the call's access. It returns
+// domain.ErrInvalidToken for a missing or unknown token and
+// domain.ErrReadOnlyToken for a write with a read-only one.
+func Authorization(tokens Tokens) Decorator {
+	return func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		access, ok := tokens.grant(tokenFrom(ctx))
+		if !ok {
+			return domain.ErrInvalidToken
+		}
+		if !access.Allows(call.Access) {
+			return domain.ErrReadOnlyToken
+		}
+		return next(ctx)
+	}
+}
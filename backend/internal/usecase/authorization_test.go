@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := ParseTokens(" admin:write, viewer:read ,")
+	if err != nil {
+		t.Fatalf("ParseTokens() error = %v", err)
+	}
+	if len(tokens) != 2 || tokens["admin"] != domain.AccessWrite || tokens["viewer"] != domain.AccessRead {
+		t.Errorf("ParseTokens() = %v", tokens)
+	}
+
+	for _, s := range []string{"", " , ", "admin", ":write", "admin:root", "viewer:read,secret:admin"} {
+		_, err := ParseTokens(s)
+		if err == nil {
+			t.Errorf("ParseTokens(%q) error = nil, want an error", s)
+			continue
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("ParseTokens(%q) error = %q, which gives the token away", s, err)
+		}
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	tokens := Tokens{"admin": domain.AccessWrite, "viewer": domain.AccessRead}
+	svc := DecorateTagService(NewTagUsecase(newMockTagRepo()), Authorization(tokens))
+	background := context.Background()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantRead  error
+		wantWrite error
+	}{
+		{name: "no token", ctx: background, wantRead: domain.ErrInvalidToken, wantWrite: domain.ErrInvalidToken},
+		{name: "empty token", ctx: WithToken(background, ""), wantRead: domain.ErrInvalidToken, wantWrite: domain.ErrInvalidToken},
+		{name: "unknown token", ctx: WithToken(background, "admin2"), wantRead: domain.ErrInvalidToken, wantWrite: domain.ErrInvalidToken},
+		{name: "read token", ctx: WithToken(background, "viewer"), wantWrite: domain.ErrReadOnlyToken},
+		{name: "write token", ctx: WithToken(background, "admin")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.List(tt.ctx); !errors.Is(err, tt.wantRead) {
+				t.Errorf("List() error = %v, want %v", err, tt.wantRead)
+			}
+			tag, err := svc.Create(tt.ctx, tt.name)
+			if !errors.Is(err, tt.wantWrite) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantWrite)
+			}
+			if err == nil && tag.Name != tt.name {
+				t.Errorf("Create() name = %q, want %q", tag.Name, tt.name)
+			}
+		})
+	}
+}
+
+func TestAuthorization_ErrorKinds(t *testing.T) {
+	if !errors.Is(domain.ErrInvalidToken, domain.ErrUnauthenticated) {
+		t.Error("ErrInvalidToken is not of kind ErrUnauthenticated")
+	}
+	if !errors.Is(domain.ErrReadOnlyToken, domain.ErrForbidden) {
+		t.Error("ErrReadOnlyToken is not of kind ErrForbidden")
+	}
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Batch runs a list of create, update and delete operations in a single
+// transaction and returns one result per operation, in order. In atomic mode
+// the first failing operation rolls the whole batch back: it keeps its error
+// and every other operation gets domain.ErrBatchAborted. In best-effort mode
+// each operation runs under its own savepoint, so a failure undoes only that
+// operation. Operations see the effects of the ones before them. It returns a
+// *domain.ValidationError for an empty or oversized batch or an unknown mode
+// or action. A failure of the storage rather than of an operation aborts the
+// batch in either mode and is returned as the error.
+func (u *TodoUsecase) Batch(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error) {
+	if err := validateBatch(ops, mode); err != nil {
+		return nil, err
+	}
+
+	var results []domain.BatchResult
+	failed := -1
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		results = make([]domain.BatchResult, len(ops))
+		failed = -1
+		for i, op := range ops {
+			if mode == domain.BatchAtomic {
+				results[i].Todo, results[i].Err = tx.applyBatchOperation(ctx, op)
+				if results[i].Err != nil {
+					if isDomainError(results[i].Err) {
+						failed = i
+					}
+					return results[i].Err
+				}
+				continue
+			}
+			err := tx.inTx(ctx, func(tx *TodoUsecase) error {
+				var err error
+				results[i].Todo, err = tx.applyBatchOperation(ctx, op)
+				return err
+			})
+			if err != nil && !isDomainError(err) {
+				// The storage failed rather than the operation; the
+				// transaction cannot be trusted to carry on
+				return err
+			}
+			results[i].Err = err
+		}
+		return nil
+	})
+
+	if failed >= 0 {
+		for i := range results {
+			if i != failed {
+				results[i] = domain.BatchResult{Err: domain.ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// validateBatch checks the shape of a batch before any operation runs
+func validateBatch(ops []domain.BatchOperation, mode domain.BatchMode) error {
+	invalid := &domain.ValidationError{}
+	if !mode.IsValid() {
+		invalid.Add("mode", "mode must be atomic or best_effort")
+	}
+	if len(ops) == 0 || len(ops) > domain.MaxBatchSize {
+		invalid.Add("operations", fmt.Sprintf("operations must hold 1 to %d operations", domain.MaxBatchSize))
+	}
+	for i, op := range ops {
+		field := fmt.Sprintf("operations[%d]", i)
+		if !op.Action.IsValid() {
+			invalid.Add(field+".op", field+".op must be create, update or delete")
+		}
+		if op.Action != domain.BatchCreate && op.ID == "" {
+			invalid.Add(field+".id", field+".id is required")
+		}
+	}
+	return invalid.Err()
+}
+
+// applyBatchOperation runs a single batch operation
+func (u *TodoUsecase) applyBatchOperation(ctx context.Context, op domain.BatchOperation) (*domain.Todo, error) {
+	switch op.Action {
+	case domain.BatchCreate:
+		return u.create(ctx, op.Create)
+	case domain.BatchUpdate:
+		return u.update(ctx, op.ID, op.Patch)
+	default:
+		return nil, u.delete(ctx, op.ID)
+	}
+}
+
+// isDomainError reports whether err is one of the domain's errors, meaning
+// the operation was refused, rather than a failure of the storage
+func isDomainError(err error) bool {
+	return errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrConflict)
+}
+
+// CompleteAll completes every active todo matching the filter in a single
+// transaction and returns how many it completed. Subtasks are completed with
+// their parents, and completing a recurring todo creates its next
+// occurrence, as with Update. The filter's status is ignored.
+func (u *TodoUsecase) CompleteAll(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	filter.Status = domain.StatusActive
+	completed := 0
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		completed = 0
+		todos, err := tx.repo.List(ctx, domain.TodoQuery{Filter: filter, Sort: domain.DefaultSort})
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			// An earlier todo of the list may have completed this one as its subtask
+			current, err := tx.repo.GetByID(ctx, todo.ID)
+			if err != nil {
+				return err
+			}
+			if current.IsCompleted {
+				continue
+			}
+			if _, err := tx.update(ctx, todo.ID, domain.TodoPatch{IsCompleted: domain.Some(true)}); err != nil {
+				return err
+			}
+			completed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return completed, nil
+}
+
+// ClearCompleted moves every completed todo matching the filter to the trash
+// in a single transaction, each together with its subtasks, and returns how
+// many todos it trashed, subtasks included. The filter's status is ignored.
+func (u *TodoUsecase) ClearCompleted(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	filter.Status = domain.StatusCompleted
+	trashed := 0
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		todos, err := tx.repo.List(ctx, domain.TodoQuery{Filter: filter, Sort: domain.DefaultSort})
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		var ids []string
+		for _, todo := range todos {
+			if seen[todo.ID] {
+				continue
+			}
+			subtree, err := tx.repo.Subtree(ctx, todo.ID)
+			if err != nil {
+				return err
+			}
+			for _, t := range subtree {
+				if !seen[t.ID] {
+					seen[t.ID] = true
+					ids = append(ids, t.ID)
+				}
+			}
+		}
+		trashed = len(ids)
+		if len(ids) == 0 {
+			return nil
+		}
+		// TIMESTAMP columns keep whole seconds
+		return tx.repo.Trash(ctx, ids, uuid.NewString(), time.Now().UTC().Truncate(time.Second))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return trashed, nil
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"testing"
+)
+
+// batchOps creates a todo, completes "1", fails to delete a missing todo and deletes "2"
+func batchOps() []domain.BatchOperation {
+	return []domain.BatchOperation{
+		{Action: domain.BatchCreate, Create: domain.CreateTodoParams{Title: "New"}},
+		{Action: domain.BatchUpdate, ID: "1", Patch: domain.TodoPatch{IsCompleted: domain.Some(true)}},
+		{Action: domain.BatchDelete, ID: "nonexistent"},
+		{Action: domain.BatchDelete, ID: "2"},
+	}
+}
+
+func TestTodoUsecase_Batch(t *testing.T) {
+	tests := []struct {
+		name string
+		mode domain.BatchMode
+		// wantErrs is the error expected for each operation, nil for success
+		wantErrs  []error
+		wantTodos int
+		wantDone  bool
+	}{
+		{
+			name:      "atomic batch rolls back every operation",
+			mode:      domain.BatchAtomic,
+			wantErrs:  []error{domain.ErrBatchAborted, domain.ErrBatchAborted, domain.ErrTodoNotFound, domain.ErrBatchAborted},
+			wantTodos: 2,
+		},
+		{
+			name:      "best-effort batch keeps the operations that succeeded",
+			mode:      domain.BatchBestEffort,
+			wantErrs:  []error{nil, nil, domain.ErrTodoNotFound, nil},
+			wantTodos: 2,
+			wantDone:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			repo.todos["1"] = &domain.Todo{ID: "1", Title: "First"}
+			repo.todos["2"] = &domain.Todo{ID: "2", Title: "Second"}
+			usecase := NewTodoUsecase(repo, repo)
+			ctx := context.Background()
+
+			results, err := usecase.Batch(ctx, batchOps(), tt.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != len(tt.wantErrs) {
+				t.Fatalf("expected %d results, got %d", len(tt.wantErrs), len(results))
+			}
+			for i, want := range tt.wantErrs {
+				if !errors.Is(results[i].Err, want) || (want == nil && results[i].Err != nil) {
+					t.Errorf("operation %d: expected error %v, got %v", i, want, results[i].Err)
+				}
+			}
+
+			page, err := usecase.List(ctx, domain.TodoQuery{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(page.Todos) != tt.wantTodos {
+				t.Errorf("expected %d todos, got %d", tt.wantTodos, len(page.Todos))
+			}
+			first, err := usecase.Get(ctx, "1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if first.IsCompleted != tt.wantDone {
+				t.Errorf("expected IsCompleted=%v, got %v", tt.wantDone, first.IsCompleted)
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_Batch_SeesEarlierOperations(t *testing.T) {
+	repo := newMockRepo()
+	usecase := NewTodoUsecase(repo, repo)
+
+	results, err := usecase.Batch(context.Background(), []domain.BatchOperation{
+		{Action: domain.BatchCreate, Create: domain.CreateTodoParams{Title: "First"}},
+		{Action: domain.BatchCreate, Create: domain.CreateTodoParams{Title: "Second"}},
+	}, domain.BatchAtomic)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Todo.Position >= results[1].Todo.Position {
+		t.Errorf("expected the second todo after the first, got positions %q and %q",
+			results[0].Todo.Position, results[1].Todo.Position)
+	}
+}
+
+func TestTodoUsecase_Batch_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []domain.BatchOperation
+		mode domain.BatchMode
+	}{
+		{name: "rejects an empty batch", mode: domain.BatchAtomic},
+		{
+			name: "rejects an oversized batch",
+			ops:  make([]domain.BatchOperation, domain.MaxBatchSize+1),
+			mode: domain.BatchAtomic,
+		},
+		{
+			name: "rejects an unknown mode",
+			ops:  []domain.BatchOperation{{Action: domain.BatchDelete, ID: "1"}},
+			mode: "sometimes",
+		},
+		{
+			name: "rejects an unknown action",
+			ops:  []domain.BatchOperation{{Action: "archive", ID: "1"}},
+			mode: domain.BatchAtomic,
+		},
+		{
+			name: "rejects an update without ID",
+			ops:  []domain.BatchOperation{{Action: domain.BatchUpdate}},
+			mode: domain.BatchAtomic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			usecase := NewTodoUsecase(repo, repo)
+
+			_, err := usecase.Batch(context.Background(), tt.ops, tt.mode)
+			var validation *domain.ValidationError
+			if !errors.As(err, &validation) {
+				t.Errorf("expected a ValidationError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_CompleteAll(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	repo.todos["other"] = &domain.Todo{ID: "other", Title: "Other"}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	n, err := usecase.CompleteAll(ctx, domain.TodoFilter{ListID: "work", Status: domain.StatusCompleted})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// child and grandchild are completed with root rather than on their own
+	if n != 1 {
+		t.Errorf("expected 1 todo completed, got %d", n)
+	}
+	for _, id := range []string{"root", "child", "child2", "grandchild"} {
+		if !repo.todos[id].IsCompleted {
+			t.Errorf("expected %s to be completed", id)
+		}
+	}
+	if repo.todos["other"].IsCompleted {
+		t.Error("expected a todo outside the filter to stay active")
+	}
+}
+
+func TestTodoUsecase_ClearCompleted(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	done := "done"
+	repo.todos["done"] = &domain.Todo{ID: "done", Title: "Done", IsCompleted: true}
+	repo.todos["done-child"] = &domain.Todo{ID: "done-child", Title: "Done child", IsCompleted: true, ParentID: &done}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	n, err := usecase.ClearCompleted(ctx, domain.TodoFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 todos trashed, got %d", n)
+	}
+	for _, id := range []string{"child2", "done", "done-child"} {
+		if repo.todos[id].DeletedAt == nil {
+			t.Errorf("expected %s to be trashed", id)
+		}
+	}
+	if !repo.todos["done"].DeletedAt.Equal(*repo.todos["done-child"].DeletedAt) {
+		t.Error("expected a todo and its subtasks to share the trash time")
+	}
+	if repo.todos["root"].DeletedAt != nil {
+		t.Error("expected an active todo to stay")
+	}
+}
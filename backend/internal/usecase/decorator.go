@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+)
+
+// Call describes a service method call to the decorators around it
+type Call struct {
+	// Service and Method name the call, as in TodoService.Create
+	Service string
+	Method  string
+	// Access is whether the call only reads or also writes
+	Access domain.Access
+}
+
+// String returns the qualified method name of the call
+func (c Call) String() string {
+	return c.Service + "." + c.Method
+}
+
+// Decorator runs around every call of a decorated service. It calls next to
+// go on with the call, possibly with a derived context, and returns its
+// error; or it refuses the call by returning an error without calling next.
+type Decorator func(ctx context.Context, call Call, next func(ctx context.Context) error) error
+
+// decorate runs fn, a call returning a result, through d
+func decorate[T any](ctx context.Context, d Decorator, call Call, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := d(ctx, call, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// DecorateTodoService wraps s in decorators. The first one is the outermost:
+// it sees each call first and its outcome last.
+func DecorateTodoService(s TodoService, decorators ...Decorator) TodoService {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		s = &decoratedTodoService{next: s, d: decorators[i]}
+	}
+	return s
+}
+
+// DecorateTagService wraps s in decorators like DecorateTodoService
+func DecorateTagService(s TagService, decorators ...Decorator) TagService {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		s = &decoratedTagService{next: s, d: decorators[i]}
+	}
+	return s
+}
+
+// DecorateListService wraps s in decorators like DecorateTodoService
+func DecorateListService(s ListService, decorators ...Decorator) ListService {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		s = &decoratedListService{next: s, d: decorators[i]}
+	}
+	return s
+}
+
+// decoratedTodoService runs every call of next through d
+type decoratedTodoService struct {
+	next TodoService
+	d    Decorator
+}
+
+func todoCall(method string, access domain.Access) Call {
+	return Call{Service: "TodoService", Method: method, Access: access}
+}
+
+func (s *decoratedTodoService) List(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+	return decorate(ctx, s.d, todoCall("List", domain.AccessRead), func(ctx context.Context) (*domain.TodoPage, error) {
+		return s.next.List(ctx, query)
+	})
+}
+
+func (s *decoratedTodoService) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("Search", domain.AccessRead), func(ctx context.Context) ([]domain.Todo, error) {
+		return s.next.Search(ctx, search)
+	})
+}
+
+func (s *decoratedTodoService) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	return decorate(ctx, s.d, todoCall("CountByPriority", domain.AccessRead), func(ctx context.Context) (map[domain.Priority]int, error) {
+		return s.next.CountByPriority(ctx, filter)
+	})
+}
+
+func (s *decoratedTodoService) Get(ctx context.Context, id string) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("Get", domain.AccessRead), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.Get(ctx, id)
+	})
+}
+
+func (s *decoratedTodoService) GetSubtree(ctx context.Context, id string) (*domain.TodoTree, error) {
+	return decorate(ctx, s.d, todoCall("GetSubtree", domain.AccessRead), func(ctx context.Context) (*domain.TodoTree, error) {
+		return s.next.GetSubtree(ctx, id)
+	})
+}
+
+func (s *decoratedTodoService) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("Create", domain.AccessWrite), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.Create(ctx, params)
+	})
+}
+
+func (s *decoratedTodoService) Update(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("Update", domain.AccessWrite), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.Update(ctx, id, patch)
+	})
+}
+
+func (s *decoratedTodoService) Move(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("Move", domain.AccessWrite), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.Move(ctx, id, move)
+	})
+}
+
+func (s *decoratedTodoService) AttachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("AttachTag", domain.AccessWrite), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.AttachTag(ctx, todoID, tagID)
+	})
+}
+
+func (s *decoratedTodoService) DetachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("DetachTag", domain.AccessWrite), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.DetachTag(ctx, todoID, tagID)
+	})
+}
+
+func (s *decoratedTodoService) Delete(ctx context.Context, id string) error {
+	return s.d(ctx, todoCall("Delete", domain.AccessWrite), func(ctx context.Context) error {
+		return s.next.Delete(ctx, id)
+	})
+}
+
+func (s *decoratedTodoService) Batch(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error) {
+	return decorate(ctx, s.d, todoCall("Batch", domain.AccessWrite), func(ctx context.Context) ([]domain.BatchResult, error) {
+		return s.next.Batch(ctx, ops, mode)
+	})
+}
+
+func (s *decoratedTodoService) CompleteAll(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	return decorate(ctx, s.d, todoCall("CompleteAll", domain.AccessWrite), func(ctx context.Context) (int, error) {
+		return s.next.CompleteAll(ctx, filter)
+	})
+}
+
+func (s *decoratedTodoService) ClearCompleted(ctx context.Context, filter domain.TodoFilter) (int, error) {
+	return decorate(ctx, s.d, todoCall("ClearCompleted", domain.AccessWrite), func(ctx context.Context) (int, error) {
+		return s.next.ClearCompleted(ctx, filter)
+	})
+}
+
+func (s *decoratedTodoService) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("ListTrash", domain.AccessRead), func(ctx context.Context) ([]domain.Todo, error) {
+		return s.next.ListTrash(ctx)
+	})
+}
+
+func (s *decoratedTodoService) Restore(ctx context.Context, id string) (*domain.Todo, error) {
+	return decorate(ctx, s.d, todoCall("Restore", domain.AccessWrite), func(ctx context.Context) (*domain.Todo, error) {
+		return s.next.Restore(ctx, id)
+	})
+}
+
+func (s *decoratedTodoService) Purge(ctx context.Context, id string) error {
+	return s.d(ctx, todoCall("Purge", domain.AccessWrite), func(ctx context.Context) error {
+		return s.next.Purge(ctx, id)
+	})
+}
+
+func (s *decoratedTodoService) EmptyTrash(ctx context.Context) (int, error) {
+	return decorate(ctx, s.d, todoCall("EmptyTrash", domain.AccessWrite), func(ctx context.Context) (int, error) {
+		return s.next.EmptyTrash(ctx)
+	})
+}
+
+// decoratedTagService runs every call of next through d
+type decoratedTagService struct {
+	next TagService
+	d    Decorator
+}
+
+func tagCall(method string, access domain.Access) Call {
+	return Call{Service: "TagService", Method: method, Access: access}
+}
+
+func (s *decoratedTagService) List(ctx context.Context) ([]domain.Tag, error) {
+	return decorate(ctx, s.d, tagCall("List", domain.AccessRead), func(ctx context.Context) ([]domain.Tag, error) {
+		return s.next.List(ctx)
+	})
+}
+
+func (s *decoratedTagService) Get(ctx context.Context, id string) (*domain.Tag, error) {
+	return decorate(ctx, s.d, tagCall("Get", domain.AccessRead), func(ctx context.Context) (*domain.Tag, error) {
+		return s.next.Get(ctx, id)
+	})
+}
+
+func (s *decoratedTagService) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	return decorate(ctx, s.d, tagCall("Create", domain.AccessWrite), func(ctx context.Context) (*domain.Tag, error) {
+		return s.next.Create(ctx, name)
+	})
+}
+
+func (s *decoratedTagService) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	return decorate(ctx, s.d, tagCall("Rename", domain.AccessWrite), func(ctx context.Context) (*domain.Tag, error) {
+		return s.next.Rename(ctx, id, name)
+	})
+}
+
+func (s *decoratedTagService) Delete(ctx context.Context, id string) error {
+	return s.d(ctx, tagCall("Delete", domain.AccessWrite), func(ctx context.Context) error {
+		return s.next.Delete(ctx, id)
+	})
+}
+
+// decoratedListService runs every call of next through d
+type decoratedListService struct {
+	next ListService
+	d    Decorator
+}
+
+func listCall(method string, access domain.Access) Call {
+	return Call{Service: "ListService", Method: method, Access: access}
+}
+
+func (s *decoratedListService) List(ctx context.Context) ([]domain.List, error) {
+	return decorate(ctx, s.d, listCall("List", domain.AccessRead), func(ctx context.Context) ([]domain.List, error) {
+		return s.next.List(ctx)
+	})
+}
+
+func (s *decoratedListService) Get(ctx context.Context, id string) (*domain.List, error) {
+	return decorate(ctx, s.d, listCall("Get", domain.AccessRead), func(ctx context.Context) (*domain.List, error) {
+		return s.next.Get(ctx, id)
+	})
+}
+
+func (s *decoratedListService) Create(ctx context.Context, name string) (*domain.List, error) {
+	return decorate(ctx, s.d, listCall("Create", domain.AccessWrite), func(ctx context.Context) (*domain.List, error) {
+		return s.next.Create(ctx, name)
+	})
+}
+
+func (s *decoratedListService) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	return decorate(ctx, s.d, listCall("Rename", domain.AccessWrite), func(ctx context.Context) (*domain.List, error) {
+		return s.next.Rename(ctx, id, name)
+	})
+}
+
+func (s *decoratedListService) Delete(ctx context.Context, id string) error {
+	return s.d(ctx, listCall("Delete", domain.AccessWrite), func(ctx context.Context) error {
+		return s.next.Delete(ctx, id)
+	})
+}
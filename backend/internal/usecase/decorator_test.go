@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"strings"
+	"testing"
+)
+
+// recordingDecorator appends "<name> <call>" to log before and "<name> done"
+// after every call, and refuses calls with refuse if it is set
+func recordingDecorator(name string, calls *[]string, refuse error) Decorator {
+	return func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		*calls = append(*calls, name+" "+call.String()+" "+string(call.Access))
+		if refuse != nil {
+			return refuse
+		}
+		err := next(ctx)
+		*calls = append(*calls, name+" done")
+		return err
+	}
+}
+
+func TestDecorateTagService_Order(t *testing.T) {
+	var calls []string
+	svc := DecorateTagService(NewTagUsecase(newMockTagRepo()),
+		recordingDecorator("outer", &calls, nil),
+		recordingDecorator("inner", &calls, nil),
+	)
+
+	tag, err := svc.Create(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if tag.Name != "work" {
+		t.Errorf("Create() name = %q, want %q", tag.Name, "work")
+	}
+
+	want := []string{"outer TagService.Create write", "inner TagService.Create write", "inner done", "outer done"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestDecorateTodoService_Refused(t *testing.T) {
+	repo := newMockRepo()
+	var calls []string
+	refused := errors.New("refused")
+	svc := DecorateTodoService(NewTodoUsecase(repo, repo), recordingDecorator("guard", &calls, refused))
+
+	todo, err := svc.Create(context.Background(), domain.CreateTodoParams{Title: "Buy milk"})
+	if !errors.Is(err, refused) {
+		t.Errorf("Create() error = %v, want %v", err, refused)
+	}
+	if todo != nil {
+		t.Errorf("Create() = %+v, want nil", todo)
+	}
+	if repo.createCount != 0 {
+		t.Errorf("repository Create called %d times, want 0", repo.createCount)
+	}
+}
+
+func TestDecorateTodoService_Access(t *testing.T) {
+	repo := newMockRepo()
+	var calls []string
+	svc := DecorateTodoService(NewTodoUsecase(repo, repo), recordingDecorator("d", &calls, nil))
+	ctx := context.Background()
+
+	if _, err := svc.List(ctx, domain.TodoQuery{Sort: domain.DefaultSort}); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if _, err := svc.Get(ctx, "missing"); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, domain.ErrTodoNotFound)
+	}
+	if err := svc.Delete(ctx, "missing"); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("Delete() error = %v, want %v", err, domain.ErrTodoNotFound)
+	}
+
+	want := []string{"d TodoService.List read", "d done", "d TodoService.Get read", "d done", "d TodoService.Delete write", "d done"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestDecorateListService_NoDecorators(t *testing.T) {
+	u := NewListUsecase(newMockListRepo())
+	if svc := DecorateListService(u); svc != ListService(u) {
+		t.Errorf("DecorateListService() without decorators = %T, want the service itself", svc)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	svc := DecorateTagService(NewTagUsecase(newMockTagRepo()), Logging(log.New(&buf, "", 0)))
+	ctx := context.Background()
+
+	if _, err := svc.Create(ctx, "work"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Get(ctx, "missing"); err == nil {
+		t.Fatal("Get() error = nil, want an error")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %q, want two lines", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "TagService.Create completed in ") {
+		t.Errorf("first line = %q, want the completed Create", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "TagService.Get failed in ") || !strings.HasSuffix(lines[1], ": tag not found") {
+		t.Errorf("second line = %q, want the failed Get with its error", lines[1])
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := new(expvar.Map).Init()
+	svc := DecorateTagService(NewTagUsecase(newMockTagRepo()), Metrics(m))
+	ctx := context.Background()
+
+	for _, name := range []string{"work", "home", "work"} {
+		svc.Create(ctx, name)
+	}
+	svc.List(ctx)
+
+	for key, want := range map[string]string{
+		"TagService.Create.calls":  "3",
+		"TagService.Create.errors": "1",
+		"TagService.List.calls":    "1",
+	} {
+		if got := m.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s = %v, want %s", key, got, want)
+		}
+	}
+	if got := m.Get("TagService.List.errors"); got != nil {
+		t.Errorf("TagService.List.errors = %v, want unset", got)
+	}
+	if got := m.Get("TagService.Create.seconds"); got == nil {
+		t.Error("TagService.Create.seconds is not set")
+	}
+}
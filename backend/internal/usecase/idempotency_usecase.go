@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyLockTimeout is how long a claimed key waits for its request to
+// finish. A claim left behind by a crashed server lapses after it, so the
+// request can be retried rather than being reported as in flight forever.
+const idempotencyLockTimeout = time.Minute
+
+// IdempotencyUsecase remembers the responses to requests that carry an
+// idempotency key, so that a retried request is answered without running again
+type IdempotencyUsecase struct {
+	repo domain.IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyUsecase creates a new IdempotencyUsecase that keeps responses for ttl
+func NewIdempotencyUsecase(repo domain.IdempotencyRepository, ttl time.Duration) *IdempotencyUsecase {
+	return &IdempotencyUsecase{repo: repo, ttl: ttl}
+}
+
+// Begin claims key for a request whose method, target and content hash to
+// requestHash. When the caller now holds the key it returns the token of its
+// claim, with which it must Complete or Release it; when the same request
+// already completed it returns the stored response. It returns
+// domain.ErrIdempotencyKeyReused when the key was used for a different
+// request and domain.ErrIdempotencyKeyInFlight while the first request is
+// still running.
+func (u *IdempotencyUsecase) Begin(ctx context.Context, key string, requestHash string) (*domain.StoredResponse, string, error) {
+	now := time.Now()
+	token := uuid.NewString()
+	held, claimed, err := u.repo.Claim(ctx, domain.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Token:       token,
+		ExpiresAt:   now.Add(idempotencyLockTimeout),
+	}, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if claimed {
+		return nil, token, nil
+	}
+	if held.RequestHash != requestHash {
+		return nil, "", domain.ErrIdempotencyKeyReused
+	}
+	if held.Response == nil {
+		return nil, "", domain.ErrIdempotencyKeyInFlight
+	}
+	return held.Response, "", nil
+}
+
+// Complete stores the response to the request that claimed key with token
+func (u *IdempotencyUsecase) Complete(ctx context.Context, key string, token string, response domain.StoredResponse) error {
+	return u.repo.Complete(ctx, key, token, response, time.Now().Add(u.ttl))
+}
+
+// Release gives up a claim whose request failed, so a retry runs it again.
+// A claim that lapsed in the meantime and was taken over is left alone.
+func (u *IdempotencyUsecase) Release(ctx context.Context, key string, token string) error {
+	return u.repo.Release(ctx, key, token)
+}
+
+// RunPurge deletes expired idempotency keys every interval until ctx is
+// done. Failures are logged and retried on the next tick.
+func (u *IdempotencyUsecase) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := u.repo.PurgeExpired(ctx, time.Now())
+		if err != nil {
+			log.Printf("Failed to purge idempotency keys: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d expired idempotency keys", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
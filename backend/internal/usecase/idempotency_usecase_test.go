@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mockIdempotencyRepository is a mock implementation of domain.IdempotencyRepository
+type mockIdempotencyRepository struct {
+	records map[string]domain.IdempotencyRecord
+}
+
+func newMockIdempotencyRepo() *mockIdempotencyRepository {
+	return &mockIdempotencyRepository{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (m *mockIdempotencyRepository) Claim(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, bool, error) {
+	if held, ok := m.records[record.Key]; ok && held.ExpiresAt.After(now) {
+		return &held, false, nil
+	}
+	m.records[record.Key] = record
+	return nil, true, nil
+}
+
+func (m *mockIdempotencyRepository) Complete(ctx context.Context, key string, token string, response domain.StoredResponse, expiresAt time.Time) error {
+	record, ok := m.records[key]
+	if !ok || record.Token != token || record.Response != nil {
+		return errors.New("not claimed")
+	}
+	record.Response = &response
+	record.ExpiresAt = expiresAt
+	m.records[key] = record
+	return nil
+}
+
+func (m *mockIdempotencyRepository) Release(ctx context.Context, key string, token string) error {
+	if record, ok := m.records[key]; ok && record.Token == token && record.Response == nil {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func (m *mockIdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	n := 0
+	for key, record := range m.records {
+		if !record.ExpiresAt.After(now) {
+			delete(m.records, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestIdempotencyUsecase_Begin(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	usecase := NewIdempotencyUsecase(repo, time.Hour)
+	ctx := context.Background()
+
+	stored, token, err := usecase.Begin(ctx, "key", "hash")
+	if err != nil || stored != nil || token == "" {
+		t.Fatalf("expected the first request to claim the key, got %+v, %q, %v", stored, token, err)
+	}
+
+	if _, _, err := usecase.Begin(ctx, "key", "hash"); !errors.Is(err, domain.ErrIdempotencyKeyInFlight) {
+		t.Errorf("expected ErrIdempotencyKeyInFlight while the first request runs, got %v", err)
+	}
+	if _, _, err := usecase.Begin(ctx, "key", "other"); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused for another request, got %v", err)
+	}
+
+	response := domain.StoredResponse{Status: 201, Header: map[string]string{"ETag": `"1"`}, Body: []byte(`{"id":"1"}`)}
+	if err := usecase.Complete(ctx, "key", token, response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, _, err = usecase.Begin(ctx, "key", "hash")
+	if err != nil || stored == nil || stored.Status != 201 || string(stored.Body) != `{"id":"1"}` {
+		t.Errorf("expected the stored response to be replayed, got %+v, %v", stored, err)
+	}
+	if _, _, err := usecase.Begin(ctx, "key", "other"); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused after completion, got %v", err)
+	}
+}
+
+func TestIdempotencyUsecase_Release(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	usecase := NewIdempotencyUsecase(repo, time.Hour)
+	ctx := context.Background()
+
+	_, token, err := usecase.Begin(ctx, "key", "hash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := usecase.Release(ctx, "key", token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, _, err := usecase.Begin(ctx, "key", "hash")
+	if err != nil || stored != nil {
+		t.Errorf("expected a released key to be claimed again, got %+v, %v", stored, err)
+	}
+}
+
+func TestIdempotencyUsecase_TakenOverClaim(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	usecase := NewIdempotencyUsecase(repo, time.Hour)
+	ctx := context.Background()
+
+	_, first, err := usecase.Begin(ctx, "key", "hash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The first claim lapses and a retry of the same request takes it over
+	record := repo.records["key"]
+	record.ExpiresAt = time.Now().Add(-time.Second)
+	repo.records["key"] = record
+	_, second, err := usecase.Begin(ctx, "key", "hash")
+	if err != nil || second == "" || second == first {
+		t.Fatalf("expected the retry to claim the lapsed key, got %q, %v", second, err)
+	}
+
+	// The first request failing afterwards must not free the retry's claim
+	if err := usecase.Release(ctx, "key", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := usecase.Begin(ctx, "key", "hash"); !errors.Is(err, domain.ErrIdempotencyKeyInFlight) {
+		t.Errorf("expected the retry to still hold the key, got %v", err)
+	}
+	if err := usecase.Complete(ctx, "key", first, domain.StoredResponse{Status: 500}); err == nil {
+		t.Error("expected the lapsed claim not to complete the retry's")
+	}
+	if err := usecase.Complete(ctx, "key", second, domain.StoredResponse{Status: 201}); err != nil {
+		t.Errorf("expected the retry to complete its claim, got %v", err)
+	}
+}
+
+func TestIdempotencyUsecase_ExpiredKey(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	repo.records["key"] = domain.IdempotencyRecord{
+		Key:         "key",
+		RequestHash: "old",
+		Response:    &domain.StoredResponse{Status: 201},
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+	usecase := NewIdempotencyUsecase(repo, time.Hour)
+
+	stored, _, err := usecase.Begin(context.Background(), "key", "new")
+	if err != nil || stored != nil {
+		t.Errorf("expected an expired key to be claimed by a new request, got %+v, %v", stored, err)
+	}
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+)
+
+// ListUsecase handles business logic for todo lists
+type ListUsecase struct {
+	repo domain.ListRepository
+}
+
+// NewListUsecase creates a new ListUsecase
+func NewListUsecase(repo domain.ListRepository) *ListUsecase {
+	return &ListUsecase{repo: repo}
+}
+
+// List returns all lists with their todo counts
+func (u *ListUsecase) List(ctx context.Context) ([]domain.List, error) {
+	return u.repo.List(ctx)
+}
+
+// Get returns a list by ID, or domain.ErrListNotFound if it does not exist
+func (u *ListUsecase) Get(ctx context.Context, id string) (*domain.List, error) {
+	return u.repo.GetByID(ctx, id)
+}
+
+// Create creates an empty list. It returns a *domain.ValidationError for an invalid name.
+func (u *ListUsecase) Create(ctx context.Context, name string) (*domain.List, error) {
+	name, err := domain.NormalizeName(name, domain.ListNameLimit)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.Create(ctx, name)
+}
+
+// Rename renames a list. It returns a *domain.ValidationError for an invalid
+// name and domain.ErrListNotFound when the list does not exist.
+func (u *ListUsecase) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	name, err := domain.NormalizeName(name, domain.ListNameLimit)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.Rename(ctx, id, name)
+}
+
+// Delete deletes an empty list. A list that still holds todos, including
+// todos in the trash, is kept and domain.ErrListNotEmpty returned, so that
+// deleting a list never removes todos without going through the trash. It
+// returns domain.ErrDefaultList for the default list and
+// domain.ErrListNotFound when the list does not exist.
+func (u *ListUsecase) Delete(ctx context.Context, id string) error {
+	if id == domain.DefaultListID {
+		return domain.ErrDefaultList
+	}
+	return u.repo.Delete(ctx, id)
+}
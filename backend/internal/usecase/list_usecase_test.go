@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"testing"
+)
+
+// mockListRepository is a mock implementation of domain.ListRepository
+type mockListRepository struct {
+	lists map[string]*domain.List
+}
+
+func newMockListRepo() *mockListRepository {
+	return &mockListRepository{
+		lists: map[string]*domain.List{
+			domain.DefaultListID: {ID: domain.DefaultListID, Name: "Inbox", IsDefault: true},
+		},
+	}
+}
+
+func (m *mockListRepository) List(ctx context.Context) ([]domain.List, error) {
+	result := make([]domain.List, 0, len(m.lists))
+	for _, l := range m.lists {
+		result = append(result, *l)
+	}
+	return result, nil
+}
+
+func (m *mockListRepository) GetByID(ctx context.Context, id string) (*domain.List, error) {
+	list, ok := m.lists[id]
+	if !ok {
+		return nil, domain.ErrListNotFound
+	}
+	return list, nil
+}
+
+func (m *mockListRepository) Create(ctx context.Context, name string) (*domain.List, error) {
+	list := &domain.List{ID: name + "-id", Name: name}
+	m.lists[list.ID] = list
+	return list, nil
+}
+
+func (m *mockListRepository) Rename(ctx context.Context, id string, name string) (*domain.List, error) {
+	list, ok := m.lists[id]
+	if !ok {
+		return nil, domain.ErrListNotFound
+	}
+	list.Name = name
+	return list, nil
+}
+
+func (m *mockListRepository) Delete(ctx context.Context, id string) error {
+	if _, ok := m.lists[id]; !ok {
+		return domain.ErrListNotFound
+	}
+	delete(m.lists, id)
+	return nil
+}
+
+func TestListUsecase_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{
+			name: "deletes list",
+			id:   "work-id",
+		},
+		{
+			name:    "refuses to delete the default list",
+			id:      domain.DefaultListID,
+			wantErr: domain.ErrDefaultList,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockListRepo()
+			repo.lists["work-id"] = &domain.List{ID: "work-id", Name: "work"}
+			usecase := NewListUsecase(repo)
+
+			err := usecase.Delete(context.Background(), tt.id)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if _, ok := repo.lists[tt.id]; !ok {
+					t.Error("expected list to be kept")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if _, ok := repo.lists[tt.id]; ok {
+				t.Error("expected list to be deleted")
+			}
+		})
+	}
+}
+
+func TestListUsecase_Delete_Missing(t *testing.T) {
+	usecase := NewListUsecase(newMockListRepo())
+
+	if err := usecase.Delete(context.Background(), "missing"); !errors.Is(err, domain.ErrListNotFound) {
+		t.Errorf("expected ErrListNotFound, got %v", err)
+	}
+}
+
+func TestListUsecase_Rename(t *testing.T) {
+	repo := newMockListRepo()
+	usecase := NewListUsecase(repo)
+	ctx := context.Background()
+
+	result, err := usecase.Rename(ctx, domain.DefaultListID, "Personal")
+	if err != nil || result == nil || result.Name != "Personal" {
+		t.Errorf("expected renamed list, got %+v, %v", result, err)
+	}
+
+	if _, err := usecase.Rename(ctx, "missing", "x"); !errors.Is(err, domain.ErrListNotFound) {
+		t.Errorf("expected ErrListNotFound for missing list, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Logging is a Decorator that logs every call to logger with how long it
+// took, and its error if it failed
+func Logging(logger *log.Logger) Decorator {
+	return func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		elapsed := time.Since(start)
+		if err != nil {
+			logger.Printf("%s failed in %v: %v", call, elapsed, err)
+		} else {
+			logger.Printf("%s completed in %v", call, elapsed)
+		}
+		return err
+	}
+}
@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+	"expvar"
+	"time"
+)
+
+// Metrics is a Decorator that keeps per-method counters in m, under keys
+// prefixed with the call's qualified name: .calls counts every call, .errors
+// the failed ones and .seconds adds up the time spent in them. Publishing m
+// with expvar makes them part of /debug/vars.
+func Metrics(m *expvar.Map) Decorator {
+	return func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		name := call.String()
+		m.Add(name+".calls", 1)
+		if err != nil {
+			m.Add(name+".errors", 1)
+		}
+		m.AddFloat(name+".seconds", time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+)
+
+// TodoService is what the HTTP layer needs from the todo usecase. The
+// usecase implements it; decorators wrap it with cross-cutting behaviour
+// (see Decorator), and tests can stand in for it without a repository.
+type TodoService interface {
+	List(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error)
+	Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error)
+	CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error)
+	Get(ctx context.Context, id string) (*domain.Todo, error)
+	GetSubtree(ctx context.Context, id string) (*domain.TodoTree, error)
+	Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error)
+	Update(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error)
+	Move(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error)
+	AttachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error)
+	DetachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error)
+	Delete(ctx context.Context, id string) error
+	Batch(ctx context.Context, ops []domain.BatchOperation, mode domain.BatchMode) ([]domain.BatchResult, error)
+	CompleteAll(ctx context.Context, filter domain.TodoFilter) (int, error)
+	ClearCompleted(ctx context.Context, filter domain.TodoFilter) (int, error)
+	ListTrash(ctx context.Context) ([]domain.Todo, error)
+	Restore(ctx context.Context, id string) (*domain.Todo, error)
+	Purge(ctx context.Context, id string) error
+	EmptyTrash(ctx context.Context) (int, error)
+}
+
+// TagService is what the HTTP layer needs from the tag usecase
+type TagService interface {
+	List(ctx context.Context) ([]domain.Tag, error)
+	Get(ctx context.Context, id string) (*domain.Tag, error)
+	Create(ctx context.Context, name string) (*domain.Tag, error)
+	Rename(ctx context.Context, id string, name string) (*domain.Tag, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// ListService is what the HTTP layer needs from the list usecase
+type ListService interface {
+	List(ctx context.Context) ([]domain.List, error)
+	Get(ctx context.Context, id string) (*domain.List, error)
+	Create(ctx context.Context, name string) (*domain.List, error)
+	Rename(ctx context.Context, id string, name string) (*domain.List, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// IdempotencyService is what the HTTP layer needs to make requests safe to
+// retry (see IdempotencyUsecase)
+type IdempotencyService interface {
+	Begin(ctx context.Context, key string, requestHash string) (*domain.StoredResponse, string, error)
+	Complete(ctx context.Context, key string, token string, response domain.StoredResponse) error
+	Release(ctx context.Context, key string, token string) error
+}
+
+var (
+	_ TodoService        = (*TodoUsecase)(nil)
+	_ TagService         = (*TagUsecase)(nil)
+	_ ListService        = (*ListUsecase)(nil)
+	_ IdempotencyService = (*IdempotencyUsecase)(nil)
+)
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+)
+
+// TagUsecase handles business logic for tags
+type TagUsecase struct {
+	repo domain.TagRepository
+}
+
+// NewTagUsecase creates a new TagUsecase
+func NewTagUsecase(repo domain.TagRepository) *TagUsecase {
+	return &TagUsecase{repo: repo}
+}
+
+// List returns all tags ordered by name
+func (u *TagUsecase) List(ctx context.Context) ([]domain.Tag, error) {
+	return u.repo.List(ctx)
+}
+
+// Get returns a tag by ID, or domain.ErrTagNotFound if it does not exist
+func (u *TagUsecase) Get(ctx context.Context, id string) (*domain.Tag, error) {
+	return u.repo.GetByID(ctx, id)
+}
+
+// Create creates a tag. It returns a *domain.ValidationError for an invalid
+// name and domain.ErrTagExists if the name is taken.
+func (u *TagUsecase) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	name, err := domain.NormalizeName(name, domain.TagNameLimit)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.Create(ctx, name)
+}
+
+// Rename renames a tag. It returns a *domain.ValidationError for an invalid
+// name, domain.ErrTagNotFound when the tag does not exist and
+// domain.ErrTagExists if another tag already uses the name.
+func (u *TagUsecase) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	name, err := domain.NormalizeName(name, domain.TagNameLimit)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.Rename(ctx, id, name)
+}
+
+// Delete deletes a tag, detaching it from every todo.
+// It returns domain.ErrTagNotFound when the tag does not exist.
+func (u *TagUsecase) Delete(ctx context.Context, id string) error {
+	return u.repo.Delete(ctx, id)
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockTagRepository is a mock implementation of domain.TagRepository
+type mockTagRepository struct {
+	tags      map[string]*domain.Tag
+	listErr   error
+	createErr error
+}
+
+func newMockTagRepo() *mockTagRepository {
+	return &mockTagRepository{
+		tags: make(map[string]*domain.Tag),
+	}
+}
+
+func (m *mockTagRepository) List(ctx context.Context) ([]domain.Tag, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	result := make([]domain.Tag, 0, len(m.tags))
+	for _, t := range m.tags {
+		result = append(result, *t)
+	}
+	return result, nil
+}
+
+func (m *mockTagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tag, ok := m.tags[id]
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	return tag, nil
+}
+
+func (m *mockTagRepository) nameTaken(name string, exceptID string) bool {
+	for _, t := range m.tags {
+		if t.ID != exceptID && t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockTagRepository) Create(ctx context.Context, name string) (*domain.Tag, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	if m.nameTaken(name, "") {
+		return nil, domain.ErrTagExists
+	}
+	tag := &domain.Tag{ID: name + "-id", Name: name, CreatedAt: time.Now()}
+	m.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (m *mockTagRepository) Rename(ctx context.Context, id string, name string) (*domain.Tag, error) {
+	tag, ok := m.tags[id]
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	if m.nameTaken(name, id) {
+		return nil, domain.ErrTagExists
+	}
+	tag.Name = name
+	return tag, nil
+}
+
+func (m *mockTagRepository) Delete(ctx context.Context, id string) error {
+	if _, ok := m.tags[id]; !ok {
+		return domain.ErrTagNotFound
+	}
+	delete(m.tags, id)
+	return nil
+}
+
+func TestTagUsecase_Create(t *testing.T) {
+	tests := []struct {
+		name      string
+		setupRepo func(*mockTagRepository)
+		tagName   string
+		wantErr   error
+	}{
+		{
+			name:      "creates tag",
+			setupRepo: func(m *mockTagRepository) {},
+			tagName:   "work",
+		},
+		{
+			name: "rejects duplicate name",
+			setupRepo: func(m *mockTagRepository) {
+				m.tags["1"] = &domain.Tag{ID: "1", Name: "work"}
+			},
+			tagName: "work",
+			wantErr: domain.ErrTagExists,
+		},
+		{
+			name: "returns error when Create fails",
+			setupRepo: func(m *mockTagRepository) {
+				m.createErr = errors.New("create error")
+			},
+			tagName: "work",
+			wantErr: errors.New("create error"),
+		},
+		{
+			name:      "rejects blank name",
+			setupRepo: func(m *mockTagRepository) {},
+			tagName:   "  ",
+			wantErr:   domain.NewValidationError("name", "name is required"),
+		},
+		{
+			name:      "rejects name over the length limit",
+			setupRepo: func(m *mockTagRepository) {},
+			tagName:   strings.Repeat("x", domain.TagNameLimit.Length+1),
+			wantErr:   domain.NewValidationError("name", "name must be at most 64 characters"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockTagRepo()
+			tt.setupRepo(repo)
+			usecase := NewTagUsecase(repo)
+
+			result, err := usecase.Create(context.Background(), tt.tagName)
+
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if result.Name != tt.tagName {
+				t.Errorf("expected name=%q, got %q", tt.tagName, result.Name)
+			}
+		})
+	}
+}
+
+func TestTagUsecase_Rename(t *testing.T) {
+	repo := newMockTagRepo()
+	repo.tags["1"] = &domain.Tag{ID: "1", Name: "work"}
+	repo.tags["2"] = &domain.Tag{ID: "2", Name: "home"}
+	usecase := NewTagUsecase(repo)
+	ctx := context.Background()
+
+	result, err := usecase.Rename(ctx, "1", "office")
+	if err != nil || result == nil || result.Name != "office" {
+		t.Errorf("expected renamed tag, got %+v, %v", result, err)
+	}
+
+	if _, err := usecase.Rename(ctx, "1", "home"); !errors.Is(err, domain.ErrTagExists) {
+		t.Errorf("expected ErrTagExists, got %v", err)
+	}
+
+	if _, err := usecase.Rename(ctx, "missing", "x"); !errors.Is(err, domain.ErrTagNotFound) {
+		t.Errorf("expected ErrTagNotFound for missing tag, got %v", err)
+	}
+}
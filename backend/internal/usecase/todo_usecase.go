@@ -3,43 +3,710 @@ package usecase
 import (
 	"backend/internal/domain"
 	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
 )
 
-// TodoUsecase handles business logic for todos
+// TodoUsecase handles business logic for todos. Operations that write more
+// than once run as one unit of work, so they apply completely or not at all.
 type TodoUsecase struct {
 	repo domain.TodoRepository
+	uow  domain.UnitOfWork
 }
 
 // NewTodoUsecase creates a new TodoUsecase
-func NewTodoUsecase(repo domain.TodoRepository) *TodoUsecase {
-	return &TodoUsecase{repo: repo}
+func NewTodoUsecase(repo domain.TodoRepository, uow domain.UnitOfWork) *TodoUsecase {
+	return &TodoUsecase{repo: repo, uow: uow}
+}
+
+// inTx runs fn with a usecase whose repository operations form one unit of
+// work, nested in the current one if u already runs in a unit of work
+func (u *TodoUsecase) inTx(ctx context.Context, fn func(tx *TodoUsecase) error) error {
+	return u.uow.Do(ctx, func(repos domain.Repositories) error {
+		return fn(&TodoUsecase{repo: repos.Todos, uow: repos.UnitOfWork})
+	})
 }
 
-// List returns all todos
-func (u *TodoUsecase) List(ctx context.Context) ([]domain.Todo, error) {
-	return u.repo.List(ctx)
+// List returns one page of the todos matching the query
+func (u *TodoUsecase) List(ctx context.Context, query domain.TodoQuery) (*domain.TodoPage, error) {
+	if query.Sort == (domain.TodoSort{}) {
+		query.Sort = domain.DefaultSort
+	}
+
+	limit := query.Page.Limit
+	if limit <= 0 {
+		limit = domain.DefaultPageLimit
+	}
+	if limit > domain.MaxPageLimit {
+		limit = domain.MaxPageLimit
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	cursor := query.Page.Cursor
+	query.Page.Limit = limit + 1
+	todos, err := u.repo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.TodoPage{}
+	backward := cursor != nil && cursor.Backward
+	hasMore := len(todos) > limit
+	if hasMore {
+		if backward {
+			// Walking backwards the extra row is the first one
+			todos = todos[1:]
+		} else {
+			todos = todos[:limit]
+		}
+	}
+	result.Todos = todos
+
+	if len(todos) == 0 {
+		return result, nil
+	}
+	if hasMore || backward {
+		result.NextCursor = domain.NewCursor(todos[len(todos)-1], query.Sort, false)
+	}
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		result.PrevCursor = domain.NewCursor(todos[0], query.Sort, true)
+	}
+	return result, nil
 }
 
-// Create creates a new todo with the given title
-func (u *TodoUsecase) Create(ctx context.Context, title string) (*domain.Todo, error) {
-	return u.repo.Create(ctx, title)
+// Create creates a new todo with the given title, optional due date and priority.
+// Subtasks join their parent's list; other todos go to the default list
+// unless params.ListID names another. A new subtask reopens its completed
+// ancestors. It returns a *domain.ValidationError for an invalid title,
+// domain.ErrSubtaskList for a subtask given a list other than its parent's,
+// domain.ErrParentNotFound when the parent does not exist and
+// domain.ErrRecurrenceWithoutDueAt for a recurring todo without a due date.
+func (u *TodoUsecase) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		var err error
+		todo, err = tx.create(ctx, params)
+		return err
+	})
+	return todo, err
 }
 
-// UpdateCompleted updates the completion status of a todo
-func (u *TodoUsecase) UpdateCompleted(ctx context.Context, id string, isCompleted bool) (*domain.Todo, error) {
-	// First get the existing todo to preserve the title
+// create is Create within the caller's unit of work
+func (u *TodoUsecase) create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
+	if err := params.Normalize(); err != nil {
+		return nil, err
+	}
+	if params.ParentID != nil {
+		parent, err := u.repo.GetByID(ctx, *params.ParentID)
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			return nil, domain.ErrParentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if params.ListID == "" {
+			params.ListID = parent.ListID
+		} else if params.ListID != parent.ListID {
+			return nil, domain.ErrSubtaskList
+		}
+	}
+	if params.ListID == "" {
+		params.ListID = domain.DefaultListID
+	}
+	if params.Recurrence != nil && params.DueAt == nil {
+		return nil, domain.ErrRecurrenceWithoutDueAt
+	}
+	position, err := u.endPosition(ctx, params.ListID)
+	if err != nil {
+		return nil, err
+	}
+	params.Position = position
+	todo, err := u.repo.Create(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	// A new todo starts out incomplete, so its ancestors cannot stay done
+	if err := u.reopenAncestors(ctx, params.ParentID); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// Get returns a todo by ID, or domain.ErrTodoNotFound if it does not exist
+func (u *TodoUsecase) Get(ctx context.Context, id string) (*domain.Todo, error) {
+	return u.repo.GetByID(ctx, id)
+}
+
+// GetSubtree returns a todo with all its subtasks nested below it,
+// or domain.ErrTodoNotFound if it does not exist
+func (u *TodoUsecase) GetSubtree(ctx context.Context, id string) (*domain.TodoTree, error) {
+	todos, err := u.repo.Subtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, domain.ErrTodoNotFound
+	}
+
+	// Subtree lists parents before their children, so every parent node
+	// exists by the time its children are attached
+	nodes := make(map[string]*domain.TodoTree, len(todos))
+	for _, todo := range todos {
+		node := &domain.TodoTree{Todo: todo, Children: []*domain.TodoTree{}}
+		nodes[todo.ID] = node
+		if todo.ID != id && todo.ParentID != nil {
+			if parent, ok := nodes[*todo.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+			}
+		}
+	}
+	return nodes[id], nil
+}
+
+// Update applies a partial update to a todo; setting ListID moves it to
+// another list together with its subtasks, and setting ParentID moves it
+// under another todo, into that todo's list.
+// Completing a todo completes all its subtasks, and reopening a subtask, or
+// moving an open todo under another parent, reopens its completed ancestors.
+// Completing an occurrence of a recurring todo creates the next occurrence,
+// which takes over the recurrence rule; for a subtask completed along with an
+// ancestor it is created next to that ancestor.
+// It returns domain.ErrTodoNotFound when the todo does not exist,
+// domain.ErrInvalidListID when the target list does not,
+// domain.ErrSubtaskList when a subtask is given a list other than its
+// parent's, domain.ErrParentNotFound or
+// domain.ErrParentCycle for an invalid parent, and
+// domain.ErrRecurrenceWithoutDueAt for a recurring todo without a due date.
+// Invalid text fields are reported as a *domain.ValidationError, and
+// domain.ErrVersionMismatch is returned when patch.IfVersion is set and the
+// todo is at another version, or when the todo changes while being updated.
+func (u *TodoUsecase) Update(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		var err error
+		todo, err = tx.update(ctx, id, patch)
+		return err
+	})
+	return todo, err
+}
+
+// update is Update within the caller's unit of work
+func (u *TodoUsecase) update(ctx context.Context, id string, patch domain.TodoPatch) (*domain.Todo, error) {
+	if err := patch.Normalize(); err != nil {
+		return nil, err
+	}
+
 	existing, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	if existing == nil {
-		return nil, nil // Not found
+	if patch.IfVersion != 0 && patch.IfVersion != existing.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	// An empty merge patch leaves the resource untouched
+	if patch.IsEmpty() {
+		return existing, nil
+	}
+
+	params := patch.Apply(*existing)
+	if params.Recurrence != nil && params.DueAt == nil {
+		return nil, domain.ErrRecurrenceWithoutDueAt
+	}
+	if params.ParentID != nil && (patch.ParentID.Set || patch.ListID.Set) {
+		parent, err := u.checkParent(ctx, id, *params.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		// A subtask lives in its parent's list and follows it there
+		if patch.ListID.Set && params.ListID != parent.ListID {
+			return nil, domain.ErrSubtaskList
+		}
+		params.ListID = parent.ListID
+	}
+	moving := params.ListID != existing.ListID
+	if moving {
+		// A todo moved to another list goes to the bottom of it
+		if params.Position, err = u.endPosition(ctx, params.ListID); err != nil {
+			return nil, err
+		}
+	}
+
+	completing := params.IsCompleted && !existing.IsCompleted
+	var rule *domain.Recurrence
+	if completing {
+		rule, params.Recurrence = params.Recurrence, nil
+	}
+
+	updated, err := u.repo.Update(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if moving {
+		if err := u.moveSubtasks(ctx, id, params.ListID); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case completing:
+		if err := u.spawnNext(ctx, params, existing.Tags, rule); err != nil {
+			return nil, err
+		}
+		if err := u.completeDescendants(ctx, id, params.ParentID); err != nil {
+			return nil, err
+		}
+		// The subtask progress changed underneath the todo
+		return u.repo.GetByID(ctx, id)
+	case !params.IsCompleted && (existing.IsCompleted || patch.ParentID.Set):
+		// Reopening a todo or moving an open one under another parent
+		// leaves its new ancestors with an open subtask
+		if err := u.reopenAncestors(ctx, updated.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// Move places a todo directly before or after another todo of its list by
+// rewriting only its own position, rebalancing the list when positions have
+// grown too long. It returns domain.ErrTodoNotFound when the todo does not exist,
+// domain.ErrMoveTargetNotFound when the other todo does not and
+// domain.ErrInvalidMoveTarget when it is the todo itself or in another list.
+func (u *TodoUsecase) Move(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		var err error
+		todo, err = tx.move(ctx, id, move)
+		return err
+	})
+	return todo, err
+}
+
+// move is Move within the caller's unit of work
+func (u *TodoUsecase) move(ctx context.Context, id string, move domain.TodoMove) (*domain.Todo, error) {
+	todo, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after := move.Before == ""
+	targetID := move.Before
+	if after {
+		targetID = move.After
+	}
+	if targetID == id {
+		return nil, domain.ErrInvalidMoveTarget
+	}
+
+	position, err := u.positionNextTo(ctx, todo, targetID, after)
+	if errors.Is(err, domain.ErrPositionOrder) || (err == nil && len(position) > domain.MaxPositionLength) {
+		// Equal or overly long neighbouring positions: spread the list out and retry
+		if err := u.rebalance(ctx, todo.ListID); err != nil {
+			return nil, err
+		}
+		position, err = u.positionNextTo(ctx, todo, targetID, after)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Reposition(ctx, id, position); err != nil {
+		return nil, err
+	}
+	return u.repo.GetByID(ctx, id)
+}
+
+// positionNextTo computes a position for todo right after (or before) the target
+func (u *TodoUsecase) positionNextTo(ctx context.Context, todo *domain.Todo, targetID string, after bool) (string, error) {
+	target, err := u.repo.GetByID(ctx, targetID)
+	if errors.Is(err, domain.ErrTodoNotFound) {
+		return "", domain.ErrMoveTargetNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if todo.ListID != target.ListID {
+		return "", domain.ErrInvalidMoveTarget
+	}
+
+	// The neighbour on the other side of the target, skipping the moving todo itself
+	order := domain.TodoSort{Field: domain.SortByPosition, Direction: domain.SortAsc}
+	neighbours, err := u.repo.List(ctx, domain.TodoQuery{
+		Filter: domain.TodoFilter{ListID: target.ListID},
+		Sort:   order,
+		Page:   domain.PageRequest{Cursor: domain.NewCursor(*target, order, !after), Limit: 2},
+	})
+	if err != nil {
+		return "", err
+	}
+	neighbour := ""
+	if after {
+		for _, t := range neighbours {
+			if t.ID != todo.ID {
+				neighbour = t.Position
+				break
+			}
+		}
+		return domain.PositionBetween(target.Position, neighbour)
+	}
+	for i := len(neighbours) - 1; i >= 0; i-- {
+		if neighbours[i].ID != todo.ID {
+			neighbour = neighbours[i].Position
+			break
+		}
+	}
+	return domain.PositionBetween(neighbour, target.Position)
+}
+
+// endPosition returns a position after every todo of the list, rebalancing
+// the list first if that position would be too long
+func (u *TodoUsecase) endPosition(ctx context.Context, listID string) (string, error) {
+	last := func() (string, error) {
+		todos, err := u.repo.List(ctx, domain.TodoQuery{
+			Filter: domain.TodoFilter{ListID: listID},
+			Sort:   domain.TodoSort{Field: domain.SortByPosition, Direction: domain.SortDesc},
+			Page:   domain.PageRequest{Limit: 1},
+		})
+		if err != nil || len(todos) == 0 {
+			return "", err
+		}
+		return todos[0].Position, nil
+	}
+
+	lastPosition, err := last()
+	if err != nil {
+		return "", err
+	}
+	position, err := domain.PositionBetween(lastPosition, "")
+	if err != nil || len(position) <= domain.MaxPositionLength {
+		return position, err
+	}
+	if err := u.rebalance(ctx, listID); err != nil {
+		return "", err
+	}
+	if lastPosition, err = last(); err != nil {
+		return "", err
+	}
+	return domain.PositionBetween(lastPosition, "")
+}
+
+// rebalance rewrites the positions of a list as short, evenly spaced keys,
+// keeping the current order
+func (u *TodoUsecase) rebalance(ctx context.Context, listID string) error {
+	todos, err := u.repo.List(ctx, domain.TodoQuery{
+		Filter: domain.TodoFilter{ListID: listID},
+		Sort:   domain.TodoSort{Field: domain.SortByPosition, Direction: domain.SortAsc},
+	})
+	if err != nil {
+		return err
+	}
+	for i, position := range domain.EvenPositions(len(todos)) {
+		if todos[i].Position == position {
+			continue
+		}
+		if err := u.repo.Reposition(ctx, todos[i].ID, position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveSubtasks moves the subtasks below id to listID, each to the bottom of it
+func (u *TodoUsecase) moveSubtasks(ctx context.Context, id string, listID string) error {
+	todos, err := u.repo.Subtree(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		if todo.ID == id || todo.ListID == listID {
+			continue
+		}
+		params := domain.TodoPatch{ListID: domain.Some(listID)}.Apply(todo)
+		if params.Position, err = u.endPosition(ctx, listID); err != nil {
+			return err
+		}
+		if _, err := u.repo.Update(ctx, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkParent returns the todo parentID after verifying that it exists and
+// is not id itself or one of its descendants
+func (u *TodoUsecase) checkParent(ctx context.Context, id string, parentID string) (*domain.Todo, error) {
+	if parentID == id {
+		return nil, domain.ErrParentCycle
+	}
+	parent, err := u.repo.GetByID(ctx, parentID)
+	if errors.Is(err, domain.ErrTodoNotFound) {
+		return nil, domain.ErrParentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Walk up from the new parent; reaching id means id is its ancestor
+	for ancestor := parent; ancestor.ParentID != nil; {
+		if *ancestor.ParentID == id {
+			return nil, domain.ErrParentCycle
+		}
+		ancestor, err = u.repo.GetByID(ctx, *ancestor.ParentID)
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parent, nil
+}
+
+// completeDescendants marks every incomplete subtask below id as completed.
+// The next occurrence of a recurring subtask cannot stay inside the completed
+// subtree, so it is created under parentID, next to the todo id itself.
+func (u *TodoUsecase) completeDescendants(ctx context.Context, id string, parentID *string) error {
+	todos, err := u.repo.Subtree(ctx, id)
+	if err != nil {
+		return err
 	}
+	for _, todo := range todos {
+		if todo.ID == id || todo.IsCompleted {
+			continue
+		}
+		params := domain.TodoPatch{IsCompleted: domain.Some(true)}.Apply(todo)
+		rule := params.Recurrence
+		params.Recurrence = nil
+		if _, err := u.repo.Update(ctx, params); err != nil {
+			return err
+		}
+		outside := params
+		outside.ParentID = parentID
+		if err := u.spawnNext(ctx, outside, todo.Tags, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return u.repo.Update(ctx, id, existing.Title, isCompleted)
+// spawnNext creates the occurrence following a just-completed one, if the
+// rule has any left. The new todo copies the completed one's details and
+// tags, is due at the next occurrence and carries the rule from then on.
+func (u *TodoUsecase) spawnNext(ctx context.Context, completed domain.UpdateTodoParams, tags []domain.Tag, rule *domain.Recurrence) error {
+	if rule == nil || completed.DueAt == nil {
+		return nil
+	}
+	dueAt, rest, ok := rule.Next(*completed.DueAt)
+	if !ok {
+		return nil
+	}
+
+	next, err := u.create(ctx, domain.CreateTodoParams{
+		Title:      completed.Title,
+		DueAt:      &dueAt,
+		Priority:   completed.Priority,
+		ListID:     completed.ListID,
+		ParentID:   completed.ParentID,
+		Recurrence: rest,
+	})
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := u.repo.AttachTag(ctx, next.ID, tag.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Delete deletes a todo by ID
+// reopenAncestors marks completed ancestors incomplete, starting at parentID,
+// since a todo cannot be done while one of its subtasks is not
+func (u *TodoUsecase) reopenAncestors(ctx context.Context, parentID *string) error {
+	for parentID != nil {
+		parent, err := u.repo.GetByID(ctx, *parentID)
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !parent.IsCompleted {
+			return nil
+		}
+		params := domain.TodoPatch{IsCompleted: domain.Some(false)}.Apply(*parent)
+		if _, err := u.repo.Update(ctx, params); err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// UpdateCompleted updates the completion status of a todo. Completing an
+// occurrence of a recurring todo creates the next occurrence.
+func (u *TodoUsecase) UpdateCompleted(ctx context.Context, id string, isCompleted bool) (*domain.Todo, error) {
+	return u.Update(ctx, id, domain.TodoPatch{IsCompleted: domain.Some(isCompleted)})
+}
+
+// UpdateDueAt sets, changes or (with a nil dueAt) clears the due date of a todo
+func (u *TodoUsecase) UpdateDueAt(ctx context.Context, id string, dueAt *time.Time) (*domain.Todo, error) {
+	return u.Update(ctx, id, domain.TodoPatch{DueAt: domain.Some(dueAt)})
+}
+
+// AttachTag labels a todo with a tag and returns the updated todo.
+// It returns domain.ErrTodoNotFound when the todo does not exist and
+// domain.ErrTagNotFound when the tag does not.
+func (u *TodoUsecase) AttachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		if _, err := tx.repo.GetByID(ctx, todoID); err != nil {
+			return err
+		}
+		if err := tx.repo.AttachTag(ctx, todoID, tagID); err != nil {
+			return err
+		}
+		var err error
+		todo, err = tx.repo.GetByID(ctx, todoID)
+		return err
+	})
+	return todo, err
+}
+
+// DetachTag removes a tag from a todo and returns the updated todo.
+// It returns domain.ErrTodoNotFound when the todo does not exist.
+func (u *TodoUsecase) DetachTag(ctx context.Context, todoID string, tagID string) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		if _, err := tx.repo.GetByID(ctx, todoID); err != nil {
+			return err
+		}
+		if err := tx.repo.DetachTag(ctx, todoID, tagID); err != nil {
+			return err
+		}
+		var err error
+		todo, err = tx.repo.GetByID(ctx, todoID)
+		return err
+	})
+	return todo, err
+}
+
+// Delete moves a todo to the trash together with all its subtasks as a single
+// trash operation, so they can be restored together. It returns
+// domain.ErrTodoNotFound if the todo does not exist or is already trashed.
 func (u *TodoUsecase) Delete(ctx context.Context, id string) error {
-	return u.repo.Delete(ctx, id)
+	return u.inTx(ctx, func(tx *TodoUsecase) error {
+		return tx.delete(ctx, id)
+	})
+}
+
+// delete is Delete within the caller's unit of work
+func (u *TodoUsecase) delete(ctx context.Context, id string) error {
+	todos, err := u.repo.Subtree(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(todos) == 0 {
+		return domain.ErrTodoNotFound
+	}
+	ids := make([]string, len(todos))
+	for i, t := range todos {
+		ids[i] = t.ID
+	}
+	// TIMESTAMP columns keep whole seconds
+	return u.repo.Trash(ctx, ids, uuid.NewString(), time.Now().UTC().Truncate(time.Second))
+}
+
+// CountByPriority returns how many todos matching the filter have each
+// priority. Every priority is present in the result, including empty ones.
+func (u *TodoUsecase) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	counts, err := u.repo.CountByPriority(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[domain.Priority]int, len(domain.Priorities))
+	for _, p := range domain.Priorities {
+		result[p] = counts[p]
+	}
+	return result, nil
+}
+
+// Search returns todos whose title matches the search, best matches first
+func (u *TodoUsecase) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	todos, err := u.repo.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	rankSearchResults(todos, search)
+	return todos, nil
+}
+
+// Match quality of a single search term, lower is better
+const (
+	matchExact = iota
+	matchPrefix
+	matchWordStart
+	matchInside
+	matchNone
+)
+
+// rankSearchResults orders todos by how well their titles match the search.
+// Ties keep the repository order (newest first).
+func rankSearchResults(todos []domain.Todo, search domain.TodoSearch) {
+	terms := search.Terms()
+	scores := make(map[string]int, len(todos))
+	for _, t := range todos {
+		scores[t.ID] = searchScore(t.Title, terms)
+	}
+	sort.SliceStable(todos, func(i, j int) bool {
+		return scores[todos[i].ID] < scores[todos[j].ID]
+	})
+}
+
+// searchScore sums the match quality of every term against title
+func searchScore(title string, terms []string) int {
+	lowerTitle := strings.ToLower(title)
+	score := 0
+	for _, term := range terms {
+		score += termMatch(lowerTitle, strings.ToLower(term))
+	}
+	return score
+}
+
+// termMatch classifies the best occurrence of term in title; both must be lower-cased
+func termMatch(title, term string) int {
+	if title == term {
+		return matchExact
+	}
+	best := matchNone
+	for offset := 0; offset <= len(title); {
+		i := strings.Index(title[offset:], term)
+		if i < 0 {
+			break
+		}
+		i += offset
+		switch {
+		case i == 0:
+			return matchPrefix
+		case isWordStart(title, i):
+			best = matchWordStart
+		default:
+			best = min(best, matchInside)
+		}
+		_, size := utf8.DecodeRuneInString(title[i:])
+		offset = i + max(size, 1)
+	}
+	return best
+}
+
+// isWordStart reports whether the byte offset i in s begins a word
+func isWordStart(s string, i int) bool {
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
 }
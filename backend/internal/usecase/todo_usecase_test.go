@@ -4,34 +4,85 @@ import (
 	"backend/internal/domain"
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
 
-// mockTodoRepository is a mock implementation of domain.TodoRepository
+// mockTodoRepository is a mock implementation of domain.TodoRepository that
+// doubles as its domain.UnitOfWork
 type mockTodoRepository struct {
 	todos       map[string]*domain.Todo
+	tags        map[string]domain.Tag
+	lists       map[string]bool
 	listErr     error
 	getByIDErr  error
 	createErr   error
 	updateErr   error
 	deleteErr   error
+	searchErr   error
+	countErr    error
 	createCount int
+	// repositionCount counts single-position writes, to check moves stay cheap
+	repositionCount int
 }
 
 func newMockRepo() *mockTodoRepository {
 	return &mockTodoRepository{
 		todos: make(map[string]*domain.Todo),
+		tags:  make(map[string]domain.Tag),
+		lists: map[string]bool{domain.DefaultListID: true},
 	}
 }
 
-func (m *mockTodoRepository) List(ctx context.Context) ([]domain.Todo, error) {
+// Do snapshots the todos and restores them when fn fails, which is all a
+// transaction or savepoint amounts to for the mock
+func (m *mockTodoRepository) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	snapshot := make(map[string]*domain.Todo, len(m.todos))
+	for id, t := range m.todos {
+		copied := *t
+		copied.Tags = append([]domain.Tag(nil), t.Tags...)
+		snapshot[id] = &copied
+	}
+	if err := fn(domain.Repositories{Todos: m, UnitOfWork: m}); err != nil {
+		m.todos = snapshot
+		return err
+	}
+	return nil
+}
+
+func (m *mockTodoRepository) List(ctx context.Context, query domain.TodoQuery) ([]domain.Todo, error) {
 	if m.listErr != nil {
 		return nil, m.listErr
 	}
 	result := make([]domain.Todo, 0, len(m.todos))
 	for _, t := range m.todos {
-		result = append(result, *t)
+		if t.DeletedAt == nil && query.Filter.Matches(*t, time.Now()) {
+			result = append(result, *t)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return query.Sort.Compare(result[i], result[j]) < 0
+	})
+	if c := query.Page.Cursor; c != nil {
+		boundary := c.Todo()
+		var kept []domain.Todo
+		for _, t := range result {
+			cmp := query.Sort.Compare(t, boundary)
+			if (c.Backward && cmp < 0) || (!c.Backward && cmp > 0) {
+				kept = append(kept, t)
+			}
+		}
+		result = kept
+		if c.Backward && len(result) > query.Page.Limit {
+			result = result[len(result)-query.Page.Limit:]
+		}
+	}
+	if query.Page.Limit > 0 && len(result) > query.Page.Limit {
+		result = result[:query.Page.Limit]
 	}
 	return result, nil
 }
@@ -41,21 +92,92 @@ func (m *mockTodoRepository) GetByID(ctx context.Context, id string) (*domain.To
 		return nil, m.getByIDErr
 	}
 	todo, ok := m.todos[id]
-	if !ok {
-		return nil, nil
+	if !ok || todo.DeletedAt != nil {
+		return nil, domain.ErrTodoNotFound
 	}
-	return todo, nil
+	// Return a copy, as a real repository would, so updates do not alias it
+	found := *todo
+	found.Subtasks = m.subtaskProgress(id)
+	return &found, nil
+}
+
+// subtaskProgress counts the direct subtasks of a todo, like the real repository does on every read
+func (m *mockTodoRepository) subtaskProgress(id string) domain.SubtaskProgress {
+	var progress domain.SubtaskProgress
+	for _, t := range m.todos {
+		if t.DeletedAt == nil && t.ParentID != nil && *t.ParentID == id {
+			progress.Total++
+			if t.IsCompleted {
+				progress.Completed++
+			}
+		}
+	}
+	return progress
 }
 
-func (m *mockTodoRepository) Create(ctx context.Context, title string) (*domain.Todo, error) {
+func (m *mockTodoRepository) GetTrashed(ctx context.Context, id string) (*domain.Todo, error) {
+	todo, ok := m.todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	found := *todo
+	return &found, nil
+}
+
+func (m *mockTodoRepository) Subtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	return m.subtree(id, false), nil
+}
+
+func (m *mockTodoRepository) TrashedSubtree(ctx context.Context, id string) ([]domain.Todo, error) {
+	return m.subtree(id, true), nil
+}
+
+// subtree walks down from id through trashed todos or through live ones
+func (m *mockTodoRepository) subtree(id string, trashed bool) []domain.Todo {
+	root, ok := m.todos[id]
+	if !ok || (root.DeletedAt != nil) != trashed {
+		return nil
+	}
+	result := []domain.Todo{*root}
+	for i := 0; i < len(result); i++ {
+		var children []domain.Todo
+		for _, t := range m.todos {
+			if (t.DeletedAt != nil) == trashed && t.ParentID != nil && *t.ParentID == result[i].ID {
+				children = append(children, *t)
+			}
+		}
+		sort.Slice(children, func(a, b int) bool { return children[a].ID < children[b].ID })
+		result = append(result, children...)
+	}
+	for i := range result {
+		result[i].Subtasks = m.subtaskProgress(result[i].ID)
+	}
+	return result
+}
+
+func (m *mockTodoRepository) Create(ctx context.Context, params domain.CreateTodoParams) (*domain.Todo, error) {
 	if m.createErr != nil {
 		return nil, m.createErr
 	}
+	if !m.lists[params.ListID] {
+		return nil, domain.ErrInvalidListID
+	}
 	m.createCount++
+	id := "test-id"
+	if m.createCount > 1 {
+		id = fmt.Sprintf("test-id-%d", m.createCount)
+	}
 	todo := &domain.Todo{
-		ID:          "test-id",
-		Title:       title,
+		ID:          id,
+		Title:       params.Title,
 		IsCompleted: false,
+		DueAt:       params.DueAt,
+		Priority:    params.Priority,
+		ListID:      params.ListID,
+		ParentID:    params.ParentID,
+		Position:    params.Position,
+		Recurrence:  params.Recurrence,
+		Version:     1,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -63,37 +185,178 @@ func (m *mockTodoRepository) Create(ctx context.Context, title string) (*domain.
 	return todo, nil
 }
 
-func (m *mockTodoRepository) Update(ctx context.Context, id string, title string, isCompleted bool) (*domain.Todo, error) {
+func (m *mockTodoRepository) Update(ctx context.Context, params domain.UpdateTodoParams) (*domain.Todo, error) {
 	if m.updateErr != nil {
 		return nil, m.updateErr
 	}
-	todo, ok := m.todos[id]
-	if !ok {
-		return nil, nil
+	todo, ok := m.todos[params.ID]
+	if !ok || todo.DeletedAt != nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	if params.ListID != todo.ListID && !m.lists[params.ListID] {
+		return nil, domain.ErrInvalidListID
+	}
+	if params.Version != todo.Version {
+		return nil, domain.ErrVersionMismatch
 	}
-	todo.Title = title
-	todo.IsCompleted = isCompleted
+	todo.Version++
+	todo.Title = params.Title
+	todo.IsCompleted = params.IsCompleted
+	todo.DueAt = params.DueAt
+	todo.Priority = params.Priority
+	todo.ListID = params.ListID
+	todo.ParentID = params.ParentID
+	todo.Position = params.Position
+	todo.Recurrence = params.Recurrence
 	todo.UpdatedAt = time.Now()
 	return todo, nil
 }
 
+func (m *mockTodoRepository) Reposition(ctx context.Context, id string, position string) error {
+	if todo, ok := m.todos[id]; ok {
+		todo.Position = position
+		m.repositionCount++
+	}
+	return nil
+}
+
 func (m *mockTodoRepository) Delete(ctx context.Context, id string) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
 	}
+	if _, ok := m.todos[id]; !ok {
+		return domain.ErrTodoNotFound
+	}
 	delete(m.todos, id)
 	return nil
 }
 
+func (m *mockTodoRepository) Trash(ctx context.Context, ids []string, trashID string, at time.Time) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	for _, id := range ids {
+		if todo, ok := m.todos[id]; ok && todo.DeletedAt == nil {
+			deletedAt := at
+			todo.DeletedAt = &deletedAt
+			todo.TrashID = trashID
+		}
+	}
+	return nil
+}
+
+func (m *mockTodoRepository) Restore(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if todo, ok := m.todos[id]; ok {
+			todo.DeletedAt = nil
+			todo.TrashID = ""
+		}
+	}
+	return nil
+}
+
+func (m *mockTodoRepository) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	var result []domain.Todo
+	for _, t := range m.todos {
+		if t.DeletedAt != nil {
+			result = append(result, *t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(*result[j].DeletedAt) {
+			return result[i].DeletedAt.After(*result[j].DeletedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (m *mockTodoRepository) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	n := 0
+	for id, t := range m.todos {
+		if t.DeletedAt != nil && (cutoff.IsZero() || t.DeletedAt.Before(cutoff)) {
+			delete(m.todos, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockTodoRepository) Search(ctx context.Context, search domain.TodoSearch) ([]domain.Todo, error) {
+	if m.searchErr != nil {
+		return nil, m.searchErr
+	}
+	var result []domain.Todo
+	for _, t := range m.todos {
+		if t.DeletedAt != nil {
+			continue
+		}
+		title := strings.ToLower(t.Title)
+		matched := true
+		for _, term := range search.Terms() {
+			term = strings.ToLower(term)
+			if search.Mode == domain.SearchModePrefix {
+				matched = matched && strings.HasPrefix(title, term)
+			} else {
+				matched = matched && strings.Contains(title, term)
+			}
+		}
+		if matched {
+			result = append(result, *t)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockTodoRepository) CountByPriority(ctx context.Context, filter domain.TodoFilter) (map[domain.Priority]int, error) {
+	if m.countErr != nil {
+		return nil, m.countErr
+	}
+	counts := make(map[domain.Priority]int)
+	for _, t := range m.todos {
+		if t.DeletedAt == nil && filter.Matches(*t, time.Now()) {
+			counts[t.Priority]++
+		}
+	}
+	return counts, nil
+}
+
+func (m *mockTodoRepository) AttachTag(ctx context.Context, todoID string, tagID string) error {
+	tag, ok := m.tags[tagID]
+	if !ok {
+		return domain.ErrTagNotFound
+	}
+	todo := m.todos[todoID]
+	for _, existing := range todo.Tags {
+		if existing.ID == tagID {
+			return nil
+		}
+	}
+	todo.Tags = append(todo.Tags, tag)
+	return nil
+}
+
+func (m *mockTodoRepository) DetachTag(ctx context.Context, todoID string, tagID string) error {
+	todo := m.todos[todoID]
+	kept := todo.Tags[:0]
+	for _, existing := range todo.Tags {
+		if existing.ID != tagID {
+			kept = append(kept, existing)
+		}
+	}
+	todo.Tags = kept
+	return nil
+}
+
 func TestTodoUsecase_UpdateCompleted(t *testing.T) {
 	tests := []struct {
-		name        string
-		setupRepo   func(*mockTodoRepository)
-		id          string
-		isCompleted bool
-		wantErr     bool
-		wantNil     bool
-		wantStatus  bool
+		name         string
+		setupRepo    func(*mockTodoRepository)
+		id           string
+		isCompleted  bool
+		wantErr      bool
+		wantNotFound bool
+		wantStatus   bool
 	}{
 		{
 			name: "successfully toggle to completed",
@@ -107,7 +370,6 @@ func TestTodoUsecase_UpdateCompleted(t *testing.T) {
 			id:          "1",
 			isCompleted: true,
 			wantErr:     false,
-			wantNil:     false,
 			wantStatus:  true,
 		},
 		{
@@ -122,16 +384,15 @@ func TestTodoUsecase_UpdateCompleted(t *testing.T) {
 			id:          "1",
 			isCompleted: false,
 			wantErr:     false,
-			wantNil:     false,
 			wantStatus:  false,
 		},
 		{
-			name:        "returns nil when todo not found",
-			setupRepo:   func(m *mockTodoRepository) {},
-			id:          "nonexistent",
-			isCompleted: true,
-			wantErr:     false,
-			wantNil:     true,
+			name:         "returns ErrTodoNotFound when todo not found",
+			setupRepo:    func(m *mockTodoRepository) {},
+			id:           "nonexistent",
+			isCompleted:  true,
+			wantErr:      false,
+			wantNotFound: true,
 		},
 		{
 			name: "returns error when GetByID fails",
@@ -162,10 +423,128 @@ func TestTodoUsecase_UpdateCompleted(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := newMockRepo()
 			tt.setupRepo(repo)
-			usecase := NewTodoUsecase(repo)
+			usecase := NewTodoUsecase(repo, repo)
 
 			result, err := usecase.UpdateCompleted(context.Background(), tt.id, tt.isCompleted)
 
+			if tt.wantNotFound {
+				if !errors.Is(err, domain.ErrTodoNotFound) {
+					t.Errorf("expected ErrTodoNotFound, got %v", err)
+				}
+				return
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if result == nil {
+				t.Error("expected non-nil result, got nil")
+				return
+			}
+
+			if result.IsCompleted != tt.wantStatus {
+				t.Errorf("expected IsCompleted=%v, got %v", tt.wantStatus, result.IsCompleted)
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_Update(t *testing.T) {
+	due := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		setupRepo    func(*mockTodoRepository)
+		id           string
+		patch        domain.TodoPatch
+		wantErr      bool
+		wantNotFound bool
+		want         domain.Todo
+	}{
+		{
+			name: "changes title and keeps other fields",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Tpyo", IsCompleted: true, DueAt: &due}
+			},
+			id:    "1",
+			patch: domain.TodoPatch{Title: domain.Some("Typo")},
+			want:  domain.Todo{ID: "1", Title: "Typo", IsCompleted: true, DueAt: &due},
+		},
+		{
+			name: "changes several fields at once",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Old"}
+			},
+			id: "1",
+			patch: domain.TodoPatch{
+				Title:       domain.Some("New"),
+				IsCompleted: domain.Some(true),
+				DueAt:       domain.Some(&due),
+			},
+			want: domain.Todo{ID: "1", Title: "New", IsCompleted: true, DueAt: &due},
+		},
+		{
+			name: "null due date clears it",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Todo", DueAt: &due}
+			},
+			id:    "1",
+			patch: domain.TodoPatch{DueAt: domain.Optional[*time.Time]{Set: true, Null: true}},
+			want:  domain.Todo{ID: "1", Title: "Todo"},
+		},
+		{
+			name: "empty patch does not write",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Todo", DueAt: &due}
+				m.updateErr = errors.New("update should not be called")
+			},
+			id:    "1",
+			patch: domain.TodoPatch{},
+			want:  domain.Todo{ID: "1", Title: "Todo", DueAt: &due},
+		},
+		{
+			name:         "returns ErrTodoNotFound when todo not found",
+			setupRepo:    func(m *mockTodoRepository) {},
+			id:           "nonexistent",
+			patch:        domain.TodoPatch{Title: domain.Some("New")},
+			wantNotFound: true,
+		},
+		{
+			name: "returns error when Update fails",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Todo"}
+				m.updateErr = errors.New("update error")
+			},
+			id:      "1",
+			patch:   domain.TodoPatch{Title: domain.Some("New")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			tt.setupRepo(repo)
+			usecase := NewTodoUsecase(repo, repo)
+
+			result, err := usecase.Update(context.Background(), tt.id, tt.patch)
+
+			if tt.wantNotFound {
+				if !errors.Is(err, domain.ErrTodoNotFound) {
+					t.Errorf("expected ErrTodoNotFound, got %v", err)
+				}
+				return
+			}
+
 			if tt.wantErr {
 				if err == nil {
 					t.Error("expected error, got nil")
@@ -178,25 +557,145 @@ func TestTodoUsecase_UpdateCompleted(t *testing.T) {
 				return
 			}
 
-			if tt.wantNil {
-				if result != nil {
-					t.Errorf("expected nil, got %+v", result)
+			if result == nil {
+				t.Error("expected non-nil result, got nil")
+				return
+			}
+
+			if result.Title != tt.want.Title {
+				t.Errorf("expected title=%q, got %q", tt.want.Title, result.Title)
+			}
+			if result.IsCompleted != tt.want.IsCompleted {
+				t.Errorf("expected IsCompleted=%v, got %v", tt.want.IsCompleted, result.IsCompleted)
+			}
+			if (result.DueAt == nil) != (tt.want.DueAt == nil) ||
+				(result.DueAt != nil && !result.DueAt.Equal(*tt.want.DueAt)) {
+				t.Errorf("expected DueAt=%v, got %v", tt.want.DueAt, result.DueAt)
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_UpdateDueAt(t *testing.T) {
+	due := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	later := due.Add(48 * time.Hour)
+
+	tests := []struct {
+		name         string
+		setupRepo    func(*mockTodoRepository)
+		id           string
+		dueAt        *time.Time
+		wantErr      bool
+		wantNotFound bool
+		wantDueAt    *time.Time
+	}{
+		{
+			name: "sets due date",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Test Todo"}
+			},
+			id:        "1",
+			dueAt:     &due,
+			wantDueAt: &due,
+		},
+		{
+			name: "changes due date",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Test Todo", DueAt: &due}
+			},
+			id:        "1",
+			dueAt:     &later,
+			wantDueAt: &later,
+		},
+		{
+			name: "clears due date",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Test Todo", DueAt: &due}
+			},
+			id:        "1",
+			dueAt:     nil,
+			wantDueAt: nil,
+		},
+		{
+			name:         "returns ErrTodoNotFound when todo not found",
+			setupRepo:    func(m *mockTodoRepository) {},
+			id:           "nonexistent",
+			dueAt:        &due,
+			wantNotFound: true,
+		},
+		{
+			name: "returns error when GetByID fails",
+			setupRepo: func(m *mockTodoRepository) {
+				m.getByIDErr = errors.New("database error")
+			},
+			id:      "1",
+			dueAt:   &due,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			tt.setupRepo(repo)
+			usecase := NewTodoUsecase(repo, repo)
+
+			result, err := usecase.UpdateDueAt(context.Background(), tt.id, tt.dueAt)
+
+			if tt.wantNotFound {
+				if !errors.Is(err, domain.ErrTodoNotFound) {
+					t.Errorf("expected ErrTodoNotFound, got %v", err)
+				}
+				return
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
 				}
 				return
 			}
 
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
 			if result == nil {
 				t.Error("expected non-nil result, got nil")
 				return
 			}
 
-			if result.IsCompleted != tt.wantStatus {
-				t.Errorf("expected IsCompleted=%v, got %v", tt.wantStatus, result.IsCompleted)
+			if result.Title != "Test Todo" {
+				t.Errorf("expected title to be preserved, got %q", result.Title)
+			}
+
+			switch {
+			case tt.wantDueAt == nil && result.DueAt != nil:
+				t.Errorf("expected DueAt=nil, got %v", *result.DueAt)
+			case tt.wantDueAt != nil && (result.DueAt == nil || !result.DueAt.Equal(*tt.wantDueAt)):
+				t.Errorf("expected DueAt=%v, got %v", *tt.wantDueAt, result.DueAt)
 			}
 		})
 	}
 }
 
+func TestTodoUsecase_UpdateCompleted_PreservesDueAt(t *testing.T) {
+	due := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	repo := newMockRepo()
+	repo.todos["1"] = &domain.Todo{ID: "1", Title: "Test Todo", DueAt: &due}
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.UpdateCompleted(context.Background(), "1", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.DueAt == nil || !result.DueAt.Equal(due) {
+		t.Errorf("expected DueAt=%v, got %v", due, result.DueAt)
+	}
+}
+
 func TestTodoUsecase_Create(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -220,15 +719,33 @@ func TestTodoUsecase_Create(t *testing.T) {
 			title:   "New Todo",
 			wantErr: true,
 		},
+		{
+			name:      "trims and normalizes the title",
+			setupRepo: func(m *mockTodoRepository) {},
+			title:     "  Cafe\u0301 menu\t",
+			wantTitle: "Caf\u00e9 menu",
+		},
+		{
+			name:      "rejects a blank title",
+			setupRepo: func(m *mockTodoRepository) {},
+			title:     " \t ",
+			wantErr:   true,
+		},
+		{
+			name:      "rejects a title over the length limit",
+			setupRepo: func(m *mockTodoRepository) {},
+			title:     strings.Repeat("a", domain.TitleLimit.Length+1),
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := newMockRepo()
 			tt.setupRepo(repo)
-			usecase := NewTodoUsecase(repo)
+			usecase := NewTodoUsecase(repo, repo)
 
-			result, err := usecase.Create(context.Background(), tt.title)
+			result, err := usecase.Create(context.Background(), domain.CreateTodoParams{Title: tt.title})
 
 			if tt.wantErr {
 				if err == nil {
@@ -284,9 +801,9 @@ func TestTodoUsecase_List(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := newMockRepo()
 			tt.setupRepo(repo)
-			usecase := NewTodoUsecase(repo)
+			usecase := NewTodoUsecase(repo, repo)
 
-			result, err := usecase.List(context.Background())
+			result, err := usecase.List(context.Background(), domain.TodoQuery{})
 
 			if tt.wantErr {
 				if err == nil {
@@ -300,43 +817,175 @@ func TestTodoUsecase_List(t *testing.T) {
 				return
 			}
 
-			if len(result) != tt.wantCount {
-				t.Errorf("expected %d todos, got %d", tt.wantCount, len(result))
+			if len(result.Todos) != tt.wantCount {
+				t.Errorf("expected %d todos, got %d", tt.wantCount, len(result.Todos))
 			}
 		})
 	}
 }
 
-func TestTodoUsecase_Delete(t *testing.T) {
-	tests := []struct {
-		name      string
-		setupRepo func(*mockTodoRepository)
-		id        string
-		wantErr   bool
-	}{
-		{
-			name: "successfully deletes todo",
-			setupRepo: func(m *mockTodoRepository) {
-				m.todos["1"] = &domain.Todo{ID: "1", Title: "Todo 1"}
-			},
-			id:      "1",
-			wantErr: false,
-		},
-		{
-			name: "returns error when Delete fails",
-			setupRepo: func(m *mockTodoRepository) {
-				m.deleteErr = errors.New("delete error")
-			},
-			id:      "1",
-			wantErr: true,
-		},
+func TestTodoUsecase_List_Pagination(t *testing.T) {
+	repo := newMockRepo()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Five todos where "c" and "d" share a timestamp to exercise the id tie-breaker
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		created := base.Add(time.Duration(i) * time.Hour)
+		if id == "d" {
+			created = base.Add(2 * time.Hour)
+		}
+		repo.todos[id] = &domain.Todo{ID: id, Title: "Todo " + id, CreatedAt: created}
 	}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repo := newMockRepo()
+	ids := func(p *domain.TodoPage) string {
+		var s []string
+		for _, t := range p.Todos {
+			s = append(s, t.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	first, err := usecase.List(ctx, domain.TodoQuery{Page: domain.PageRequest{Limit: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(first); got != "e,d" {
+		t.Errorf("first page: expected e,d, got %s", got)
+	}
+	if first.PrevCursor != nil {
+		t.Error("first page: expected no prev cursor")
+	}
+	if first.NextCursor == nil {
+		t.Fatal("first page: expected a next cursor")
+	}
+
+	second, err := usecase.List(ctx, domain.TodoQuery{Page: domain.PageRequest{Cursor: first.NextCursor, Limit: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(second); got != "c,b" {
+		t.Errorf("second page: expected c,b, got %s", got)
+	}
+	if second.NextCursor == nil || second.PrevCursor == nil {
+		t.Fatal("second page: expected next and prev cursors")
+	}
+
+	last, err := usecase.List(ctx, domain.TodoQuery{Page: domain.PageRequest{Cursor: second.NextCursor, Limit: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(last); got != "a" {
+		t.Errorf("last page: expected a, got %s", got)
+	}
+	if last.NextCursor != nil {
+		t.Error("last page: expected no next cursor")
+	}
+
+	back, err := usecase.List(ctx, domain.TodoQuery{Page: domain.PageRequest{Cursor: second.PrevCursor, Limit: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(back); got != "e,d" {
+		t.Errorf("prev of second page: expected e,d, got %s", got)
+	}
+	if back.PrevCursor != nil {
+		t.Error("prev of second page: expected no prev cursor")
+	}
+	if back.NextCursor == nil {
+		t.Error("prev of second page: expected a next cursor")
+	}
+}
+
+func TestTodoUsecase_List_SortByDueAtAcrossNulls(t *testing.T) {
+	repo := newMockRepo()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"a", "b", "c"} {
+		due := base.Add(time.Duration(i) * time.Hour)
+		repo.todos[id] = &domain.Todo{ID: id, DueAt: &due}
+	}
+	repo.todos["x"] = &domain.Todo{ID: "x"}
+	repo.todos["y"] = &domain.Todo{ID: "y"}
+	usecase := NewTodoUsecase(repo, repo)
+
+	query := domain.TodoQuery{
+		Sort: domain.TodoSort{Field: domain.SortByDueAt, Direction: domain.SortDesc},
+		Page: domain.PageRequest{Limit: 2},
+	}
+	var got []string
+	for {
+		page, err := usecase.List(context.Background(), query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, todo := range page.Todos {
+			got = append(got, todo.ID)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		query.Page.Cursor = page.NextCursor
+	}
+
+	if strings.Join(got, ",") != "c,b,a,y,x" {
+		t.Errorf("expected c,b,a,y,x, got %s", strings.Join(got, ","))
+	}
+}
+
+func TestTodoUsecase_List_ClampsLimit(t *testing.T) {
+	repo := newMockRepo()
+	for i := 0; i < domain.MaxPageLimit+5; i++ {
+		id := fmt.Sprintf("%03d", i)
+		repo.todos[id] = &domain.Todo{ID: id}
+	}
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.List(context.Background(), domain.TodoQuery{Page: domain.PageRequest{Limit: domain.MaxPageLimit + 100}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Todos) != domain.MaxPageLimit {
+		t.Errorf("expected %d todos, got %d", domain.MaxPageLimit, len(result.Todos))
+	}
+}
+
+func TestTodoUsecase_Delete(t *testing.T) {
+	tests := []struct {
+		name      string
+		setupRepo func(*mockTodoRepository)
+		id        string
+		wantErr   bool
+	}{
+		{
+			name: "successfully deletes todo",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Todo 1"}
+			},
+			id:      "1",
+			wantErr: false,
+		},
+		{
+			name:      "returns ErrTodoNotFound when todo not found",
+			setupRepo: func(m *mockTodoRepository) {},
+			id:        "missing",
+			wantErr:   true,
+		},
+		{
+			name: "returns error when Delete fails",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "Todo 1"}
+				m.deleteErr = errors.New("delete error")
+			},
+			id:      "1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
 			tt.setupRepo(repo)
-			usecase := NewTodoUsecase(repo)
+			usecase := NewTodoUsecase(repo, repo)
 
 			err := usecase.Delete(context.Background(), tt.id)
 
@@ -353,3 +1002,1116 @@ func TestTodoUsecase_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestTodoUsecase_Search(t *testing.T) {
+	tests := []struct {
+		name      string
+		setupRepo func(*mockTodoRepository)
+		search    domain.TodoSearch
+		wantErr   bool
+		wantIDs   []string
+	}{
+		{
+			name: "ranks exact, prefix, word start, then inner matches",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["inside"] = &domain.Todo{ID: "inside", Title: "Rebuy milk"}
+				m.todos["word"] = &domain.Todo{ID: "word", Title: "Go buy milk"}
+				m.todos["prefix"] = &domain.Todo{ID: "prefix", Title: "Buy milk"}
+				m.todos["exact"] = &domain.Todo{ID: "exact", Title: "buy"}
+				m.todos["other"] = &domain.Todo{ID: "other", Title: "Walk the dog"}
+			},
+			search:  domain.TodoSearch{Query: "buy", Mode: domain.SearchModeSubstring},
+			wantIDs: []string{"exact", "prefix", "word", "inside"},
+		},
+		{
+			name: "all words matches in any order",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1", Title: "milk and buy"}
+				m.todos["2"] = &domain.Todo{ID: "2", Title: "buy bread"}
+			},
+			search:  domain.TodoSearch{Query: "buy  milk", Mode: domain.SearchModeAllWords},
+			wantIDs: []string{"1"},
+		},
+		{
+			name: "returns error when Search fails",
+			setupRepo: func(m *mockTodoRepository) {
+				m.searchErr = errors.New("search error")
+			},
+			search:  domain.TodoSearch{Query: "buy", Mode: domain.SearchModeSubstring},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			tt.setupRepo(repo)
+			usecase := NewTodoUsecase(repo, repo)
+
+			result, err := usecase.Search(context.Background(), tt.search)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(result) != len(tt.wantIDs) {
+				t.Fatalf("expected %d todos, got %d", len(tt.wantIDs), len(result))
+			}
+			for i, id := range tt.wantIDs {
+				if result[i].ID != id {
+					t.Errorf("position %d: expected id=%q, got %q", i, id, result[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_CountByPriority(t *testing.T) {
+	repo := newMockRepo()
+	repo.todos["1"] = &domain.Todo{ID: "1", Priority: domain.PriorityHigh}
+	repo.todos["2"] = &domain.Todo{ID: "2", Priority: domain.PriorityHigh}
+	repo.todos["3"] = &domain.Todo{ID: "3", Priority: domain.PriorityLow, IsCompleted: true}
+	usecase := NewTodoUsecase(repo, repo)
+
+	counts, err := usecase.CountByPriority(context.Background(), domain.TodoFilter{Status: domain.StatusActive})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[domain.Priority]int{
+		domain.PriorityNone:   0,
+		domain.PriorityLow:    0,
+		domain.PriorityMedium: 0,
+		domain.PriorityHigh:   2,
+		domain.PriorityUrgent: 0,
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("expected %d priorities, got %d", len(want), len(counts))
+	}
+	for p, n := range want {
+		if counts[p] != n {
+			t.Errorf("expected %s=%d, got %d", p, n, counts[p])
+		}
+	}
+
+	repo.countErr = errors.New("count error")
+	if _, err := usecase.CountByPriority(context.Background(), domain.TodoFilter{}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestTodoUsecase_List_SortByPriority(t *testing.T) {
+	repo := newMockRepo()
+	soon := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := soon.Add(24 * time.Hour)
+	repo.todos["low"] = &domain.Todo{ID: "low", Priority: domain.PriorityLow, DueAt: &soon}
+	repo.todos["urgent-undated"] = &domain.Todo{ID: "urgent-undated", Priority: domain.PriorityUrgent}
+	repo.todos["urgent-later"] = &domain.Todo{ID: "urgent-later", Priority: domain.PriorityUrgent, DueAt: &later}
+	repo.todos["urgent-soon"] = &domain.Todo{ID: "urgent-soon", Priority: domain.PriorityUrgent, DueAt: &soon}
+	repo.todos["none"] = &domain.Todo{ID: "none"}
+	usecase := NewTodoUsecase(repo, repo)
+
+	page, err := usecase.List(context.Background(), domain.TodoQuery{
+		Sort: domain.TodoSort{Field: domain.SortByPriority, Direction: domain.SortDesc},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, todo := range page.Todos {
+		got = append(got, todo.ID)
+	}
+	want := "urgent-soon,urgent-later,urgent-undated,low,none"
+	if strings.Join(got, ",") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestTodoUsecase_AttachTag(t *testing.T) {
+	tests := []struct {
+		name         string
+		setupRepo    func(*mockTodoRepository)
+		todoID       string
+		tagID        string
+		wantErr      error
+		wantNotFound bool
+		wantTags     int
+	}{
+		{
+			name: "attaches tag",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1"}
+				m.tags["work"] = domain.Tag{ID: "work", Name: "work"}
+			},
+			todoID:   "1",
+			tagID:    "work",
+			wantTags: 1,
+		},
+		{
+			name: "attaching twice is a no-op",
+			setupRepo: func(m *mockTodoRepository) {
+				m.tags["work"] = domain.Tag{ID: "work", Name: "work"}
+				m.todos["1"] = &domain.Todo{ID: "1", Tags: []domain.Tag{m.tags["work"]}}
+			},
+			todoID:   "1",
+			tagID:    "work",
+			wantTags: 1,
+		},
+		{
+			name:         "returns ErrTodoNotFound when todo not found",
+			setupRepo:    func(m *mockTodoRepository) {},
+			todoID:       "missing",
+			tagID:        "work",
+			wantNotFound: true,
+		},
+		{
+			name: "returns ErrTagNotFound when tag not found",
+			setupRepo: func(m *mockTodoRepository) {
+				m.todos["1"] = &domain.Todo{ID: "1"}
+			},
+			todoID:  "1",
+			tagID:   "missing",
+			wantErr: domain.ErrTagNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			tt.setupRepo(repo)
+			usecase := NewTodoUsecase(repo, repo)
+
+			result, err := usecase.AttachTag(context.Background(), tt.todoID, tt.tagID)
+
+			if tt.wantNotFound {
+				if !errors.Is(err, domain.ErrTodoNotFound) {
+					t.Errorf("expected ErrTodoNotFound, got %v", err)
+				}
+				return
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(result.Tags) != tt.wantTags {
+				t.Errorf("expected %d tags, got %d", tt.wantTags, len(result.Tags))
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_DetachTag(t *testing.T) {
+	repo := newMockRepo()
+	work := domain.Tag{ID: "work", Name: "work"}
+	home := domain.Tag{ID: "home", Name: "home"}
+	repo.todos["1"] = &domain.Todo{ID: "1", Tags: []domain.Tag{work, home}}
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.DetachTag(context.Background(), "1", "work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Tags) != 1 || result.Tags[0].ID != "home" {
+		t.Errorf("expected only home tag, got %+v", result.Tags)
+	}
+
+	if _, err := usecase.DetachTag(context.Background(), "missing", "work"); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected ErrTodoNotFound for missing todo, got %v", err)
+	}
+}
+
+func TestTodoUsecase_List_TagFilter(t *testing.T) {
+	repo := newMockRepo()
+	work := domain.Tag{ID: "work", Name: "work"}
+	home := domain.Tag{ID: "home", Name: "Home"}
+	repo.todos["both"] = &domain.Todo{ID: "both", Tags: []domain.Tag{work, home}}
+	repo.todos["work"] = &domain.Todo{ID: "work", Tags: []domain.Tag{work}}
+	repo.todos["none"] = &domain.Todo{ID: "none"}
+	usecase := NewTodoUsecase(repo, repo)
+
+	tests := []struct {
+		match domain.TagMatch
+		want  int
+	}{
+		{domain.TagMatchAny, 2},
+		{domain.TagMatchAll, 1},
+	}
+	for _, tt := range tests {
+		page, err := usecase.List(context.Background(), domain.TodoQuery{
+			Filter: domain.TodoFilter{Tags: []string{"work", "home"}, TagMatch: tt.match},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Todos) != tt.want {
+			t.Errorf("%s: expected %d todos, got %d", tt.match, tt.want, len(page.Todos))
+		}
+	}
+}
+
+func TestTodoUsecase_Create_DefaultList(t *testing.T) {
+	repo := newMockRepo()
+	repo.lists["work"] = true
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	result, err := usecase.Create(ctx, domain.CreateTodoParams{Title: "No list"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ListID != domain.DefaultListID {
+		t.Errorf("expected default list, got %q", result.ListID)
+	}
+
+	result, err = usecase.Create(ctx, domain.CreateTodoParams{Title: "Work", ListID: "work"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ListID != "work" {
+		t.Errorf("expected list work, got %q", result.ListID)
+	}
+
+	if _, err := usecase.Create(ctx, domain.CreateTodoParams{Title: "Lost", ListID: "missing"}); !errors.Is(err, domain.ErrInvalidListID) {
+		t.Errorf("expected ErrInvalidListID, got %v", err)
+	}
+}
+
+func TestTodoUsecase_Update_MoveToList(t *testing.T) {
+	repo := newMockRepo()
+	repo.lists["work"] = true
+	repo.todos["1"] = &domain.Todo{ID: "1", Title: "Todo", ListID: domain.DefaultListID, Priority: domain.PriorityHigh}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	result, err := usecase.Update(ctx, "1", domain.TodoPatch{ListID: domain.Some("work")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ListID != "work" {
+		t.Errorf("expected list work, got %q", result.ListID)
+	}
+	if result.Title != "Todo" || result.Priority != domain.PriorityHigh {
+		t.Errorf("expected other fields unchanged, got %+v", result)
+	}
+
+	if _, err := usecase.Update(ctx, "1", domain.TodoPatch{ListID: domain.Some("missing")}); !errors.Is(err, domain.ErrInvalidListID) {
+		t.Errorf("expected ErrInvalidListID, got %v", err)
+	}
+
+	page, err := usecase.List(ctx, domain.TodoQuery{Filter: domain.TodoFilter{ListID: "work"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Todos) != 1 || page.Todos[0].ID != "1" {
+		t.Errorf("expected the moved todo in list work, got %+v", page.Todos)
+	}
+}
+
+// subtaskTree seeds the mock with root -> child -> grandchild and a second child
+func subtaskTree(m *mockTodoRepository) {
+	root, child := "root", "child"
+	m.todos["root"] = &domain.Todo{ID: "root", Title: "Root", ListID: "work"}
+	m.todos["child"] = &domain.Todo{ID: "child", Title: "Child", ListID: "work", ParentID: &root}
+	m.todos["child2"] = &domain.Todo{ID: "child2", Title: "Child 2", ListID: "work", ParentID: &root, IsCompleted: true}
+	m.todos["grandchild"] = &domain.Todo{ID: "grandchild", Title: "Grandchild", ListID: "work", ParentID: &child}
+	m.lists["work"] = true
+}
+
+func TestTodoUsecase_Create_Subtask(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	parentID := "root"
+	result, err := usecase.Create(ctx, domain.CreateTodoParams{Title: "Sub", ParentID: &parentID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ParentID == nil || *result.ParentID != "root" {
+		t.Errorf("expected parent root, got %v", result.ParentID)
+	}
+	if result.ListID != "work" {
+		t.Errorf("expected subtask to join the parent's list, got %q", result.ListID)
+	}
+
+	missing := "missing"
+	if _, err := usecase.Create(ctx, domain.CreateTodoParams{Title: "Orphan", ParentID: &missing}); !errors.Is(err, domain.ErrParentNotFound) {
+		t.Errorf("expected ErrParentNotFound, got %v", err)
+	}
+}
+
+func TestTodoUsecase_SubtasksStayInParentList(t *testing.T) {
+	ctx := context.Background()
+	root := "root"
+
+	t.Run("create in another list", func(t *testing.T) {
+		repo := newMockRepo()
+		subtaskTree(repo)
+		usecase := NewTodoUsecase(repo, repo)
+		_, err := usecase.Create(ctx, domain.CreateTodoParams{Title: "Sub", ListID: domain.DefaultListID, ParentID: &root})
+		if !errors.Is(err, domain.ErrSubtaskList) {
+			t.Errorf("expected ErrSubtaskList, got %v", err)
+		}
+	})
+
+	t.Run("move a subtask to another list", func(t *testing.T) {
+		repo := newMockRepo()
+		subtaskTree(repo)
+		usecase := NewTodoUsecase(repo, repo)
+		_, err := usecase.Update(ctx, "child", domain.TodoPatch{ListID: domain.Some(domain.DefaultListID)})
+		if !errors.Is(err, domain.ErrSubtaskList) {
+			t.Errorf("expected ErrSubtaskList, got %v", err)
+		}
+	})
+
+	t.Run("move under a parent in another list", func(t *testing.T) {
+		repo := newMockRepo()
+		subtaskTree(repo)
+		repo.todos["inbox"] = &domain.Todo{ID: "inbox", Title: "Inbox todo", ListID: domain.DefaultListID}
+		usecase := NewTodoUsecase(repo, repo)
+		parentID := "inbox"
+		if _, err := usecase.Update(ctx, "child", domain.TodoPatch{ParentID: domain.Some(&parentID)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, id := range []string{"child", "grandchild"} {
+			if repo.todos[id].ListID != domain.DefaultListID {
+				t.Errorf("expected %s to follow its new parent's list, got %q", id, repo.todos[id].ListID)
+			}
+		}
+	})
+
+	t.Run("move a parent to another list", func(t *testing.T) {
+		repo := newMockRepo()
+		subtaskTree(repo)
+		repo.todos["last"] = &domain.Todo{ID: "last", Title: "Last", ListID: domain.DefaultListID, Position: "x"}
+		usecase := NewTodoUsecase(repo, repo)
+		if _, err := usecase.Update(ctx, "root", domain.TodoPatch{ListID: domain.Some(domain.DefaultListID)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, id := range []string{"root", "child", "child2", "grandchild"} {
+			todo := repo.todos[id]
+			if todo.ListID != domain.DefaultListID || todo.Position <= "x" {
+				t.Errorf("expected %s at the bottom of the target list, got list %q at %q", id, todo.ListID, todo.Position)
+			}
+		}
+	})
+
+	t.Run("restore under a parent that moved", func(t *testing.T) {
+		repo := newMockRepo()
+		subtaskTree(repo)
+		usecase := NewTodoUsecase(repo, repo)
+		if err := usecase.Delete(ctx, "child"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := usecase.Update(ctx, "root", domain.TodoPatch{ListID: domain.Some(domain.DefaultListID)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := usecase.Restore(ctx, "child"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, id := range []string{"child", "grandchild"} {
+			if repo.todos[id].ListID != domain.DefaultListID {
+				t.Errorf("expected %s to rejoin its parent's list, got %q", id, repo.todos[id].ListID)
+			}
+		}
+	})
+}
+
+func TestTodoUsecase_Update_ParentCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		parentID string
+		wantErr  error
+	}{
+		{name: "under itself", id: "root", parentID: "root", wantErr: domain.ErrParentCycle},
+		{name: "under its child", id: "root", parentID: "child", wantErr: domain.ErrParentCycle},
+		{name: "under its grandchild", id: "root", parentID: "grandchild", wantErr: domain.ErrParentCycle},
+		{name: "under a missing todo", id: "child", parentID: "missing", wantErr: domain.ErrParentNotFound},
+		{name: "under a sibling", id: "child", parentID: "child2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			subtaskTree(repo)
+			usecase := NewTodoUsecase(repo, repo)
+
+			parentID := tt.parentID
+			result, err := usecase.Update(context.Background(), tt.id, domain.TodoPatch{ParentID: domain.Some(&parentID)})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.ParentID == nil || *result.ParentID != tt.parentID {
+				t.Errorf("expected parent %s, got %v", tt.parentID, result.ParentID)
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_Update_DetachFromParent(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.Update(context.Background(), "child", domain.TodoPatch{ParentID: domain.Some[*string](nil)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ParentID != nil {
+		t.Errorf("expected a top-level todo, got parent %s", *result.ParentID)
+	}
+}
+
+func TestTodoUsecase_GetSubtree(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	usecase := NewTodoUsecase(repo, repo)
+
+	tree, err := usecase.GetSubtree(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tree.ID != "root" || len(tree.Children) != 2 {
+		t.Fatalf("expected root with 2 children, got %+v", tree)
+	}
+	if tree.Subtasks != (domain.SubtaskProgress{Completed: 1, Total: 2}) {
+		t.Errorf("expected 1 of 2 subtasks done, got %+v", tree.Subtasks)
+	}
+	child := tree.Children[0]
+	if child.ID != "child" || len(child.Children) != 1 || child.Children[0].ID != "grandchild" {
+		t.Errorf("expected child with grandchild, got %+v", child)
+	}
+	if len(child.Children[0].Children) != 0 || child.Children[0].Children == nil {
+		t.Errorf("expected leaf to have an empty, non-nil children slice")
+	}
+
+	if _, err := usecase.GetSubtree(context.Background(), "missing"); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected ErrTodoNotFound for missing todo, got %v", err)
+	}
+}
+
+func TestTodoUsecase_UpdateCompleted_CascadesToSubtasks(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.UpdateCompleted(context.Background(), "root", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"root", "child", "child2", "grandchild"} {
+		if !repo.todos[id].IsCompleted {
+			t.Errorf("expected %s to be completed", id)
+		}
+	}
+	if result.Subtasks != (domain.SubtaskProgress{Completed: 2, Total: 2}) {
+		t.Errorf("expected 2 of 2 subtasks done, got %+v", result.Subtasks)
+	}
+}
+
+func TestTodoUsecase_UpdateCompleted_ReopensAncestors(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	for _, todo := range repo.todos {
+		todo.IsCompleted = true
+	}
+	usecase := NewTodoUsecase(repo, repo)
+
+	if _, err := usecase.UpdateCompleted(context.Background(), "grandchild", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, want := range map[string]bool{"grandchild": false, "child": false, "root": false, "child2": true} {
+		if repo.todos[id].IsCompleted != want {
+			t.Errorf("expected %s completed=%v, got %v", id, want, repo.todos[id].IsCompleted)
+		}
+	}
+}
+
+func TestTodoUsecase_Create_SubtaskReopensAncestors(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	for _, todo := range repo.todos {
+		todo.IsCompleted = true
+	}
+	usecase := NewTodoUsecase(repo, repo)
+
+	parentID := "child"
+	if _, err := usecase.Create(context.Background(), domain.CreateTodoParams{Title: "New", ParentID: &parentID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, want := range map[string]bool{"child": false, "root": false, "grandchild": true, "child2": true} {
+		if repo.todos[id].IsCompleted != want {
+			t.Errorf("expected %s completed=%v, got %v", id, want, repo.todos[id].IsCompleted)
+		}
+	}
+}
+
+func TestTodoUsecase_Update_ReparentReopensAncestors(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	repo.todos["root"].IsCompleted = true
+	repo.todos["child"].IsCompleted = true
+	repo.todos["grandchild"].IsCompleted = true
+	repo.todos["open"] = &domain.Todo{ID: "open", Title: "Open", ListID: "work"}
+	usecase := NewTodoUsecase(repo, repo)
+
+	parentID := "child"
+	if _, err := usecase.Update(context.Background(), "open", domain.TodoPatch{ParentID: domain.Some(&parentID)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, want := range map[string]bool{"open": false, "child": false, "root": false, "grandchild": true} {
+		if repo.todos[id].IsCompleted != want {
+			t.Errorf("expected %s completed=%v, got %v", id, want, repo.todos[id].IsCompleted)
+		}
+	}
+}
+
+func TestTodoUsecase_Delete_CascadesToSubtasks(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	repo.todos["other"] = &domain.Todo{ID: "other"}
+	usecase := NewTodoUsecase(repo, repo)
+
+	if err := usecase.Delete(context.Background(), "child"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"child", "grandchild"} {
+		if repo.todos[id].DeletedAt == nil {
+			t.Errorf("expected %s to be trashed", id)
+		}
+	}
+	if !repo.todos["child"].DeletedAt.Equal(*repo.todos["grandchild"].DeletedAt) {
+		t.Error("expected the subtree to be trashed at the same time")
+	}
+	if id := repo.todos["child"].TrashID; id == "" || repo.todos["grandchild"].TrashID != id {
+		t.Errorf("expected the subtree to share one trash operation, got %q and %q", id, repo.todos["grandchild"].TrashID)
+	}
+	for _, id := range []string{"root", "child2", "other"} {
+		if repo.todos[id].DeletedAt != nil {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+}
+
+// orderedList seeds the mock with todos a, b, c, d in that manual order
+func orderedList(m *mockTodoRepository) {
+	for i, id := range []string{"a", "b", "c", "d"} {
+		m.todos[id] = &domain.Todo{ID: id, ListID: domain.DefaultListID, Position: string(rune('1' + i))}
+	}
+	m.lists["other"] = true
+	m.todos["x"] = &domain.Todo{ID: "x", ListID: "other", Position: "V"}
+}
+
+// manualOrder returns the ids of the default list in position order
+func manualOrder(t *testing.T, usecase *TodoUsecase) string {
+	t.Helper()
+	page, err := usecase.List(context.Background(), domain.TodoQuery{
+		Filter: domain.TodoFilter{ListID: domain.DefaultListID},
+		Sort:   domain.TodoSort{Field: domain.SortByPosition, Direction: domain.SortAsc},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids string
+	for _, todo := range page.Todos {
+		ids += todo.ID
+	}
+	return ids
+}
+
+func TestTodoUsecase_Move(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		move      domain.TodoMove
+		wantOrder string
+		wantErr   error
+	}{
+		{name: "after a later todo", id: "a", move: domain.TodoMove{After: "c"}, wantOrder: "bcad"},
+		{name: "before an earlier todo", id: "d", move: domain.TodoMove{Before: "b"}, wantOrder: "adbc"},
+		{name: "to the top", id: "c", move: domain.TodoMove{Before: "a"}, wantOrder: "cabd"},
+		{name: "to the bottom", id: "a", move: domain.TodoMove{After: "d"}, wantOrder: "bcda"},
+		{name: "to where it already is", id: "b", move: domain.TodoMove{After: "a"}, wantOrder: "abcd"},
+		{name: "next to itself", id: "b", move: domain.TodoMove{After: "b"}, wantErr: domain.ErrInvalidMoveTarget},
+		{name: "next to a todo in another list", id: "b", move: domain.TodoMove{After: "x"}, wantErr: domain.ErrInvalidMoveTarget},
+		{name: "next to a missing todo", id: "b", move: domain.TodoMove{After: "missing"}, wantErr: domain.ErrMoveTargetNotFound},
+		{name: "missing todo", id: "missing", move: domain.TodoMove{After: "a"}, wantErr: domain.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			orderedList(repo)
+			usecase := NewTodoUsecase(repo, repo)
+
+			_, err := usecase.Move(context.Background(), tt.id, tt.move)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := manualOrder(t, usecase); got != tt.wantOrder {
+				t.Errorf("expected order %s, got %s", tt.wantOrder, got)
+			}
+			if repo.repositionCount != 1 {
+				t.Errorf("expected a single-row update, got %d", repo.repositionCount)
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_Move_RebalancesEqualPositions(t *testing.T) {
+	repo := newMockRepo()
+	for _, id := range []string{"a", "b", "c"} {
+		repo.todos[id] = &domain.Todo{ID: id, ListID: domain.DefaultListID, Position: "V"}
+	}
+	usecase := NewTodoUsecase(repo, repo)
+
+	// a and b share a position, so nothing fits between them until the list is spread out
+	if _, err := usecase.Move(context.Background(), "c", domain.TodoMove{After: "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := manualOrder(t, usecase); got != "acb" {
+		t.Errorf("expected order acb, got %s", got)
+	}
+}
+
+func TestTodoUsecase_Move_RebalancesLongPositions(t *testing.T) {
+	repo := newMockRepo()
+	orderedList(repo)
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	// Keep squeezing d in directly after a, then move it back to the end
+	for i := 0; i < 200; i++ {
+		if _, err := usecase.Move(ctx, "d", domain.TodoMove{After: "a"}); err != nil {
+			t.Fatalf("move %d: unexpected error: %v", i, err)
+		}
+		if _, err := usecase.Move(ctx, "b", domain.TodoMove{After: "d"}); err != nil {
+			t.Fatalf("move %d: unexpected error: %v", i, err)
+		}
+	}
+	for _, todo := range repo.todos {
+		if len(todo.Position) > domain.MaxPositionLength {
+			t.Errorf("position of %s grew to %d characters", todo.ID, len(todo.Position))
+		}
+	}
+	if got := manualOrder(t, usecase); got != "adbc" {
+		t.Errorf("expected order adbc, got %s", got)
+	}
+}
+
+func TestTodoUsecase_Create_AppendsToList(t *testing.T) {
+	repo := newMockRepo()
+	orderedList(repo)
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.Create(context.Background(), domain.CreateTodoParams{Title: "New"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Position <= repo.todos["d"].Position {
+		t.Errorf("expected new todo after %q, got %q", repo.todos["d"].Position, result.Position)
+	}
+}
+
+func TestTodoUsecase_Update_MoveToListAppends(t *testing.T) {
+	repo := newMockRepo()
+	orderedList(repo)
+	usecase := NewTodoUsecase(repo, repo)
+
+	result, err := usecase.Update(context.Background(), "a", domain.TodoPatch{ListID: domain.Some("other")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Position <= repo.todos["x"].Position {
+		t.Errorf("expected moved todo after %q, got %q", repo.todos["x"].Position, result.Position)
+	}
+}
+
+// mustRecurrence parses a rule for tests
+func mustRecurrence(t *testing.T, rule, timeZone string) *domain.Recurrence {
+	t.Helper()
+	r, err := domain.ParseRecurrence(rule, timeZone)
+	if err != nil {
+		t.Fatalf("ParseRecurrence(%q, %q): %v", rule, timeZone, err)
+	}
+	return r
+}
+
+// spawned returns the todos created by the usecase, i.e. all but the seeded "1"
+func spawned(m *mockTodoRepository) []*domain.Todo {
+	var result []*domain.Todo
+	for id, todo := range m.todos {
+		if id != "1" {
+			result = append(result, todo)
+		}
+	}
+	return result
+}
+
+func TestTodoUsecase_UpdateCompleted_SpawnsNextOccurrence(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		timeZone string
+		due      string // RFC 3339
+		wantDue  string // RFC 3339 in UTC, empty when the rule is exhausted
+		wantRule string
+	}{
+		{
+			name:     "every other day",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			due:      "2024-05-30T08:00:00Z",
+			wantDue:  "2024-06-01T08:00:00Z",
+			wantRule: "FREQ=DAILY;INTERVAL=2",
+		},
+		{
+			name:     "weekly by day wraps to next week",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			due:      "2024-05-31T08:00:00Z", // Friday
+			wantDue:  "2024-06-03T08:00:00Z", // Monday
+			wantRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		},
+		{
+			name:     "weekly by day within the week",
+			rule:     "FREQ=WEEKLY;BYDAY=FR,MO",
+			due:      "2024-05-27T08:00:00Z", // Monday
+			wantDue:  "2024-05-31T08:00:00Z", // Friday
+			wantRule: "FREQ=WEEKLY;BYDAY=FR,MO",
+		},
+		{
+			name:     "biweekly by day skips a week",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			due:      "2024-05-30T08:00:00Z", // Thursday
+			wantDue:  "2024-06-11T08:00:00Z", // Tuesday two weeks on
+			wantRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+		},
+		{
+			name:     "daily across spring DST change keeps local time",
+			rule:     "FREQ=DAILY",
+			timeZone: "America/New_York",
+			due:      "2024-03-09T09:00:00-05:00",
+			wantDue:  "2024-03-10T13:00:00Z", // 09:00 EDT
+			wantRule: "FREQ=DAILY",
+		},
+		{
+			name:     "weekly across autumn DST change keeps local time",
+			rule:     "FREQ=WEEKLY",
+			timeZone: "Europe/Berlin",
+			due:      "2024-10-21T09:00:00+02:00",
+			wantDue:  "2024-10-28T08:00:00Z", // 09:00 CET
+			wantRule: "FREQ=WEEKLY",
+		},
+		{
+			name:     "occurrence in the DST gap moves forward",
+			rule:     "FREQ=DAILY",
+			timeZone: "America/New_York",
+			due:      "2024-03-09T02:30:00-05:00",
+			wantDue:  "2024-03-10T07:30:00Z", // 02:30 does not exist; 03:30 EDT
+			wantRule: "FREQ=DAILY",
+		},
+		{
+			name:     "monthly on the 31st skips shorter months",
+			rule:     "FREQ=MONTHLY",
+			due:      "2024-01-31T08:00:00Z",
+			wantDue:  "2024-03-31T08:00:00Z",
+			wantRule: "FREQ=MONTHLY",
+		},
+		{
+			name:     "monthly on the 30th skips February",
+			rule:     "FREQ=MONTHLY",
+			due:      "2023-01-30T08:00:00Z",
+			wantDue:  "2023-03-30T08:00:00Z",
+			wantRule: "FREQ=MONTHLY",
+		},
+		{
+			name:     "yearly interval on a leap day waits for the next leap year",
+			rule:     "FREQ=MONTHLY;INTERVAL=12",
+			due:      "2024-02-29T08:00:00Z",
+			wantDue:  "2028-02-29T08:00:00Z",
+			wantRule: "FREQ=MONTHLY;INTERVAL=12",
+		},
+		{
+			name:     "monthly month-end in local time",
+			rule:     "FREQ=MONTHLY",
+			timeZone: "Asia/Tokyo",
+			due:      "2024-03-31T08:00:00+09:00", // still the 30th in UTC
+			wantDue:  "2024-05-30T23:00:00Z",      // May 31st 08:00 in Tokyo
+			wantRule: "FREQ=MONTHLY",
+		},
+		{
+			name:     "monthly across the year end",
+			rule:     "FREQ=MONTHLY",
+			due:      "2024-12-15T08:00:00Z",
+			wantDue:  "2025-01-15T08:00:00Z",
+			wantRule: "FREQ=MONTHLY",
+		},
+		{
+			name:     "count is carried down",
+			rule:     "FREQ=DAILY;COUNT=3",
+			due:      "2024-05-30T08:00:00Z",
+			wantDue:  "2024-05-31T08:00:00Z",
+			wantRule: "FREQ=DAILY;COUNT=2",
+		},
+		{
+			name: "last counted occurrence",
+			rule: "FREQ=DAILY;COUNT=1",
+			due:  "2024-05-30T08:00:00Z",
+		},
+		{
+			name:     "next occurrence on the until date",
+			rule:     "FREQ=WEEKLY;UNTIL=20240606",
+			due:      "2024-05-30T08:00:00Z",
+			wantDue:  "2024-06-06T08:00:00Z",
+			wantRule: "FREQ=WEEKLY;UNTIL=20240606T235959Z",
+		},
+		{
+			name: "next occurrence after until",
+			rule: "FREQ=WEEKLY;UNTIL=20240605T000000Z",
+			due:  "2024-05-30T08:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			due, err := time.Parse(time.RFC3339, tt.due)
+			if err != nil {
+				t.Fatal(err)
+			}
+			due = due.UTC()
+			repo.todos["1"] = &domain.Todo{
+				ID:         "1",
+				Title:      "Chore",
+				DueAt:      &due,
+				Priority:   domain.PriorityHigh,
+				ListID:     domain.DefaultListID,
+				Recurrence: mustRecurrence(t, tt.rule, tt.timeZone),
+			}
+			usecase := NewTodoUsecase(repo, repo)
+
+			result, err := usecase.UpdateCompleted(context.Background(), "1", true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.IsCompleted || result.Recurrence != nil {
+				t.Errorf("expected the occurrence completed without its rule, got %+v", result)
+			}
+
+			next := spawned(repo)
+			if tt.wantDue == "" {
+				if len(next) != 0 {
+					t.Errorf("expected no next occurrence, got due %v", next[0].DueAt)
+				}
+				return
+			}
+			if len(next) != 1 {
+				t.Fatalf("expected one next occurrence, got %d", len(next))
+			}
+			if got := next[0].DueAt.Format(time.RFC3339); got != tt.wantDue {
+				t.Errorf("expected next due %s, got %s", tt.wantDue, got)
+			}
+			if got := next[0].Recurrence.String(); got != tt.wantRule {
+				t.Errorf("expected rule %s, got %s", tt.wantRule, got)
+			}
+			if next[0].Title != "Chore" || next[0].Priority != domain.PriorityHigh || next[0].IsCompleted {
+				t.Errorf("expected an open copy of the todo, got %+v", next[0])
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_UpdateCompleted_RecurrenceOnlySpawnsOnce(t *testing.T) {
+	repo := newMockRepo()
+	repo.tags["chores"] = domain.Tag{ID: "chores", Name: "chores"}
+	due := time.Date(2024, 5, 30, 8, 0, 0, 0, time.UTC)
+	repo.todos["1"] = &domain.Todo{
+		ID:         "1",
+		Title:      "Chore",
+		DueAt:      &due,
+		ListID:     domain.DefaultListID,
+		Tags:       []domain.Tag{repo.tags["chores"]},
+		Recurrence: mustRecurrence(t, "FREQ=DAILY", ""),
+	}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	for _, completed := range []bool{true, false, true} {
+		if _, err := usecase.UpdateCompleted(ctx, "1", completed); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	next := spawned(repo)
+	if len(next) != 1 {
+		t.Fatalf("expected exactly one next occurrence, got %d", len(next))
+	}
+	if !next[0].HasTag("chores") {
+		t.Errorf("expected tags to be copied, got %+v", next[0].Tags)
+	}
+}
+
+func TestTodoUsecase_UpdateCompleted_CascadeSpawnsOutsideSubtree(t *testing.T) {
+	tests := []struct {
+		name       string
+		complete   string
+		wantParent string // empty for a top-level todo
+	}{
+		{name: "from the parent", complete: "child", wantParent: "root"},
+		{name: "from the top", complete: "root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			subtaskTree(repo)
+			due := time.Date(2024, 5, 30, 8, 0, 0, 0, time.UTC)
+			repo.todos["grandchild"].DueAt = &due
+			repo.todos["grandchild"].Recurrence = mustRecurrence(t, "FREQ=DAILY", "")
+			usecase := NewTodoUsecase(repo, repo)
+
+			if _, err := usecase.UpdateCompleted(context.Background(), tt.complete, true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			seeded := map[string]bool{"root": true, "child": true, "child2": true, "grandchild": true}
+			var next *domain.Todo
+			for id, todo := range repo.todos {
+				if !seeded[id] {
+					next = todo
+				}
+			}
+			if next == nil || next.IsCompleted || next.Recurrence == nil {
+				t.Fatalf("expected an open next occurrence, got %+v", next)
+			}
+			parent := ""
+			if next.ParentID != nil {
+				parent = *next.ParentID
+			}
+			if parent != tt.wantParent {
+				t.Errorf("expected the next occurrence under %q, got %q", tt.wantParent, parent)
+			}
+			if !repo.todos[tt.complete].IsCompleted || !repo.todos["grandchild"].IsCompleted {
+				t.Errorf("expected the completed subtree to stay completed")
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_Recurrence_RequiresDueAt(t *testing.T) {
+	repo := newMockRepo()
+	repo.todos["1"] = &domain.Todo{ID: "1", Title: "Undated", ListID: domain.DefaultListID}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+	rule := mustRecurrence(t, "FREQ=WEEKLY", "")
+
+	if _, err := usecase.Create(ctx, domain.CreateTodoParams{Title: "Chore", Recurrence: rule}); !errors.Is(err, domain.ErrRecurrenceWithoutDueAt) {
+		t.Errorf("Create: expected ErrRecurrenceWithoutDueAt, got %v", err)
+	}
+	if _, err := usecase.Update(ctx, "1", domain.TodoPatch{Recurrence: domain.Some(rule)}); !errors.Is(err, domain.ErrRecurrenceWithoutDueAt) {
+		t.Errorf("Update: expected ErrRecurrenceWithoutDueAt, got %v", err)
+	}
+}
+
+func TestTodoUsecase_Update_ValidatesTitle(t *testing.T) {
+	repo := newMockRepo()
+	repo.todos["1"] = &domain.Todo{ID: "1", Title: "Original", ListID: domain.DefaultListID}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	_, err := usecase.Update(ctx, "1", domain.TodoPatch{Title: domain.Some("   ")})
+	var invalid *domain.ValidationError
+	if !errors.As(err, &invalid) || invalid.Fields[0].Field != "title" {
+		t.Fatalf("expected a title validation error, got %v", err)
+	}
+	if repo.todos["1"].Title != "Original" {
+		t.Errorf("expected the todo to be unchanged, got %q", repo.todos["1"].Title)
+	}
+
+	result, err := usecase.Update(ctx, "1", domain.TodoPatch{Title: domain.Some(" Renamed ")})
+	if err != nil || result.Title != "Renamed" {
+		t.Errorf("expected trimmed title, got %+v, %v", result, err)
+	}
+}
+
+func TestTodoUsecase_Update_IfVersion(t *testing.T) {
+	repo := newMockRepo()
+	repo.todos["1"] = &domain.Todo{ID: "1", Title: "Original", ListID: domain.DefaultListID, Version: 3}
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	_, err := usecase.Update(ctx, "1", domain.TodoPatch{Title: domain.Some("Stale"), IfVersion: 2})
+	if !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch for a stale version, got %v", err)
+	}
+	if repo.todos["1"].Title != "Original" {
+		t.Errorf("expected the todo to be unchanged, got %q", repo.todos["1"].Title)
+	}
+
+	// An empty patch still checks the precondition
+	if _, err := usecase.Update(ctx, "1", domain.TodoPatch{IfVersion: 2}); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for an empty patch, got %v", err)
+	}
+
+	result, err := usecase.Update(ctx, "1", domain.TodoPatch{Title: domain.Some("Fresh"), IfVersion: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Title != "Fresh" || result.Version != 4 {
+		t.Errorf("expected the update at version 4, got %q at %d", result.Title, result.Version)
+	}
+}
+
+func TestTodoUsecase_UpdateCompleted_RollsBackOnFailure(t *testing.T) {
+	repo := newMockRepo()
+	due := time.Date(2024, 5, 30, 8, 0, 0, 0, time.UTC)
+	repo.todos["1"] = &domain.Todo{
+		ID:         "1",
+		Title:      "Chore",
+		DueAt:      &due,
+		ListID:     domain.DefaultListID,
+		Recurrence: mustRecurrence(t, "FREQ=DAILY", ""),
+	}
+	// Completing succeeds, creating the next occurrence then fails
+	repo.createErr = errors.New("create error")
+	usecase := NewTodoUsecase(repo, repo)
+
+	if _, err := usecase.UpdateCompleted(context.Background(), "1", true); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if repo.todos["1"].IsCompleted || repo.todos["1"].Recurrence == nil {
+		t.Errorf("expected the completion to be rolled back, got %+v", repo.todos["1"])
+	}
+}
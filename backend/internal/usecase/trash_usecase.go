@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ListTrash returns the trashed todos, most recently trashed first
+func (u *TodoUsecase) ListTrash(ctx context.Context) ([]domain.Todo, error) {
+	return u.repo.ListTrash(ctx)
+}
+
+// Restore takes a trashed todo out of the trash together with the subtasks
+// that were trashed with it. Trashed ancestors are restored as well, so the
+// todo never comes back under a parent that is still in the trash, and the
+// restored todos join the list their parent has moved to meanwhile. It
+// returns domain.ErrTodoNotFound if the todo is not in the trash.
+func (u *TodoUsecase) Restore(ctx context.Context, id string) (*domain.Todo, error) {
+	var todo *domain.Todo
+	err := u.inTx(ctx, func(tx *TodoUsecase) error {
+		var err error
+		todo, err = tx.restore(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+// restore is Restore within the caller's unit of work
+func (u *TodoUsecase) restore(ctx context.Context, id string) (*domain.Todo, error) {
+	todo, err := u.repo.GetTrashed(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subtree, err := u.repo.TrashedSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subtasks trashed on their own by another operation stay in the trash
+	ids := trashedSubtree(subtree, id, func(t *domain.Todo) bool {
+		return t.TrashID == todo.TrashID
+	})
+	top := id
+	for p := todo.ParentID; p != nil; {
+		parent, err := u.repo.GetTrashed(ctx, *p)
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, parent.ID)
+		top = parent.ID
+		p = parent.ParentID
+	}
+
+	if err := u.repo.Restore(ctx, ids); err != nil {
+		return nil, err
+	}
+	if err := u.joinParentList(ctx, top); err != nil {
+		return nil, err
+	}
+	return u.repo.GetByID(ctx, id)
+}
+
+// joinParentList moves a restored todo and its subtasks to its parent's list
+// if the parent has moved to another list while the todo was in the trash
+func (u *TodoUsecase) joinParentList(ctx context.Context, id string) error {
+	todo, err := u.repo.GetByID(ctx, id)
+	if err != nil || todo.ParentID == nil {
+		return err
+	}
+	parent, err := u.repo.GetByID(ctx, *todo.ParentID)
+	if err != nil || parent.ListID == todo.ListID {
+		return err
+	}
+	_, err = u.update(ctx, id, domain.TodoPatch{ListID: domain.Some(parent.ListID)})
+	return err
+}
+
+// Purge permanently deletes a trashed todo and its trashed subtasks. It
+// returns domain.ErrTodoNotFound if the todo is not in the trash.
+func (u *TodoUsecase) Purge(ctx context.Context, id string) error {
+	return u.inTx(ctx, func(tx *TodoUsecase) error {
+		return tx.purge(ctx, id)
+	})
+}
+
+// purge is Purge within the caller's unit of work
+func (u *TodoUsecase) purge(ctx context.Context, id string) error {
+	subtree, err := u.repo.TrashedSubtree(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(subtree) == 0 {
+		return domain.ErrTodoNotFound
+	}
+	ids := make([]string, len(subtree))
+	for i, t := range subtree {
+		ids[i] = t.ID
+	}
+	// Delete the deepest subtasks first so no todo is left pointing at a deleted parent
+	for i := len(ids) - 1; i >= 0; i-- {
+		if err := u.repo.Delete(ctx, ids[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmptyTrash permanently deletes every trashed todo and returns how many there were
+func (u *TodoUsecase) EmptyTrash(ctx context.Context) (int, error) {
+	return u.repo.PurgeTrash(ctx, time.Time{})
+}
+
+// PurgeExpired permanently deletes the todos that have been in the trash for
+// longer than retention
+func (u *TodoUsecase) PurgeExpired(ctx context.Context, retention time.Duration) (int, error) {
+	return u.repo.PurgeTrash(ctx, time.Now().Add(-retention))
+}
+
+// RunTrashPurge calls PurgeExpired every interval until ctx is done. Failures
+// are logged and retried on the next tick.
+func (u *TodoUsecase) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := u.PurgeExpired(ctx, retention)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d todos from the trash", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashedSubtree returns id followed by its descendants in trash, parents
+// first, descending only into todos accepted by include. It returns nothing
+// if id is not in trash.
+func trashedSubtree(trash []domain.Todo, id string, include func(*domain.Todo) bool) []string {
+	children := make(map[string][]*domain.Todo)
+	found := false
+	for i := range trash {
+		t := &trash[i]
+		if t.ID == id {
+			found = true
+		}
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	ids := []string{id}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if include(child) {
+				ids = append(ids, child.ID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTodoUsecase_Delete_HidesTrashedTodos(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	if err := usecase.Delete(ctx, "child"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := usecase.Get(ctx, "grandchild"); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected trashed todo to be hidden, got %v", err)
+	}
+	root, err := usecase.Get(ctx, "root")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if root.Subtasks != (domain.SubtaskProgress{Completed: 1, Total: 1}) {
+		t.Errorf("expected trashed subtasks not to count, got %+v", root.Subtasks)
+	}
+	trash, err := usecase.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trash) != 2 {
+		t.Errorf("expected 2 todos in the trash, got %d", len(trash))
+	}
+}
+
+func TestTodoUsecase_Restore(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		id           string
+		wantErr      error
+		wantRestored []string
+	}{
+		{name: "restores subtasks trashed with it", id: "root", wantRestored: []string{"root", "child", "grandchild"}},
+		{name: "restores trashed ancestors", id: "grandchild", wantRestored: []string{"root", "child", "grandchild"}},
+		{name: "keeps subtasks trashed by another operation", id: "child2", wantRestored: []string{"root", "child2"}},
+		{name: "returns ErrTodoNotFound when not in the trash", id: "other", wantErr: domain.ErrTodoNotFound},
+		{name: "returns ErrTodoNotFound when not found", id: "nonexistent", wantErr: domain.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepo()
+			subtaskTree(repo)
+			repo.todos["other"] = &domain.Todo{ID: "other"}
+			// child2 went to the trash on its own, then the rest of the tree
+			// followed within the same second
+			repo.todos["child2"].DeletedAt = &at
+			repo.todos["child2"].TrashID = "child2-trash"
+			for _, id := range []string{"root", "child", "grandchild"} {
+				repo.todos[id].DeletedAt = &at
+				repo.todos[id].TrashID = "root-trash"
+			}
+			usecase := NewTodoUsecase(repo, repo)
+
+			todo, err := usecase.Restore(context.Background(), tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if todo == nil || todo.ID != tt.id {
+				t.Fatalf("expected restored todo %s, got %+v", tt.id, todo)
+			}
+
+			restored := make(map[string]bool)
+			for _, id := range tt.wantRestored {
+				restored[id] = true
+			}
+			for id, todo := range repo.todos {
+				if id == "other" {
+					continue
+				}
+				if got := todo.DeletedAt == nil; got != restored[id] {
+					t.Errorf("%s: restored = %v, want %v", id, got, restored[id])
+				}
+			}
+		})
+	}
+}
+
+func TestTodoUsecase_Purge(t *testing.T) {
+	repo := newMockRepo()
+	subtaskTree(repo)
+	usecase := NewTodoUsecase(repo, repo)
+	ctx := context.Background()
+
+	if err := usecase.Purge(ctx, "child"); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Errorf("expected ErrTodoNotFound for a todo outside the trash, got %v", err)
+	}
+	if err := usecase.Delete(ctx, "child"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := usecase.Purge(ctx, "child"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"child", "grandchild"} {
+		if _, ok := repo.todos[id]; ok {
+			t.Errorf("expected %s to be deleted", id)
+		}
+	}
+	if _, ok := repo.todos["root"]; !ok {
+		t.Error("expected root to be kept")
+	}
+}
+
+func TestTodoUsecase_PurgeExpired(t *testing.T) {
+	repo := newMockRepo()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	repo.todos["old"] = &domain.Todo{ID: "old", DeletedAt: &old}
+	repo.todos["recent"] = &domain.Todo{ID: "recent", DeletedAt: &recent}
+	repo.todos["active"] = &domain.Todo{ID: "active"}
+	usecase := NewTodoUsecase(repo, repo)
+
+	n, err := usecase.PurgeExpired(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 purged todo, got %d", n)
+	}
+	if _, ok := repo.todos["old"]; ok {
+		t.Error("expected the expired todo to be purged")
+	}
+	for _, id := range []string{"recent", "active"} {
+		if _, ok := repo.todos[id]; !ok {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+}
@@ -1,42 +1,66 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"expvar"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
+	// Recurrence rules name IANA time zones; the runtime image has no zoneinfo
+	_ "time/tzdata"
 
 	"backend/internal/handler"
-	"backend/internal/infrastructure/db"
 	"backend/internal/usecase"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
+	repos, idempotencyRepo, closeStorage := openStorage()
+	defer closeStorage()
+
+	// Every service call the handlers make goes through the same decorators
+	decorators := serviceDecorators()
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dbUser, dbPassword, dbHost, dbPort, dbName)
+	// Setup layers (dependency injection)
+	todoUsecase := usecase.NewTodoUsecase(repos.Todos, repos.UnitOfWork)
+	todoHandler := handler.NewTodoHandler(usecase.DecorateTodoService(todoUsecase, decorators...))
 
-	database, err := db.ConnectDB(dsn)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// Trashed todos are purged for good once they are older than TRASH_RETENTION
+	// (a Go duration, 720h by default); 0 keeps them until purged by hand
+	retention := 30 * 24 * time.Hour
+	var err error
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		retention, err = time.ParseDuration(v)
+		if err != nil || retention < 0 {
+			log.Fatalf("Invalid TRASH_RETENTION %q", v)
+		}
+	}
+	if retention > 0 {
+		go todoUsecase.RunTrashPurge(context.Background(), retention, time.Hour)
 	}
-	defer database.Close()
-	log.Println("Successfully connected to database")
 
-	// Setup layers (dependency injection)
-	todoRepo := db.NewTodoRepository(database)
-	repoAdapter := db.NewTodoRepositoryAdapter(todoRepo)
-	todoUsecase := usecase.NewTodoUsecase(repoAdapter)
-	todoHandler := handler.NewTodoHandler(todoUsecase)
+	tagUsecase := usecase.NewTagUsecase(repos.Tags)
+	tagHandler := handler.NewTagHandler(usecase.DecorateTagService(tagUsecase, decorators...))
+
+	listUsecase := usecase.NewListUsecase(repos.Lists)
+	listHandler := handler.NewListHandler(usecase.DecorateListService(listUsecase, decorators...))
+
+	// Responses to requests with an Idempotency-Key are replayed to retries for
+	// IDEMPOTENCY_TTL (a Go duration, 24h by default)
+	idempotencyTTL := 24 * time.Hour
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		idempotencyTTL, err = time.ParseDuration(v)
+		if err != nil || idempotencyTTL <= 0 {
+			log.Fatalf("Invalid IDEMPOTENCY_TTL %q", v)
+		}
+	}
+	idempotencyUsecase := usecase.NewIdempotencyUsecase(idempotencyRepo, idempotencyTTL)
+	go idempotencyUsecase.RunPurge(context.Background(), time.Hour)
+	idempotency := handler.NewIdempotency(idempotencyUsecase)
 
 	// Setup router
-	router := handler.NewRouter(todoHandler)
+	router := handler.NewRouter(todoHandler, tagHandler, listHandler, idempotency)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -48,3 +72,47 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// serviceDecorators returns the decorators to wrap the services in,
+// outermost first, as the environment configures them.
+//
+// LOG_SERVICE_CALLS=true logs every call with its duration. Per-method call
+// and error counts and times are always kept, and served as JSON at
+// /debug/vars on METRICS_ADDR (such as localhost:9090) when it is set.
+// API_TOKENS, a comma-separated list of token:access pairs with access read
+// or write, makes every call require an "Authorization: Bearer <token>"
+// header granting its access; without it the API is open to everyone.
+func serviceDecorators() []usecase.Decorator {
+	var decorators []usecase.Decorator
+
+	if v := os.Getenv("LOG_SERVICE_CALLS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid LOG_SERVICE_CALLS %q", v)
+		}
+		if enabled {
+			decorators = append(decorators, usecase.Logging(log.Default()))
+		}
+	}
+
+	decorators = append(decorators, usecase.Metrics(expvar.NewMap("services")))
+	if addr := os.Getenv("METRICS_ADDR"); addr != "" {
+		go func() {
+			log.Printf("Serving metrics on %s", addr)
+			if err := http.ListenAndServe(addr, expvar.Handler()); err != nil {
+				log.Fatalf("Failed to serve metrics: %v", err)
+			}
+		}()
+	}
+
+	// Authorization goes last, so that refused calls are logged and counted
+	if v := os.Getenv("API_TOKENS"); v != "" {
+		tokens, err := usecase.ParseTokens(v)
+		if err != nil {
+			log.Fatalf("Invalid API_TOKENS: %v", err)
+		}
+		decorators = append(decorators, usecase.Authorization(tokens))
+	}
+
+	return decorators
+}
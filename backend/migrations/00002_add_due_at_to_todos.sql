@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- TIMESTAMP values are stored as UTC and converted using the session time zone,
+-- so the application connects with time_zone='+00:00' to keep due dates absolute.
+ALTER TABLE todos
+    ADD COLUMN due_at TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_todos_due_at (due_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos
+    DROP INDEX idx_todos_due_at,
+    DROP COLUMN due_at;
+-- +goose StatementEnd
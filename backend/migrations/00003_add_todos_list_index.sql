@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Supports keyset pagination over (created_at DESC, id DESC)
+CREATE INDEX idx_todos_created_at_id ON todos (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_todos_created_at_id ON todos;
+-- +goose StatementEnd
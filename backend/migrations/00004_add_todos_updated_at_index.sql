@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Supports filtering and keyset pagination on updated_at
+CREATE INDEX idx_todos_updated_at_id ON todos (updated_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_todos_updated_at_id ON todos;
+-- +goose StatementEnd
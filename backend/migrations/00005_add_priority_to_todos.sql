@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 0 = none, 1 = low, 2 = medium, 3 = high, 4 = urgent
+ALTER TABLE todos
+    ADD COLUMN priority TINYINT NOT NULL DEFAULT 0,
+    ADD INDEX idx_todos_priority_due_at (priority, due_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos
+    DROP INDEX idx_todos_priority_due_at,
+    DROP COLUMN priority;
+-- +goose StatementEnd
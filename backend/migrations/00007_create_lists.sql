@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lists (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- The default list has a fixed ID so that todos created without a list_id,
+-- including every todo that existed before lists, have somewhere to live
+-- +goose StatementBegin
+INSERT INTO lists (id, name) VALUES ('00000000-0000-0000-0000-000000000000', 'Inbox');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE todos
+    ADD COLUMN list_id CHAR(36) NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    ADD KEY idx_todos_list_id (list_id),
+    ADD CONSTRAINT fk_todos_list FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos
+    DROP FOREIGN KEY fk_todos_list,
+    DROP KEY idx_todos_list_id,
+    DROP COLUMN list_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS lists;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos
+    ADD COLUMN parent_id CHAR(36) NULL,
+    ADD KEY idx_todos_parent_id (parent_id),
+    ADD CONSTRAINT fk_todos_parent FOREIGN KEY (parent_id) REFERENCES todos (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos
+    DROP FOREIGN KEY fk_todos_parent,
+    DROP KEY idx_todos_parent_id,
+    DROP COLUMN parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Positions are compared byte by byte, so the column uses a binary collation
+-- +goose StatementBegin
+ALTER TABLE todos
+    ADD COLUMN position VARCHAR(255) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- Seed each list in its previous default order (newest first) with evenly
+-- spaced positions; the trailing 'V' keeps them free of trailing zeros
+-- +goose StatementBegin
+UPDATE todos
+JOIN (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY list_id ORDER BY created_at DESC, id DESC) AS n
+    FROM todos
+) ranked USING (id)
+SET todos.position = CONCAT(LPAD(ranked.n, 10, '0'), 'V');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_list_id_position ON todos (list_id, position, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_todos_list_id_position ON todos;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE todos DROP COLUMN position;
+-- +goose StatementEnd
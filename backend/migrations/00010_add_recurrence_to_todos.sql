@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos
+    ADD COLUMN recurrence_rule VARCHAR(255) NULL,
+    ADD COLUMN recurrence_tz VARCHAR(64) NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos
+    DROP COLUMN recurrence_rule,
+    DROP COLUMN recurrence_tz;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos
+    ADD COLUMN deleted_at TIMESTAMP NULL,
+    ADD KEY idx_todos_deleted_at (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos
+    DROP KEY idx_todos_deleted_at,
+    DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- A key is claimed with a NULL status_code while its first request runs and
+-- holds the response once it completes; either way it lapses at expires_at
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    status_code SMALLINT NULL,
+    response_header JSON NULL,
+    response_body MEDIUMBLOB NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_idempotency_keys_expires_at (expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- TIMESTAMP ends in 2038, so due dates move to DATETIME, which holds any year
+-- up to 9999. DATETIME carries no time zone: the application reads and writes
+-- it in UTC (time_zone='+00:00', loc=UTC), and the session is set to UTC here
+-- so existing values convert unchanged.
+-- +goose StatementBegin
+SET time_zone = '+00:00';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE todos
+    MODIFY COLUMN due_at DATETIME NULL DEFAULT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SET time_zone = '+00:00';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE todos
+    MODIFY COLUMN due_at TIMESTAMP NULL DEFAULT NULL;
+-- +goose StatementEnd
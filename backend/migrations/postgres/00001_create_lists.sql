@@ -0,0 +1,45 @@
+-- +goose Up
+-- The Postgres schema starts out in the shape the MySQL migrations arrived
+-- at. Timestamps keep whole seconds, as MySQL's TIMESTAMP columns do.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT date_trunc('second', now()),
+    updated_at TIMESTAMPTZ(0) NOT NULL DEFAULT date_trunc('second', now())
+);
+-- +goose StatementEnd
+
+-- The default list has a fixed ID so that todos created without a list_id
+-- have somewhere to live
+-- +goose StatementBegin
+INSERT INTO lists (id, name) VALUES ('00000000-0000-0000-0000-000000000000', 'Inbox');
+-- +goose StatementEnd
+
+-- Postgres has no ON UPDATE CURRENT_TIMESTAMP; triggers calling this
+-- function stand in for it
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := date_trunc('second', now());
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Like ON UPDATE CURRENT_TIMESTAMP, only an actual change moves updated_at
+-- +goose StatementBegin
+CREATE TRIGGER lists_updated_at
+BEFORE UPDATE OF name ON lists
+FOR EACH ROW WHEN (NEW.name IS DISTINCT FROM OLD.name)
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS lists;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS set_updated_at();
+-- +goose StatementEnd
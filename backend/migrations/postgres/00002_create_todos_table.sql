@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS todos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    is_completed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT date_trunc('second', now()),
+    updated_at TIMESTAMPTZ(0) NOT NULL DEFAULT date_trunc('second', now()),
+    due_at TIMESTAMPTZ(0),
+    -- 0 = none, 1 = low, 2 = medium, 3 = high, 4 = urgent
+    priority SMALLINT NOT NULL DEFAULT 0,
+    list_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    parent_id UUID,
+    -- Positions are compared byte by byte
+    position VARCHAR(255) COLLATE "C" NOT NULL DEFAULT '',
+    recurrence_rule VARCHAR(255),
+    recurrence_tz VARCHAR(64),
+    deleted_at TIMESTAMPTZ(0),
+    version INTEGER NOT NULL DEFAULT 1,
+    CONSTRAINT fk_todos_list FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE,
+    CONSTRAINT fk_todos_parent FOREIGN KEY (parent_id) REFERENCES todos (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_due_at ON todos (due_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_created_at_id ON todos (created_at, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_updated_at_id ON todos (updated_at, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_priority_due_at ON todos (priority, due_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_list_id_position ON todos (list_id, position, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_parent_id ON todos (parent_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_deleted_at ON todos (deleted_at);
+-- +goose StatementEnd
+
+-- The trigger fires on edits only; reordering, trashing and tagging leave
+-- the edited columns alone and so keep updated_at, as they do in MySQL
+-- +goose StatementBegin
+CREATE TRIGGER todos_updated_at
+BEFORE UPDATE OF title, is_completed, due_at, priority, list_id, parent_id, recurrence_rule, recurrence_tz ON todos
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS todos;
+-- +goose StatementEnd
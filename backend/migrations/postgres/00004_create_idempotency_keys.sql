@@ -0,0 +1,23 @@
+-- +goose Up
+-- A key is claimed with a NULL status_code while its first request runs and
+-- holds the response once it completes; either way it lapses at expires_at
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    status_code SMALLINT,
+    response_header JSONB,
+    response_body BYTEA,
+    expires_at TIMESTAMPTZ(0) NOT NULL,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT date_trunc('second', now())
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,69 @@
+-- +goose Up
+-- The SQLite schema starts out in the shape the MySQL migrations arrived at,
+-- so the columns they added to todos one by one are part of the table here.
+-- Timestamps are stored as UTC text in the driver's layout, which keeps them
+-- ordered when compared as strings.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS todos (
+    id TEXT PRIMARY KEY NOT NULL,
+    title TEXT NOT NULL COLLATE NOCASE,
+    is_completed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now')),
+    due_at DATETIME,
+    -- 0 = none, 1 = low, 2 = medium, 3 = high, 4 = urgent
+    priority INTEGER NOT NULL DEFAULT 0,
+    list_id TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000' REFERENCES lists (id) ON DELETE CASCADE,
+    parent_id TEXT REFERENCES todos (id) ON DELETE CASCADE,
+    position TEXT NOT NULL DEFAULT '',
+    recurrence_rule TEXT,
+    recurrence_tz TEXT,
+    deleted_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_due_at ON todos (due_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_created_at_id ON todos (created_at, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_updated_at_id ON todos (updated_at, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_priority_due_at ON todos (priority, due_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_list_id_position ON todos (list_id, position, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_parent_id ON todos (parent_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_todos_deleted_at ON todos (deleted_at);
+-- +goose StatementEnd
+
+-- SQLite has no ON UPDATE CURRENT_TIMESTAMP. The trigger stands in for it on
+-- edits; reordering, trashing and tagging leave the edited columns alone and
+-- so keep updated_at, as they do in MySQL.
+-- +goose StatementBegin
+CREATE TRIGGER todos_updated_at
+AFTER UPDATE OF title, is_completed, due_at, priority, list_id, parent_id, recurrence_rule, recurrence_tz ON todos
+FOR EACH ROW
+BEGIN
+    UPDATE todos SET updated_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now') WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS todos;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lists (
+    id TEXT PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- The default list has a fixed ID so that todos created without a list_id
+-- have somewhere to live
+-- +goose StatementBegin
+INSERT INTO lists (id, name) VALUES ('00000000-0000-0000-0000-000000000000', 'Inbox');
+-- +goose StatementEnd
+
+-- Like ON UPDATE CURRENT_TIMESTAMP, only an actual change moves updated_at
+-- +goose StatementBegin
+CREATE TRIGGER lists_updated_at
+AFTER UPDATE OF name ON lists
+FOR EACH ROW WHEN NEW.name IS NOT OLD.name
+BEGIN
+    UPDATE lists SET updated_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now') WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS lists;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- A key is claimed with a NULL status_code while its first request runs and
+-- holds the response once it completes; either way it lapses at expires_at
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key TEXT PRIMARY KEY NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER,
+    response_header TEXT,
+    response_body BLOB,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Each claim of a key gets its own token, so a request whose claim lapsed
+-- and was taken over can no longer complete or release its successor's
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys ADD COLUMN claim_token TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN claim_token;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Todos trashed by one operation share a trash_id, so a restore brings back
+-- exactly the subtasks trashed with a todo. Todos trashed before keep being
+-- grouped by their deletion time.
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN trash_id TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE todos SET trash_id = 'legacy-' || deleted_at WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos DROP COLUMN trash_id;
+-- +goose StatementEnd
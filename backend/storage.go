@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"backend/internal/domain"
+	"backend/internal/infrastructure/db"
+	"backend/internal/infrastructure/memory"
+	"backend/internal/infrastructure/postgres"
+	"backend/internal/infrastructure/sqlite"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sqliteMigrations are built into the binary, so a SQLite deployment needs
+// nothing besides it
+//
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// postgresMigrations are built in for the same reason; the database itself
+// has to exist already
+//
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// openStorage opens the storage the environment selects and returns its
+// repositories together with a function that closes it.
+//
+// STORAGE is database (the default) or memory, which keeps everything in the
+// process and loses it on exit. The database is named by the scheme of
+// DATABASE_URL: sqlite:<path> opens a SQLite file and postgres:// or
+// postgresql:// a PostgreSQL database, either migrated as needed. Without
+// DATABASE_URL, MySQL is reached through the DB_* variables.
+func openStorage() (domain.Repositories, domain.IdempotencyRepository, func()) {
+	storage := os.Getenv("STORAGE")
+	if storage == "" {
+		storage = "database"
+	}
+	switch storage {
+	case "database":
+	case "memory":
+		store := memory.NewStore()
+		log.Println("Using in-memory storage; data is lost when the server stops")
+		return domain.Repositories{
+			Todos:      memory.NewTodoRepository(store),
+			Tags:       memory.NewTagRepository(store),
+			Lists:      memory.NewListRepository(store),
+			UnitOfWork: store,
+		}, memory.NewIdempotencyRepository(store), func() {}
+	default:
+		log.Fatalf("Invalid STORAGE %q", storage)
+	}
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	scheme, rest, _ := strings.Cut(databaseURL, ":")
+	switch {
+	case databaseURL == "":
+		database := connectMySQL()
+		return domain.Repositories{
+			Todos:      db.NewTodoRepositoryAdapter(db.NewTodoRepository(database)),
+			Tags:       db.NewTagRepositoryAdapter(db.NewTagRepository(database)),
+			Lists:      db.NewListRepositoryAdapter(db.NewListRepository(database)),
+			UnitOfWork: db.NewUnitOfWork(database),
+		}, db.NewIdempotencyRepositoryAdapter(db.NewIdempotencyRepository(database)), func() { database.Close() }
+	case scheme == "sqlite":
+		database := connectSQLite(strings.TrimPrefix(rest, "//"))
+		return domain.Repositories{
+			Todos:      sqlite.NewTodoRepository(database),
+			Tags:       sqlite.NewTagRepository(database),
+			Lists:      sqlite.NewListRepository(database),
+			UnitOfWork: sqlite.NewUnitOfWork(database),
+		}, sqlite.NewIdempotencyRepository(database), func() { database.Close() }
+	case scheme == "postgres" || scheme == "postgresql":
+		pool := connectPostgres(databaseURL)
+		return domain.Repositories{
+			Todos:      postgres.NewTodoRepository(pool),
+			Tags:       postgres.NewTagRepository(pool),
+			Lists:      postgres.NewListRepository(pool),
+			UnitOfWork: postgres.NewUnitOfWork(pool),
+		}, postgres.NewIdempotencyRepository(pool), pool.Close
+	}
+	log.Fatalf("Unsupported DATABASE_URL scheme %q", scheme)
+	return domain.Repositories{}, nil, nil
+}
+
+// connectMySQL connects to the MySQL database named by the DB_* variables
+func connectMySQL() *sql.DB {
+	dbHost := os.Getenv("DB_HOST")
+	dbPort := os.Getenv("DB_PORT")
+	dbUser := os.Getenv("DB_USER")
+	dbPassword := os.Getenv("DB_PASSWORD")
+	dbName := os.Getenv("DB_NAME")
+
+	// Run the session in UTC so TIMESTAMP columns round-trip as absolute instants
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=UTC&time_zone=%%27%%2B00%%3A00%%27", dbUser, dbPassword, dbHost, dbPort, dbName)
+
+	database, err := db.ConnectDB(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	log.Println("Successfully connected to database")
+	return database
+}
+
+// connectSQLite opens the SQLite file at path and brings its schema up to date
+func connectSQLite(path string) *sql.DB {
+	if path == "" {
+		log.Fatal("DATABASE_URL names no SQLite file")
+	}
+	database, err := sqlite.ConnectDB(path)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	migrations, err := fs.Sub(sqliteMigrations, "migrations/sqlite")
+	if err != nil {
+		log.Fatalf("Failed to read migrations: %v", err)
+	}
+	if err := sqlite.Migrate(context.Background(), database, migrations); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	log.Printf("Successfully connected to SQLite database %s", path)
+	return database
+}
+
+// connectPostgres connects to the PostgreSQL database at url and brings its
+// schema up to date
+func connectPostgres(url string) *pgxpool.Pool {
+	ctx := context.Background()
+	pool, err := postgres.ConnectDB(ctx, url)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	migrations, err := fs.Sub(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		log.Fatalf("Failed to read migrations: %v", err)
+	}
+	if err := postgres.Migrate(ctx, pool, migrations); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	log.Println("Successfully connected to PostgreSQL database")
+	return pool
+}